@@ -10,46 +10,51 @@ import (
 	"github.com/pivotal-cf-experimental/lattice-cli/route_helpers"
 )
 
-const AppNotFoundErrorMessage = "App not found."
+const (
+	AppNotFoundErrorMessage  = "App not found."
+	CellNotFoundErrorMessage = "Cell not found."
+)
 
 type EnvironmentVariable struct {
-	Name  string
-	Value string
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
 }
 
 type AppInfo struct {
-	ProcessGuid            string
-	DesiredInstances       int
-	ActualRunningInstances int
-	Stack                  string
-	EnvironmentVariables   []EnvironmentVariable
-	StartTimeout           uint
-	DiskMB                 int
-	MemoryMB               int
-	CPUWeight              uint
-	Ports                  []uint16
-	Routes                 route_helpers.AppRoutes
-	LogGuid                string
-	LogSource              string
-	Annotation             string
-	ActualInstances        []InstanceInfo
+	ProcessGuid            string                  `json:"process_guid" yaml:"process_guid"`
+	DesiredInstances       int                     `json:"desired_instances" yaml:"desired_instances"`
+	ActualRunningInstances int                     `json:"actual_running_instances" yaml:"actual_running_instances"`
+	Stack                  string                  `json:"stack" yaml:"stack"`
+	Domain                 string                  `json:"domain" yaml:"domain"`
+	EnvironmentVariables   []EnvironmentVariable   `json:"environment_variables" yaml:"environment_variables"`
+	StartTimeout           uint                    `json:"start_timeout" yaml:"start_timeout"`
+	DiskMB                 int                     `json:"disk_mb" yaml:"disk_mb"`
+	MemoryMB               int                     `json:"memory_mb" yaml:"memory_mb"`
+	CPUWeight              uint                    `json:"cpu_weight" yaml:"cpu_weight"`
+	Privileged             bool                    `json:"privileged" yaml:"privileged"`
+	Ports                  []uint16                `json:"ports" yaml:"ports"`
+	Routes                 route_helpers.AppRoutes `json:"routes" yaml:"routes"`
+	LogGuid                string                  `json:"log_guid" yaml:"log_guid"`
+	LogSource              string                  `json:"log_source" yaml:"log_source"`
+	Annotation             string                  `json:"annotation" yaml:"annotation"`
+	ActualInstances        []InstanceInfo          `json:"actual_instances" yaml:"actual_instances"`
 }
 
 type PortMapping struct {
-	HostPort      uint16
-	ContainerPort uint16
+	HostPort      uint16 `json:"host_port" yaml:"host_port"`
+	ContainerPort uint16 `json:"container_port" yaml:"container_port"`
 }
 
 type InstanceInfo struct {
-	InstanceGuid   string
-	CellID         string
-	Index          int
-	Ip             string
-	Ports          []PortMapping
-	State          string
-	Since          int64
-	PlacementError string
-	CrashCount     int
+	InstanceGuid   string        `json:"instance_guid" yaml:"instance_guid"`
+	CellID         string        `json:"cell_id" yaml:"cell_id"`
+	Index          int           `json:"index" yaml:"index"`
+	Ip             string        `json:"ip" yaml:"ip"`
+	Ports          []PortMapping `json:"ports" yaml:"ports"`
+	State          string        `json:"state" yaml:"state"`
+	Since          int64         `json:"since" yaml:"since"`
+	PlacementError string        `json:"placement_error" yaml:"placement_error"`
+	CrashCount     int           `json:"crash_count" yaml:"crash_count"`
 }
 
 type instanceInfoSortableByIndex []InstanceInfo
@@ -67,15 +72,51 @@ func (x instanceInfoSortableByIndex) Swap(i, j int) {
 }
 
 type CellInfo struct {
-	CellID           string
-	RunningInstances int
-	ClaimedInstances int
-	Missing          bool
+	CellID              string             `json:"cell_id" yaml:"cell_id"`
+	Zone                string             `json:"zone" yaml:"zone"`
+	RunningInstances    int                `json:"running_instances" yaml:"running_instances"`
+	ClaimedInstances    int                `json:"claimed_instances" yaml:"claimed_instances"`
+	Missing             bool               `json:"missing" yaml:"missing"`
+	MemoryMB            int                `json:"memory_mb" yaml:"memory_mb"`
+	DiskMB              int                `json:"disk_mb" yaml:"disk_mb"`
+	Containers          int                `json:"containers" yaml:"containers"`
+	RemainingMemoryMB   int                `json:"remaining_memory_mb" yaml:"remaining_memory_mb"`
+	RemainingDiskMB     int                `json:"remaining_disk_mb" yaml:"remaining_disk_mb"`
+	RemainingContainers int                `json:"remaining_containers" yaml:"remaining_containers"`
+	Instances           []CellInstanceInfo `json:"instances" yaml:"instances"`
+}
+
+// CellInstanceInfo is an app instance placed on a cell, with the resources it reserves.
+type CellInstanceInfo struct {
+	ProcessGuid string `json:"process_guid" yaml:"process_guid"`
+	Index       int    `json:"index" yaml:"index"`
+	State       string `json:"state" yaml:"state"`
+	MemoryMB    int    `json:"memory_mb" yaml:"memory_mb"`
+	DiskMB      int    `json:"disk_mb" yaml:"disk_mb"`
+}
+
+type cellInstanceInfoSortableByApp []CellInstanceInfo
+
+func (x cellInstanceInfoSortableByApp) Len() int {
+	return len(x)
+}
+
+func (x cellInstanceInfoSortableByApp) Less(i, j int) bool {
+	if x[i].ProcessGuid != x[j].ProcessGuid {
+		return x[i].ProcessGuid < x[j].ProcessGuid
+	}
+	return x[i].Index < x[j].Index
+}
+
+func (x cellInstanceInfoSortableByApp) Swap(i, j int) {
+	x[i], x[j] = x[j], x[i]
 }
 
 type AppExaminer interface {
 	ListApps() ([]AppInfo, error)
 	ListCells() ([]CellInfo, error)
+	ListDistribution() ([]CellInfo, []AppInfo, error)
+	CellStatus(cellID string) (CellInfo, error)
 	AppStatus(appName string) (AppInfo, error)
 }
 
@@ -88,39 +129,119 @@ func New(receptorClient receptor.Client) *appExaminer {
 }
 
 func (e *appExaminer) ListCells() ([]CellInfo, error) {
-	allCells := make(map[string]*CellInfo)
 	cellList, err := e.receptorClient.Cells()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, cell := range cellList {
-		allCells[cell.CellID] = &CellInfo{CellID: cell.CellID}
+	actualLRPs, err := e.receptorClient.ActualLRPs()
+	if err != nil {
+		return nil, err
 	}
 
-	actualLRPs, err := e.receptorClient.ActualLRPs()
+	desiredLRPs, err := e.receptorClient.DesiredLRPs()
 	if err != nil {
 		return nil, err
 	}
 
+	return buildCells(cellList, desiredLRPs, actualLRPs), nil
+}
+
+// ListDistribution returns the cells and the apps built from a single fetch of the
+// LRPs, so that both views describe the same moment.
+func (e *appExaminer) ListDistribution() ([]CellInfo, []AppInfo, error) {
+	cellList, err := e.receptorClient.Cells()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actualLRPs, err := e.receptorClient.ActualLRPs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desiredLRPs, err := e.receptorClient.DesiredLRPs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cells := buildCells(cellList, desiredLRPs, actualLRPs)
+	apps := sortApps(mergeDesiredActualLRPs(desiredLRPs, actualLRPs))
+	return cells, apps, nil
+}
+
+func buildCells(cellList []receptor.CellResponse, desiredLRPs []receptor.DesiredLRPResponse, actualLRPs []receptor.ActualLRPResponse) []CellInfo {
+	allCells := make(map[string]*CellInfo)
+	for _, cell := range cellList {
+		allCells[cell.CellID] = &CellInfo{
+			CellID:              cell.CellID,
+			Zone:                cell.Zone,
+			MemoryMB:            cell.Capacity.MemoryMB,
+			DiskMB:              cell.Capacity.DiskMB,
+			Containers:          cell.Capacity.Containers,
+			RemainingMemoryMB:   cell.Capacity.MemoryMB,
+			RemainingDiskMB:     cell.Capacity.DiskMB,
+			RemainingContainers: cell.Capacity.Containers,
+		}
+	}
+
+	desiredLRPsByProcessGuid := make(map[string]receptor.DesiredLRPResponse)
+	for _, desiredLRP := range desiredLRPs {
+		desiredLRPsByProcessGuid[desiredLRP.ProcessGuid] = desiredLRP
+	}
+
 	for _, actualLRP := range actualLRPs {
 		if actualLRP.State == receptor.ActualLRPStateUnclaimed {
 			continue
 		}
 
-		_, ok := allCells[actualLRP.CellID]
+		cell, ok := allCells[actualLRP.CellID]
 		if !ok {
-			allCells[actualLRP.CellID] = &CellInfo{CellID: actualLRP.CellID, Missing: true}
+			cell = &CellInfo{CellID: actualLRP.CellID, Missing: true}
+			allCells[actualLRP.CellID] = cell
 		}
 
 		if actualLRP.State == receptor.ActualLRPStateRunning {
-			allCells[actualLRP.CellID].RunningInstances++
+			cell.RunningInstances++
 		} else if actualLRP.State == receptor.ActualLRPStateClaimed {
-			allCells[actualLRP.CellID].ClaimedInstances++
+			cell.ClaimedInstances++
+		} else {
+			continue
+		}
+
+		desiredLRP := desiredLRPsByProcessGuid[actualLRP.ProcessGuid]
+		cell.Instances = append(cell.Instances, CellInstanceInfo{
+			ProcessGuid: actualLRP.ProcessGuid,
+			Index:       actualLRP.Index,
+			State:       string(actualLRP.State),
+			MemoryMB:    desiredLRP.MemoryMB,
+			DiskMB:      desiredLRP.DiskMB,
+		})
+		cell.RemainingMemoryMB -= desiredLRP.MemoryMB
+		cell.RemainingDiskMB -= desiredLRP.DiskMB
+		cell.RemainingContainers--
+	}
+
+	for _, cell := range allCells {
+		sort.Sort(cellInstanceInfoSortableByApp(cell.Instances))
+	}
+
+	return sortCells(allCells)
+}
+
+func (e *appExaminer) CellStatus(cellID string) (CellInfo, error) {
+	cells, err := e.ListCells()
+	if err != nil {
+		return CellInfo{}, err
+	}
+
+	for _, cell := range cells {
+		if cell.CellID == cellID {
+			return cell, nil
 		}
 	}
 
-	return sortCells(allCells), nil
+	return CellInfo{}, errors.New(CellNotFoundErrorMessage)
 }
 
 func (e *appExaminer) ListApps() ([]AppInfo, error) {
@@ -172,17 +293,19 @@ func mergeDesiredActualLRPs(desiredLRPs []receptor.DesiredLRPResponse, actualLRP
 			ProcessGuid:            desiredLRP.ProcessGuid,
 			DesiredInstances:       desiredLRP.Instances,
 			ActualRunningInstances: 0,
-			Stack:                desiredLRP.Stack,
-			EnvironmentVariables: buildEnvVars(desiredLRP),
-			StartTimeout:         desiredLRP.StartTimeout,
-			DiskMB:               desiredLRP.DiskMB,
-			MemoryMB:             desiredLRP.MemoryMB,
-			CPUWeight:            desiredLRP.CPUWeight,
-			Ports:                desiredLRP.Ports,
-			Routes:               route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes),
-			LogGuid:              desiredLRP.LogGuid,
-			LogSource:            desiredLRP.LogSource,
-			Annotation:           desiredLRP.Annotation,
+			Stack:                  desiredLRP.Stack,
+			Domain:                 desiredLRP.Domain,
+			EnvironmentVariables:   buildEnvVars(desiredLRP),
+			StartTimeout:           desiredLRP.StartTimeout,
+			DiskMB:                 desiredLRP.DiskMB,
+			MemoryMB:               desiredLRP.MemoryMB,
+			CPUWeight:              desiredLRP.CPUWeight,
+			Privileged:             desiredLRP.Privileged,
+			Ports:                  desiredLRP.Ports,
+			Routes:                 route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes),
+			LogGuid:                desiredLRP.LogGuid,
+			LogSource:              desiredLRP.LogSource,
+			Annotation:             desiredLRP.Annotation,
 		}
 	}
 
@@ -196,6 +196,56 @@ var _ = Describe("AppRunner", func() {
 			})
 		})
 
+		Context("receptor returns the capacity of the cells", func() {
+			BeforeEach(func() {
+				fakeReceptorClient.CellsReturns([]receptor.CellResponse{
+					receptor.CellResponse{
+						CellID:   "Cell-1",
+						Zone:     "z1",
+						Capacity: receptor.CellCapacity{MemoryMB: 4096, DiskMB: 20480, Containers: 250},
+					},
+				}, nil)
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "process2-scalding-pony", MemoryMB: 256, DiskMB: 1024},
+					receptor.DesiredLRPResponse{ProcessGuid: "process1-hot-coffee", MemoryMB: 128, DiskMB: 512},
+				}, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+					receptor.ActualLRPResponse{ProcessGuid: "process2-scalding-pony", Index: 0, CellID: "Cell-1", State: receptor.ActualLRPStateRunning},
+					receptor.ActualLRPResponse{ProcessGuid: "process1-hot-coffee", Index: 1, CellID: "Cell-1", State: receptor.ActualLRPStateClaimed},
+					receptor.ActualLRPResponse{ProcessGuid: "process1-hot-coffee", Index: 0, CellID: "Cell-1", State: receptor.ActualLRPStateRunning},
+					receptor.ActualLRPResponse{ProcessGuid: "process1-hot-coffee", Index: 2, CellID: "Cell-1", State: receptor.ActualLRPStateCrashed},
+				}, nil)
+			})
+
+			It("returns the zone, capacity and the instances placed on each cell", func() {
+				cellList, err := appExaminer.ListCells()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cellList).To(HaveLen(1))
+
+				cell := cellList[0]
+				Expect(cell.Zone).To(Equal("z1"))
+				Expect(cell.MemoryMB).To(Equal(4096))
+				Expect(cell.DiskMB).To(Equal(20480))
+				Expect(cell.Containers).To(Equal(250))
+				Expect(cell.RemainingMemoryMB).To(Equal(4096 - 256 - 128 - 128))
+				Expect(cell.RemainingDiskMB).To(Equal(20480 - 1024 - 512 - 512))
+				Expect(cell.RemainingContainers).To(Equal(247))
+				Expect(cell.Instances).To(Equal([]app_examiner.CellInstanceInfo{
+					app_examiner.CellInstanceInfo{ProcessGuid: "process1-hot-coffee", Index: 0, State: "RUNNING", MemoryMB: 128, DiskMB: 512},
+					app_examiner.CellInstanceInfo{ProcessGuid: "process1-hot-coffee", Index: 1, State: "CLAIMED", MemoryMB: 128, DiskMB: 512},
+					app_examiner.CellInstanceInfo{ProcessGuid: "process2-scalding-pony", Index: 0, State: "RUNNING", MemoryMB: 256, DiskMB: 1024},
+				}))
+			})
+
+			It("returns errors from fetching the DesiredLRPs", func() {
+				fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("Receptor is lost."))
+				_, err := appExaminer.ListCells()
+
+				Expect(err).To(MatchError("Receptor is lost."))
+			})
+		})
+
 		Context("receptor returns unclaimed actual lrps", func() {
 			BeforeEach(func() {
 				actualLrps := []receptor.ActualLRPResponse{
@@ -234,6 +284,97 @@ var _ = Describe("AppRunner", func() {
 		})
 	})
 
+	Describe("ListDistribution", func() {
+		BeforeEach(func() {
+			fakeReceptorClient.CellsReturns([]receptor.CellResponse{receptor.CellResponse{CellID: "cell-1"}}, nil)
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+				receptor.DesiredLRPResponse{ProcessGuid: "app-a", Instances: 2, MemoryMB: 128},
+			}, nil)
+			fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+				receptor.ActualLRPResponse{ProcessGuid: "app-a", Index: 0, CellID: "cell-1", State: receptor.ActualLRPStateRunning},
+				receptor.ActualLRPResponse{ProcessGuid: "app-a", Index: 1, State: receptor.ActualLRPStateUnclaimed, PlacementError: "insufficient resources"},
+			}, nil)
+		})
+
+		It("builds the cells and the apps from a single fetch of the LRPs", func() {
+			cells, apps, err := appExaminer.ListDistribution()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeReceptorClient.CellsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(1))
+
+			Expect(cells).To(HaveLen(1))
+			Expect(cells[0].CellID).To(Equal("cell-1"))
+			Expect(cells[0].RunningInstances).To(Equal(1))
+			Expect(cells[0].Instances).To(Equal([]app_examiner.CellInstanceInfo{
+				app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: 0, State: "RUNNING", MemoryMB: 128},
+			}))
+
+			Expect(apps).To(HaveLen(1))
+			Expect(apps[0].ProcessGuid).To(Equal("app-a"))
+			Expect(apps[0].ActualInstances).To(HaveLen(2))
+			Expect(apps[0].ActualInstances[1].PlacementError).To(Equal("insufficient resources"))
+		})
+
+		It("returns errors from fetching the Cells", func() {
+			fakeReceptorClient.CellsReturns(nil, errors.New("You should go catch it."))
+			_, _, err := appExaminer.ListDistribution()
+
+			Expect(err).To(MatchError("You should go catch it."))
+		})
+
+		It("returns errors from fetching the ActualLRPs", func() {
+			fakeReceptorClient.ActualLRPsReturns(nil, errors.New("Receptor is Running."))
+			_, _, err := appExaminer.ListDistribution()
+
+			Expect(err).To(MatchError("Receptor is Running."))
+		})
+
+		It("returns errors from fetching the DesiredLRPs", func() {
+			fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("Receptor is lost."))
+			_, _, err := appExaminer.ListDistribution()
+
+			Expect(err).To(MatchError("Receptor is lost."))
+		})
+	})
+
+	Describe("CellStatus", func() {
+		BeforeEach(func() {
+			fakeReceptorClient.CellsReturns([]receptor.CellResponse{
+				receptor.CellResponse{CellID: "Cell-1", Zone: "z1"},
+				receptor.CellResponse{CellID: "Cell-2", Zone: "z2"},
+			}, nil)
+			fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+				receptor.ActualLRPResponse{ProcessGuid: "process1-hot-coffee", CellID: "Cell-2", State: receptor.ActualLRPStateRunning},
+			}, nil)
+		})
+
+		It("returns the cell with the given id", func() {
+			cell, err := appExaminer.CellStatus("Cell-2")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cell.CellID).To(Equal("Cell-2"))
+			Expect(cell.Zone).To(Equal("z2"))
+			Expect(cell.RunningInstances).To(Equal(1))
+			Expect(cell.Instances[0].ProcessGuid).To(Equal("process1-hot-coffee"))
+		})
+
+		It("returns an error for unknown cells", func() {
+			_, err := appExaminer.CellStatus("Cell-3")
+
+			Expect(err).To(MatchError(app_examiner.CellNotFoundErrorMessage))
+		})
+
+		It("returns errors from the receptor", func() {
+			fakeReceptorClient.CellsReturns(nil, errors.New("Receptor is on fire!!"))
+
+			_, err := appExaminer.CellStatus("Cell-1")
+
+			Expect(err).To(MatchError("Receptor is on fire!!"))
+		})
+	})
+
 	Describe("AppStatus", func() {
 
 		Context("When receptor successfully responds to all requests", func() {
@@ -262,6 +403,7 @@ var _ = Describe("AppRunner", func() {
 					DiskMB:       256,
 					MemoryMB:     128,
 					CPUWeight:    77,
+					Privileged:   true,
 					Ports:        []uint16{8765, 2300},
 					Routes:       route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"peekaboo-one.example.com", "peekaboo-too.example.com"}}}.RoutingInfo(),
 					LogGuid:      "9832-ur98j-idsckl",
@@ -327,7 +469,8 @@ var _ = Describe("AppRunner", func() {
 					ProcessGuid:            "peekaboo-app",
 					DesiredInstances:       4,
 					ActualRunningInstances: 1,
-					Stack: "lucid99",
+					Stack:  "lucid99",
+					Domain: "welp.org",
 					EnvironmentVariables: []app_examiner.EnvironmentVariable{
 						app_examiner.EnvironmentVariable{
 							Name:  "API_TOKEN",
@@ -342,6 +485,7 @@ var _ = Describe("AppRunner", func() {
 					DiskMB:       256,
 					MemoryMB:     128,
 					CPUWeight:    77,
+					Privileged:   true,
 					Ports:        []uint16{8765, 2300},
 					Routes:       route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"peekaboo-one.example.com", "peekaboo-too.example.com"}}},
 					LogGuid:      "9832-ur98j-idsckl",
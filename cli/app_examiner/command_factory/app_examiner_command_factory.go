@@ -3,11 +3,13 @@ package command_factory
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner/command_factory/presentation"
@@ -49,6 +51,10 @@ func (commandFactory *AppExaminerCommandFactory) MakeVisualizeCommand() cli.Comm
 			Name:  "rate, r",
 			Usage: "The rate at which to refresh the visualization.\n\te.g. -r=\".5s\"\n\te.g. -r=\"1000ns\"",
 		},
+		cli.StringFlag{
+			Name:  "app, a",
+			Usage: "only show the instances of the given app",
+		},
 	}
 
 	var startCommand = cli.Command{
@@ -62,6 +68,26 @@ func (commandFactory *AppExaminerCommandFactory) MakeVisualizeCommand() cli.Comm
 	return startCommand
 }
 
+func (commandFactory *AppExaminerCommandFactory) MakeListCellsCommand() cli.Command {
+	return cli.Command{
+		Name:        "cells",
+		Description: "List the Lattice Cells with their zone, free capacity and instances",
+		Usage:       "ltc cells",
+		Action:      commandFactory.appExaminerCommand.listCells,
+		Flags:       []cli.Flag{},
+	}
+}
+
+func (commandFactory *AppExaminerCommandFactory) MakeCellCommand() cli.Command {
+	return cli.Command{
+		Name:        "cell",
+		Description: "Displays the capacity of the given Lattice Cell and the app instances placed on it",
+		Usage:       "ltc cell CELL_ID",
+		Action:      commandFactory.appExaminerCommand.cellStatus,
+		Flags:       []cli.Flag{},
+	}
+}
+
 func (commandFactory *AppExaminerCommandFactory) MakeStatusCommand() cli.Command {
 	return cli.Command{
 		Name:        "status",
@@ -84,6 +110,9 @@ func (cmd *appExaminerCommand) listApps(context *cli.Context) {
 	if err != nil {
 		cmd.output.Say("Error listing apps: " + err.Error())
 		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(appList)
+		return
 	} else if len(appList) == 0 {
 		cmd.output.Say("No apps to display.")
 		return
@@ -125,6 +154,9 @@ func (cmd *appExaminerCommand) appStatus(context *cli.Context) {
 	if err != nil {
 		cmd.output.Say(err.Error())
 		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(appInfo)
+		return
 	}
 
 	minColumnWidth := 13
@@ -153,11 +185,14 @@ func printAppInfo(w io.Writer, appInfo app_examiner.AppInfo) {
 
 	fmt.Fprintf(w, "%s\t%s\n", "Instances", colorInstances(appInfo))
 	fmt.Fprintf(w, "%s\t%s\n", "Stack", appInfo.Stack)
+	fmt.Fprintf(w, "%s\t%s\n", "Domain", appInfo.Domain)
 
 	fmt.Fprintf(w, "%s\t%d\n", "Start Timeout", appInfo.StartTimeout)
 	fmt.Fprintf(w, "%s\t%d\n", "DiskMB", appInfo.DiskMB)
 	fmt.Fprintf(w, "%s\t%d\n", "MemoryMB", appInfo.MemoryMB)
 	fmt.Fprintf(w, "%s\t%d\n", "CPUWeight", appInfo.CPUWeight)
+	fmt.Fprintf(w, "%s\t%t\n", "Privileged", appInfo.Privileged)
+	fmt.Fprintf(w, "%s\t%s\n", "Log Source", appInfo.LogSource)
 
 	portStrings := make([]string, 0)
 	for _, port := range appInfo.Ports {
@@ -231,11 +266,127 @@ func printInstanceInfo(w io.Writer, headingPrefix string, actualInstances []app_
 	}
 }
 
+func (cmd *appExaminerCommand) listCells(context *cli.Context) {
+	cells, err := cmd.appExaminer.ListCells()
+	if err != nil {
+		cmd.output.Say("Error listing cells: " + err.Error())
+		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(cells)
+		return
+	} else if len(cells) == 0 {
+		cmd.output.Say("No cells to display.")
+		return
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(cmd.output, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
+
+	header := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", colors.Bold("Cell ID"), colors.Bold("Zone"), colors.Bold("Instances"), colors.Bold("Free MemoryMB"), colors.Bold("Free DiskMB"), colors.Bold("Free Containers"))
+	fmt.Fprintln(w, header)
+
+	for _, cell := range cells {
+		cellID := colors.Bold(cell.CellID)
+		if cell.Missing {
+			cellID = colors.Red(cell.CellID + " [MISSING]")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", cellID, colors.NoColor(cell.Zone), colors.NoColor(strconv.Itoa(len(cell.Instances))), colorCapacity(cell.RemainingMemoryMB, cell.MemoryMB), colorCapacity(cell.RemainingDiskMB, cell.DiskMB), colorCapacity(cell.RemainingContainers, cell.Containers))
+	}
+
+	w.Flush()
+}
+
+func (cmd *appExaminerCommand) cellStatus(context *cli.Context) {
+	if len(context.Args()) < 1 {
+		cmd.output.IncorrectUsage("Cell ID required")
+		return
+	}
+
+	cellID := context.Args()[0]
+	cell, err := cmd.appExaminer.CellStatus(cellID)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(cell)
+		return
+	}
+
+	minColumnWidth := 13
+	w := tabwriter.NewWriter(cmd.output, minColumnWidth, 8, 1, '\t', 0)
+
+	headingPrefix := strings.Repeat(" ", minColumnWidth/2)
+
+	printHorizontalRule(w, "=")
+	fmt.Fprintf(w, "%s%s\n", headingPrefix, colors.Bold(cellID))
+	printHorizontalRule(w, "-")
+
+	if cell.Missing {
+		fmt.Fprintf(w, "%s\t%s\n", "State", colors.Red("MISSING"))
+	}
+	fmt.Fprintf(w, "%s\t%s\n", "Zone", cell.Zone)
+	fmt.Fprintf(w, "%s\t%d\n", "Running", cell.RunningInstances)
+	fmt.Fprintf(w, "%s\t%d\n", "Claimed", cell.ClaimedInstances)
+	fmt.Fprintf(w, "%s\t%s\n", "MemoryMB", formatCapacity(cell.RemainingMemoryMB, cell.MemoryMB))
+	fmt.Fprintf(w, "%s\t%s\n", "DiskMB", formatCapacity(cell.RemainingDiskMB, cell.DiskMB))
+	fmt.Fprintf(w, "%s\t%s\n", "Containers", formatCapacity(cell.RemainingContainers, cell.Containers))
+
+	printHorizontalRule(w, "=")
+	w.Flush()
+
+	if len(cell.Instances) == 0 {
+		cmd.output.Say("No instances on this cell.\n")
+		return
+	}
+
+	w = tabwriter.NewWriter(cmd.output, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", colors.Bold("App Name"), colors.Bold("Index"), colors.Bold("State"), colors.Bold("MemoryMB"), colors.Bold("DiskMB"))
+	for _, instance := range cell.Instances {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", colors.Bold(instance.ProcessGuid), colors.NoColor(strconv.Itoa(instance.Index)), presentation.ColorInstanceState(app_examiner.InstanceInfo{State: instance.State}), colors.NoColor(strconv.Itoa(instance.MemoryMB)), colors.NoColor(strconv.Itoa(instance.DiskMB)))
+	}
+	w.Flush()
+}
+
+// formatCapacity shows the free capacity of a cell next to its total. Cells report
+// no capacity when they are missing.
+func formatCapacity(remaining, total int) string {
+	if total == 0 && remaining == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", remaining, total)
+}
+
+func colorCapacity(remaining, total int) string {
+	capacity := formatCapacity(remaining, total)
+	if remaining < 0 {
+		return colors.Red(capacity)
+	} else if remaining*10 < total {
+		return colors.Yellow(capacity)
+	}
+
+	return colors.NoColor(capacity)
+}
+
 func (cmd *appExaminerCommand) visualizeCells(context *cli.Context) {
 	rate := context.Duration("rate")
+	appName := context.String("app")
+
+	if cmd.output.IsStructured() {
+		cells, err := cmd.appExaminer.ListCells()
+		if err != nil {
+			cmd.output.Say("Error visualizing: " + err.Error())
+			return
+		}
+		for i := range cells {
+			cells[i].Instances = filterCellInstances(cells[i].Instances, appName)
+		}
+		cmd.sayFormatted(cells)
+		return
+	}
 
 	cmd.output.Say(colors.Bold("Distribution\n"))
-	linesWritten := cmd.printDistribution()
+	linesWritten := cmd.printDistribution(appName)
 
 	if rate == 0 {
 		return
@@ -255,40 +406,232 @@ func (cmd *appExaminerCommand) visualizeCells(context *cli.Context) {
 			return
 		case <-cmd.clock.NewTimer(rate).C():
 			cmd.output.Say(cursor.Up(linesWritten))
-			linesWritten = cmd.printDistribution()
+			linesWritten = cmd.printDistribution(appName)
 		}
 	}
 }
 
-func (cmd *appExaminerCommand) printDistribution() int {
+// printDistribution draws a row per cell with its capacity bar and a dot per instance in
+// the color of its app, then a row for the instances that are not placed on any cell and
+// a legend of the apps. It returns the number of lines written.
+func (cmd *appExaminerCommand) printDistribution(appName string) int {
 	defer cmd.output.Say(cursor.ClearToEndOfDisplay())
 
-	cells, err := cmd.appExaminer.ListCells()
-	if err != nil {
-		cmd.output.Say("Error visualizing: " + err.Error())
+	linesWritten := 0
+	sayLine := func(line string) {
+		cmd.output.Say(line)
 		cmd.output.Say(cursor.ClearToEndOfLine())
 		cmd.output.NewLine()
-		return 1
+		linesWritten++
+	}
+
+	cells, apps, err := cmd.appExaminer.ListDistribution()
+	if err != nil {
+		sayLine("Error visualizing: " + err.Error())
+		return linesWritten
 	}
 
+	width := cursor.TerminalWidth(cmd.output.Writer)
+
+	labelWidth := 0
 	for _, cell := range cells {
-		cmd.output.Say(cell.CellID)
+		if length := len(cellLabel(cell)); length > labelWidth {
+			labelWidth = length
+		}
+	}
+
+	var shownApps []string
+	for _, cell := range cells {
+		line := cell.CellID
 		if cell.Missing {
-			cmd.output.Say(colors.Red("[MISSING]"))
+			line += colors.Red("[MISSING]")
 		}
-		cmd.output.Say(": ")
+		line += ": " + strings.Repeat(" ", labelWidth-len(cellLabel(cell))) + capacityBar(cell) + " "
 
+		instances := filterCellInstances(cell.Instances, appName)
 		if cell.RunningInstances == 0 && cell.ClaimedInstances == 0 && !cell.Missing {
-			cmd.output.Say(colors.Red("empty"))
+			line += colors.Red("empty")
 		} else {
-			cmd.output.Say(colors.Green(strings.Repeat("•", cell.RunningInstances)))
-			cmd.output.Say(colors.Yellow(strings.Repeat("•", cell.ClaimedInstances)))
+			var dots []string
+			for _, instance := range instances {
+				dot := "•"
+				if instance.State != string(receptor.ActualLRPStateRunning) {
+					dot = "◦"
+				}
+				dots = append(dots, colors.ColorForKey(instance.ProcessGuid, dot))
+				shownApps = append(shownApps, instance.ProcessGuid)
+			}
+			line += truncateDots(dots, width-labelWidth-len(": ")-capacityBarLength-1)
 		}
-		cmd.output.Say(cursor.ClearToEndOfLine())
-		cmd.output.NewLine()
+
+		sayLine(line)
+	}
+
+	var unplacedDots []string
+	placementErrors := make(map[string]int)
+	crashed := 0
+	for _, app := range apps {
+		if appName != "" && app.ProcessGuid != appName {
+			continue
+		}
+
+		for _, instance := range app.ActualInstances {
+			switch receptor.ActualLRPState(instance.State) {
+			case receptor.ActualLRPStateUnclaimed:
+				unplacedDots = append(unplacedDots, colors.ColorForKey(app.ProcessGuid, "•"))
+				shownApps = append(shownApps, app.ProcessGuid)
+				if instance.PlacementError != "" {
+					placementErrors[instance.PlacementError]++
+				}
+			case receptor.ActualLRPStateCrashed:
+				crashed++
+			}
+		}
+	}
+
+	if len(unplacedDots) == 0 && crashed == 0 {
+		sayLine("Pending: none")
+	} else {
+		summary := fmt.Sprintf("%d unplaced, ", len(unplacedDots))
+		if crashed > 0 {
+			summary += colors.Red(fmt.Sprintf("%d crashed", crashed))
+		} else {
+			summary += "0 crashed"
+		}
+		summaryLength := len(fmt.Sprintf("%d unplaced, %d crashed", len(unplacedDots), crashed))
+
+		line := "Pending: "
+		if len(unplacedDots) > 0 {
+			line += truncateDots(unplacedDots, width-len(line)-summaryLength-1) + " "
+		}
+		sayLine(line + summary)
+	}
+
+	errorMessages := make([]string, 0, len(placementErrors))
+	for message := range placementErrors {
+		errorMessages = append(errorMessages, message)
+	}
+	sort.Strings(errorMessages)
+	for _, message := range errorMessages {
+		count := fmt.Sprintf(" (%d)", placementErrors[message])
+		sayLine("  " + colors.Red(truncate(message, width-2-len(count))) + count)
+	}
+
+	for _, line := range legendLines(shownApps, width) {
+		sayLine(line)
+	}
+
+	return linesWritten
+}
+
+const (
+	capacityBarWidth  = 10
+	capacityBarLength = capacityBarWidth + len("[] 100%")
+)
+
+func cellLabel(cell app_examiner.CellInfo) string {
+	if cell.Missing {
+		return cell.CellID + "[MISSING]"
+	}
+	return cell.CellID
+}
+
+// capacityBar shows how much of the cell's most used resource, out of memory, disk
+// and containers, is reserved by the instances placed on it.
+func capacityBar(cell app_examiner.CellInfo) string {
+	percent := -1
+	for _, capacity := range [][2]int{
+		{cell.MemoryMB, cell.RemainingMemoryMB},
+		{cell.DiskMB, cell.RemainingDiskMB},
+		{cell.Containers, cell.RemainingContainers},
+	} {
+		if total, remaining := capacity[0], capacity[1]; total > 0 {
+			if used := (total - remaining) * 100 / total; used > percent {
+				percent = used
+			}
+		}
+	}
+
+	if percent < 0 {
+		return strings.Repeat(" ", capacityBarLength)
+	}
+
+	filled := percent * capacityBarWidth / 100
+	if filled > capacityBarWidth {
+		filled = capacityBarWidth
+	}
+
+	colorFunc := colors.Green
+	if percent >= 90 {
+		colorFunc = colors.Red
+	} else if percent >= 70 {
+		colorFunc = colors.Yellow
+	}
+
+	return fmt.Sprintf("[%s%s] %3d%%", colorFunc(strings.Repeat("█", filled)), strings.Repeat("░", capacityBarWidth-filled), percent)
+}
+
+func filterCellInstances(instances []app_examiner.CellInstanceInfo, appName string) []app_examiner.CellInstanceInfo {
+	if appName == "" {
+		return instances
+	}
+
+	filtered := []app_examiner.CellInstanceInfo{}
+	for _, instance := range instances {
+		if instance.ProcessGuid == appName {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered
+}
+
+// truncateDots joins dots that each take up one column, replacing the ones that do not
+// fit in width with a count.
+func truncateDots(dots []string, width int) string {
+	if len(dots) <= width {
+		return strings.Join(dots, "")
+	}
+
+	shown := width - len(fmt.Sprintf("+%d", len(dots)))
+	if shown < 0 {
+		shown = 0
 	}
+	return strings.Join(dots[:shown], "") + fmt.Sprintf("+%d", len(dots)-shown)
+}
+
+func truncate(text string, width int) string {
+	if width < 3 || len(text) <= width {
+		return text
+	}
+	return text[:width-3] + "..."
+}
 
-	return len(cells)
+func legendLines(appNames []string, width int) []string {
+	sort.Strings(appNames)
+
+	var lines []string
+	line, lineLength := "Legend: • running ◦ claimed", len("Legend: * running * claimed")
+	for i, appName := range appNames {
+		if i > 0 && appNames[i-1] == appName {
+			continue
+		}
+
+		entryLength := len("  * ") + len(appName)
+		if lineLength+entryLength > width {
+			lines = append(lines, line)
+			line, lineLength = "       ", len("       ")
+		}
+		line += "  " + colors.ColorForKey(appName, "•") + " " + appName
+		lineLength += entryLength
+	}
+
+	return append(lines, line)
+}
+
+func (cmd *appExaminerCommand) sayFormatted(value interface{}) {
+	if err := cmd.output.SayFormatted(value); err != nil {
+		cmd.output.Say("Error formatting output: " + err.Error())
+	}
 }
 
 func colorInstances(appInfo app_examiner.AppInfo) string {
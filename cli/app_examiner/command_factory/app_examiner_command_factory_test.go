@@ -1,8 +1,10 @@
 package command_factory_test
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -118,25 +120,114 @@ var _ = Describe("CommandFactory", func() {
 			visualizeCommand = commandFactory.MakeVisualizeCommand()
 		})
 
-		It("displays a visualization of cells", func() {
-			listCells := []app_examiner.CellInfo{
-				app_examiner.CellInfo{CellID: "cell-1", RunningInstances: 3, ClaimedInstances: 2},
-				app_examiner.CellInfo{CellID: "cell-2", RunningInstances: 2, ClaimedInstances: 1},
-				app_examiner.CellInfo{CellID: "cell-3", RunningInstances: 0, ClaimedInstances: 0},
-			}
-			appExaminer.ListCellsReturns(listCells, nil)
+		It("displays a capacity bar and a dot per instance in the color of its app", func() {
+			appExaminer.ListDistributionReturns([]app_examiner.CellInfo{
+				app_examiner.CellInfo{
+					CellID: "cell-1", RunningInstances: 2, ClaimedInstances: 1,
+					MemoryMB: 1000, RemainingMemoryMB: 400, DiskMB: 1000, RemainingDiskMB: 900, Containers: 10, RemainingContainers: 7,
+					Instances: []app_examiner.CellInstanceInfo{
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: 0, State: "RUNNING"},
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: 1, State: "RUNNING"},
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-b", Index: 0, State: "CLAIMED"},
+					},
+				},
+				app_examiner.CellInfo{
+					CellID: "cell-2", RunningInstances: 1,
+					MemoryMB: 1000, RemainingMemoryMB: 50, DiskMB: 1000, RemainingDiskMB: 900, Containers: 10, RemainingContainers: 9,
+					Instances: []app_examiner.CellInstanceInfo{
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-b", Index: 1, State: "RUNNING"},
+					},
+				},
+				app_examiner.CellInfo{
+					CellID:   "cell-3",
+					MemoryMB: 1000, RemainingMemoryMB: 1000, DiskMB: 1000, RemainingDiskMB: 1000, Containers: 10, RemainingContainers: 10,
+				},
+			}, nil, nil)
 
 			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{})
 
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Distribution\n")))
-			Expect(outputBuffer).To(test_helpers.Say("cell-1: " + colors.Green("•••") + colors.Yellow("••") + cursor.ClearToEndOfLine() + "\n"))
-			Expect(outputBuffer).To(test_helpers.Say("cell-2: " + colors.Green("••") + colors.Yellow("•") + cursor.ClearToEndOfLine() + "\n"))
-			Expect(outputBuffer).To(test_helpers.Say("cell-3: " + colors.Red("empty")))
-			Expect(outputBuffer).To(test_helpers.SayNewLine())
+			Expect(outputBuffer).To(test_helpers.Say("cell-1: [" + colors.Green("██████") + "░░░░]  60% " + colors.ColorForKey("app-a", "•") + colors.ColorForKey("app-a", "•") + colors.ColorForKey("app-b", "◦") + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("cell-2: [" + colors.Red("█████████") + "░]  95% " + colors.ColorForKey("app-b", "•") + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("cell-3: [░░░░░░░░░░]   0% " + colors.Red("empty") + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Pending: none" + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Legend: • running ◦ claimed  " + colors.ColorForKey("app-a", "•") + " app-a  " + colors.ColorForKey("app-b", "•") + " app-b" + cursor.ClearToEndOfLine() + "\n"))
 		})
 
-		It("alerts the user if fetching the cells returns an error", func() {
-			appExaminer.ListCellsReturns(nil, errors.New("The list was lost"))
+		It("shows the instances that are not placed on a cell, with their placement errors", func() {
+			appExaminer.ListDistributionReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-1"}}, []app_examiner.AppInfo{
+				app_examiner.AppInfo{
+					ProcessGuid: "app-a",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "UNCLAIMED", PlacementError: "insufficient resources"},
+						app_examiner.InstanceInfo{Index: 1, State: "UNCLAIMED", PlacementError: "insufficient resources"},
+						app_examiner.InstanceInfo{Index: 2, State: "UNCLAIMED"},
+						app_examiner.InstanceInfo{Index: 3, State: "CRASHED", CrashCount: 4},
+						app_examiner.InstanceInfo{Index: 4, State: "RUNNING"},
+					},
+				},
+				app_examiner.AppInfo{
+					ProcessGuid: "app-c",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "UNCLAIMED", PlacementError: "found no compatible cell"},
+					},
+				},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{})
+
+			unplacedA := colors.ColorForKey("app-a", "•")
+			Expect(outputBuffer).To(test_helpers.Say("Pending: " + unplacedA + unplacedA + unplacedA + colors.ColorForKey("app-c", "•") + " 4 unplaced, " + colors.Red("1 crashed") + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("  " + colors.Red("found no compatible cell") + " (1)" + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("  " + colors.Red("insufficient resources") + " (2)" + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say(" app-a  " + colors.ColorForKey("app-c", "•") + " app-c"))
+		})
+
+		It("only shows the instances of the app given with --app", func() {
+			appExaminer.ListDistributionReturns([]app_examiner.CellInfo{
+				app_examiner.CellInfo{
+					CellID: "cell-1", RunningInstances: 1, ClaimedInstances: 1,
+					Instances: []app_examiner.CellInstanceInfo{
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: 0, State: "RUNNING"},
+						app_examiner.CellInstanceInfo{ProcessGuid: "app-b", Index: 0, State: "CLAIMED"},
+					},
+				},
+			}, []app_examiner.AppInfo{
+				app_examiner.AppInfo{
+					ProcessGuid:     "app-a",
+					ActualInstances: []app_examiner.InstanceInfo{app_examiner.InstanceInfo{Index: 1, State: "UNCLAIMED"}},
+				},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{"--app", "app-b"})
+
+			Expect(outputBuffer).To(test_helpers.Say("cell-1: " + strings.Repeat(" ", 17) + " " + colors.ColorForKey("app-b", "◦") + cursor.ClearToEndOfLine() + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Pending: none"))
+			Expect(outputBuffer).To(test_helpers.Say("Legend: • running ◦ claimed  " + colors.ColorForKey("app-b", "•") + " app-b" + cursor.ClearToEndOfLine() + "\n"))
+		})
+
+		It("fits the rows to the width of the terminal", func() {
+			instances := make([]app_examiner.CellInstanceInfo, 100)
+			for i := range instances {
+				instances[i] = app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: i, State: "RUNNING"}
+			}
+			appExaminer.ListDistributionReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-1", RunningInstances: 100, Instances: instances}}, nil, nil)
+
+			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("cell-1: " + strings.Repeat(" ", 17) + " " + strings.Repeat(colors.ColorForKey("app-a", "•"), 50) + "+50" + cursor.ClearToEndOfLine() + "\n"))
+		})
+
+		It("fetches the cells and the apps together once per refresh", func() {
+			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{})
+
+			Expect(appExaminer.ListDistributionCallCount()).To(Equal(1))
+			Expect(appExaminer.ListCellsCallCount()).To(Equal(0))
+			Expect(appExaminer.ListAppsCallCount()).To(Equal(0))
+		})
+
+		It("alerts the user if fetching the distribution returns an error", func() {
+			appExaminer.ListDistributionReturns(nil, nil, errors.New("The list was lost"))
 
 			test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{})
 
@@ -148,15 +239,20 @@ var _ = Describe("CommandFactory", func() {
 
 			It("dynamically displays the visualization", func() {
 				setNumberOfRunningInstances := func(count int) {
-					appExaminer.ListCellsReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-0", RunningInstances: count}, app_examiner.CellInfo{CellID: "cell-1", RunningInstances: count, Missing: true}}, nil)
+					instances := make([]app_examiner.CellInstanceInfo, count)
+					for i := range instances {
+						instances[i] = app_examiner.CellInstanceInfo{ProcessGuid: "app-a", Index: i, State: "RUNNING"}
+					}
+					appExaminer.ListDistributionReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-0", RunningInstances: count, Instances: instances}, app_examiner.CellInfo{CellID: "cell-1", RunningInstances: count, Missing: true, Instances: instances}}, nil, nil)
 				}
 
 				setNumberOfRunningInstances(0)
 
 				closeChan = test_helpers.AsyncExecuteCommandWithArgs(visualizeCommand, []string{"-rate", "2s"})
 
-				Eventually(outputBuffer).Should(test_helpers.Say("cell-0: " + colors.Red("empty") + cursor.ClearToEndOfLine() + "\n"))
-				Eventually(outputBuffer).Should(test_helpers.Say("cell-1" + colors.Red("[MISSING]") + ": " + cursor.ClearToEndOfLine() + "\n"))
+				noCapacity := strings.Repeat(" ", 17) + " "
+				Eventually(outputBuffer).Should(test_helpers.Say("cell-0: " + strings.Repeat(" ", 9) + noCapacity + colors.Red("empty") + cursor.ClearToEndOfLine() + "\n"))
+				Eventually(outputBuffer).Should(test_helpers.Say("cell-1" + colors.Red("[MISSING]") + ": " + noCapacity + cursor.ClearToEndOfLine() + "\n"))
 
 				setNumberOfRunningInstances(2)
 
@@ -166,15 +262,16 @@ var _ = Describe("CommandFactory", func() {
 
 				clock.IncrementBySeconds(1)
 
+				dots := colors.ColorForKey("app-a", "•") + colors.ColorForKey("app-a", "•")
 				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
-				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(2)))
-				Eventually(outputBuffer).Should(test_helpers.Say("cell-0: " + colors.Green("••") + cursor.ClearToEndOfLine() + "\n"))
-				Eventually(outputBuffer).Should(test_helpers.Say("cell-1" + colors.Red("[MISSING]") + ": " + colors.Green("••") + cursor.ClearToEndOfLine() + "\n"))
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(4)))
+				Eventually(outputBuffer).Should(test_helpers.Say("cell-0: " + strings.Repeat(" ", 9) + noCapacity + dots + cursor.ClearToEndOfLine() + "\n"))
+				Eventually(outputBuffer).Should(test_helpers.Say("cell-1" + colors.Red("[MISSING]") + ": " + noCapacity + dots + cursor.ClearToEndOfLine() + "\n"))
 				Eventually(outputBuffer).Should(test_helpers.Say(cursor.ClearToEndOfDisplay()))
 			})
 
 			It("dynamically displays any errors", func() {
-				appExaminer.ListCellsReturns(nil, errors.New("Spilled the Paint"))
+				appExaminer.ListDistributionReturns(nil, nil, errors.New("Spilled the Paint"))
 
 				closeChan = test_helpers.AsyncExecuteCommandWithArgs(visualizeCommand, []string{"-rate", "1s"})
 
@@ -205,6 +302,159 @@ var _ = Describe("CommandFactory", func() {
 
 	})
 
+	Describe("ListCellsCommand", func() {
+		var (
+			listCellsCommand cli.Command
+			cellsOutput      *output.Output
+		)
+
+		BeforeEach(func() {
+			cellsOutput = output.New(outputBuffer)
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, cellsOutput, clock, exitHandler)
+			listCellsCommand = commandFactory.MakeListCellsCommand()
+		})
+
+		It("displays the zone, free capacity and instances of each cell", func() {
+			appExaminer.ListCellsReturns([]app_examiner.CellInfo{
+				app_examiner.CellInfo{
+					CellID: "cell-1", Zone: "z1",
+					MemoryMB: 4096, DiskMB: 20480, Containers: 250,
+					RemainingMemoryMB: 3840, RemainingDiskMB: 19456, RemainingContainers: 248,
+					Instances: []app_examiner.CellInstanceInfo{
+						app_examiner.CellInstanceInfo{ProcessGuid: "process1", Index: 0, State: "RUNNING", MemoryMB: 128, DiskMB: 512},
+						app_examiner.CellInstanceInfo{ProcessGuid: "process2", Index: 0, State: "CLAIMED", MemoryMB: 128, DiskMB: 512},
+					},
+				},
+				app_examiner.CellInfo{
+					CellID: "cell-2", Zone: "z2",
+					MemoryMB: 1024, DiskMB: 20480, Containers: 250,
+					RemainingMemoryMB: -256, RemainingDiskMB: 1024, RemainingContainers: 240,
+				},
+				app_examiner.CellInfo{CellID: "cell-3", Missing: true},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listCellsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Cell ID")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Zone")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Instances")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Free MemoryMB")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Free DiskMB")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Free Containers")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("cell-1")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("z1")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("2")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("3840/4096")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("19456/20480")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("248/250")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("cell-2")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("-256/1024")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("1024/20480")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("cell-3 [MISSING]")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("-")))
+		})
+
+		It("alerts the user if there are no cells", func() {
+			appExaminer.ListCellsReturns([]app_examiner.CellInfo{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listCellsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("No cells to display."))
+		})
+
+		It("alerts the user if fetching the cells returns an error", func() {
+			appExaminer.ListCellsReturns(nil, errors.New("The list was lost"))
+
+			test_helpers.ExecuteCommandWithArgs(listCellsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error listing cells: The list was lost"))
+		})
+
+		It("writes structured output", func() {
+			Expect(cellsOutput.SetFormat(output.JSONFormat)).To(Succeed())
+			appExaminer.ListCellsReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-1", Zone: "z1"}}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listCellsCommand, []string{})
+
+			var cells []app_examiner.CellInfo
+			Expect(json.Unmarshal(outputBuffer.Contents(), &cells)).To(Succeed())
+			Expect(cells[0].Zone).To(Equal("z1"))
+		})
+	})
+
+	Describe("CellCommand", func() {
+		var cellCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, output.New(outputBuffer), clock, exitHandler)
+			cellCommand = commandFactory.MakeCellCommand()
+		})
+
+		It("displays the capacity of the cell and the instances placed on it", func() {
+			appExaminer.CellStatusReturns(app_examiner.CellInfo{
+				CellID: "cell-1", Zone: "z1",
+				RunningInstances: 1, ClaimedInstances: 1,
+				MemoryMB: 4096, DiskMB: 20480, Containers: 250,
+				RemainingMemoryMB: 3584, RemainingDiskMB: 19456, RemainingContainers: 248,
+				Instances: []app_examiner.CellInstanceInfo{
+					app_examiner.CellInstanceInfo{ProcessGuid: "hungry-app", Index: 3, State: "RUNNING", MemoryMB: 384, DiskMB: 512},
+					app_examiner.CellInstanceInfo{ProcessGuid: "tiny-app", Index: 0, State: "CLAIMED", MemoryMB: 128, DiskMB: 512},
+				},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(cellCommand, []string{"cell-1"})
+
+			Expect(appExaminer.CellStatusArgsForCall(0)).To(Equal("cell-1"))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("cell-1")))
+			Expect(outputBuffer).To(test_helpers.Say("Zone"))
+			Expect(outputBuffer).To(test_helpers.Say("z1"))
+			Expect(outputBuffer).To(test_helpers.Say("Running"))
+			Expect(outputBuffer).To(test_helpers.Say("1"))
+			Expect(outputBuffer).To(test_helpers.Say("MemoryMB"))
+			Expect(outputBuffer).To(test_helpers.Say("3584/4096"))
+			Expect(outputBuffer).To(test_helpers.Say("DiskMB"))
+			Expect(outputBuffer).To(test_helpers.Say("19456/20480"))
+			Expect(outputBuffer).To(test_helpers.Say("Containers"))
+			Expect(outputBuffer).To(test_helpers.Say("248/250"))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("App Name")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("hungry-app")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("3")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("RUNNING")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("384")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("tiny-app")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("CLAIMED")))
+		})
+
+		It("marks missing cells", func() {
+			appExaminer.CellStatusReturns(app_examiner.CellInfo{CellID: "cell-1", Missing: true}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(cellCommand, []string{"cell-1"})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("MISSING")))
+			Expect(outputBuffer).To(test_helpers.Say("No instances on this cell."))
+		})
+
+		It("alerts the user if fetching the cell returns an error", func() {
+			appExaminer.CellStatusReturns(app_examiner.CellInfo{}, errors.New(app_examiner.CellNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(cellCommand, []string{"cell-9"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Cell not found."))
+		})
+
+		It("requires a cell id", func() {
+			test_helpers.ExecuteCommandWithArgs(cellCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(appExaminer.CellStatusCallCount()).To(Equal(0))
+		})
+	})
+
 	Describe("StatusCommand", func() {
 		var statusCommand cli.Command
 
@@ -219,7 +469,8 @@ var _ = Describe("CommandFactory", func() {
 					ProcessGuid:            "wompy-app",
 					DesiredInstances:       12,
 					ActualRunningInstances: 1,
-					Stack: "lucid64",
+					Stack:  "lucid64",
+					Domain: "lattice",
 					EnvironmentVariables: []app_examiner.EnvironmentVariable{
 						app_examiner.EnvironmentVariable{Name: "WOMPY_APP_PASSWORD", Value: "seekreet pass"},
 						app_examiner.EnvironmentVariable{Name: "WOMPY_APP_USERNAME", Value: "mrbigglesworth54"},
@@ -228,6 +479,7 @@ var _ = Describe("CommandFactory", func() {
 					DiskMB:       2048,
 					MemoryMB:     256,
 					CPUWeight:    100,
+					Privileged:   true,
 					Ports:        []uint16{8887, 9000},
 					Routes:       route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"wompy-app.my-fun-domain.com", "cranky-app.my-fun-domain.com"}, Port: 8080}},
 					LogGuid:      "a9s8dfa99023r",
@@ -279,6 +531,9 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("Stack"))
 			Expect(outputBuffer).To(test_helpers.Say("lucid64"))
 
+			Expect(outputBuffer).To(test_helpers.Say("Domain"))
+			Expect(outputBuffer).To(test_helpers.Say("lattice"))
+
 			Expect(outputBuffer).To(test_helpers.Say("Start Timeout"))
 			Expect(outputBuffer).To(test_helpers.Say("600"))
 
@@ -291,6 +546,12 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("CPUWeight"))
 			Expect(outputBuffer).To(test_helpers.Say("100"))
 
+			Expect(outputBuffer).To(test_helpers.Say("Privileged"))
+			Expect(outputBuffer).To(test_helpers.Say("true"))
+
+			Expect(outputBuffer).To(test_helpers.Say("Log Source"))
+			Expect(outputBuffer).To(test_helpers.Say("wompy-app-logz"))
+
 			Expect(outputBuffer).To(test_helpers.Say("Ports"))
 			Expect(outputBuffer).To(test_helpers.Say("8887"))
 			Expect(outputBuffer).To(test_helpers.Say("9000"))
@@ -400,4 +661,132 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 	})
+
+	Describe("structured output", func() {
+		var (
+			commandFactory *command_factory.AppExaminerCommandFactory
+			cliOutput      *output.Output
+		)
+
+		BeforeEach(func() {
+			cliOutput = output.New(outputBuffer)
+			commandFactory = command_factory.NewAppExaminerCommandFactory(appExaminer, cliOutput, clock, exitHandler)
+		})
+
+		Context("when the output format is json", func() {
+			BeforeEach(func() {
+				Expect(cliOutput.SetFormat(output.JSONFormat)).To(Succeed())
+			})
+
+			It("lists the apps as json with stable field names", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					app_examiner.AppInfo{
+						ProcessGuid:            "process1",
+						DesiredInstances:       2,
+						ActualRunningInstances: 1,
+						Ports:                  []uint16{8080},
+						Routes:                 route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"process1.example.com"}, Port: 8080}},
+					},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeListAppCommand(), []string{})
+
+				var apps []map[string]interface{}
+				Expect(json.Unmarshal(outputBuffer.Contents(), &apps)).To(Succeed())
+				Expect(apps).To(HaveLen(1))
+				Expect(apps[0]).To(HaveKeyWithValue("process_guid", "process1"))
+				Expect(apps[0]).To(HaveKeyWithValue("desired_instances", BeNumerically("==", 2)))
+				Expect(apps[0]).To(HaveKeyWithValue("actual_running_instances", BeNumerically("==", 1)))
+				Expect(apps[0]).To(HaveKey("disk_mb"))
+				Expect(apps[0]).To(HaveKey("memory_mb"))
+				Expect(apps[0]).To(HaveKey("actual_instances"))
+				Expect(apps[0]["routes"]).To(Equal([]interface{}{
+					map[string]interface{}{"hostnames": []interface{}{"process1.example.com"}, "port": float64(8080)},
+				}))
+				Expect(outputBuffer.Contents()).ToNot(ContainSubstring("\x1b["))
+			})
+
+			It("lists an empty array when there are no apps", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeListAppCommand(), []string{})
+
+				Expect(string(outputBuffer.Contents())).To(Equal("[]\n"))
+			})
+
+			It("shows the status of an app and its instances as json", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{
+					ProcessGuid: "wompy-app",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{
+							InstanceGuid: "a0s9f-u9a8sf-aasdioasdjoi",
+							CellID:       "cell-12",
+							Index:        1,
+							Ip:           "10.85.12.100",
+							Ports:        []app_examiner.PortMapping{app_examiner.PortMapping{HostPort: 1234, ContainerPort: 3000}},
+							State:        "RUNNING",
+							Since:        401120627 * 1e9,
+							CrashCount:   2,
+						},
+					},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeStatusCommand(), []string{"wompy-app"})
+
+				var appInfo map[string]interface{}
+				Expect(json.Unmarshal(outputBuffer.Contents(), &appInfo)).To(Succeed())
+				Expect(appInfo).To(HaveKeyWithValue("process_guid", "wompy-app"))
+				Expect(appInfo["actual_instances"]).To(Equal([]interface{}{
+					map[string]interface{}{
+						"instance_guid":   "a0s9f-u9a8sf-aasdioasdjoi",
+						"cell_id":         "cell-12",
+						"index":           float64(1),
+						"ip":              "10.85.12.100",
+						"ports":           []interface{}{map[string]interface{}{"host_port": float64(1234), "container_port": float64(3000)}},
+						"state":           "RUNNING",
+						"since":           float64(401120627 * 1e9),
+						"placement_error": "",
+						"crash_count":     float64(2),
+					},
+				}))
+			})
+
+			It("prints the cells once as json instead of visualizing them", func() {
+				appExaminer.ListCellsReturns([]app_examiner.CellInfo{
+					app_examiner.CellInfo{CellID: "cell-0", RunningInstances: 3, ClaimedInstances: 2},
+					app_examiner.CellInfo{CellID: "cell-1", Missing: true},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeVisualizeCommand(), []string{"--rate", "2s"})
+
+				var cells []map[string]interface{}
+				Expect(json.Unmarshal(outputBuffer.Contents(), &cells)).To(Succeed())
+				Expect(cells).To(Equal([]map[string]interface{}{
+					map[string]interface{}{"cell_id": "cell-0", "running_instances": float64(3), "claimed_instances": float64(2), "missing": false},
+					map[string]interface{}{"cell_id": "cell-1", "running_instances": float64(0), "claimed_instances": float64(0), "missing": true},
+				}))
+				Expect(appExaminer.ListCellsCallCount()).To(Equal(1))
+			})
+
+			It("still reports errors as text", func() {
+				appExaminer.ListAppsReturns(nil, errors.New("The list was lost"))
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeListAppCommand(), []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error listing apps: The list was lost"))
+			})
+		})
+
+		Context("when the output format is yaml", func() {
+			It("shows the status of an app as yaml", func() {
+				Expect(cliOutput.SetFormat(output.YAMLFormat)).To(Succeed())
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "wompy-app", DesiredInstances: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(commandFactory.MakeStatusCommand(), []string{"wompy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("process_guid: wompy-app\n"))
+				Expect(outputBuffer).To(test_helpers.Say("desired_instances: 3\n"))
+			})
+		})
+	})
 })
@@ -22,6 +22,23 @@ type FakeAppExaminer struct {
 		result1 []app_examiner.CellInfo
 		result2 error
 	}
+	ListDistributionStub        func() ([]app_examiner.CellInfo, []app_examiner.AppInfo, error)
+	listDistributionMutex       sync.RWMutex
+	listDistributionArgsForCall []struct{}
+	listDistributionReturns     struct {
+		result1 []app_examiner.CellInfo
+		result2 []app_examiner.AppInfo
+		result3 error
+	}
+	CellStatusStub        func(cellID string) (app_examiner.CellInfo, error)
+	cellStatusMutex       sync.RWMutex
+	cellStatusArgsForCall []struct {
+		cellID string
+	}
+	cellStatusReturns struct {
+		result1 app_examiner.CellInfo
+		result2 error
+	}
 	AppStatusStub        func(appName string) (app_examiner.AppInfo, error)
 	appStatusMutex       sync.RWMutex
 	appStatusArgsForCall []struct {
@@ -83,6 +100,65 @@ func (fake *FakeAppExaminer) ListCellsReturns(result1 []app_examiner.CellInfo, r
 	}{result1, result2}
 }
 
+func (fake *FakeAppExaminer) ListDistribution() ([]app_examiner.CellInfo, []app_examiner.AppInfo, error) {
+	fake.listDistributionMutex.Lock()
+	fake.listDistributionArgsForCall = append(fake.listDistributionArgsForCall, struct{}{})
+	fake.listDistributionMutex.Unlock()
+	if fake.ListDistributionStub != nil {
+		return fake.ListDistributionStub()
+	} else {
+		return fake.listDistributionReturns.result1, fake.listDistributionReturns.result2, fake.listDistributionReturns.result3
+	}
+}
+
+func (fake *FakeAppExaminer) ListDistributionCallCount() int {
+	fake.listDistributionMutex.RLock()
+	defer fake.listDistributionMutex.RUnlock()
+	return len(fake.listDistributionArgsForCall)
+}
+
+func (fake *FakeAppExaminer) ListDistributionReturns(result1 []app_examiner.CellInfo, result2 []app_examiner.AppInfo, result3 error) {
+	fake.ListDistributionStub = nil
+	fake.listDistributionReturns = struct {
+		result1 []app_examiner.CellInfo
+		result2 []app_examiner.AppInfo
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeAppExaminer) CellStatus(cellID string) (app_examiner.CellInfo, error) {
+	fake.cellStatusMutex.Lock()
+	fake.cellStatusArgsForCall = append(fake.cellStatusArgsForCall, struct {
+		cellID string
+	}{cellID})
+	fake.cellStatusMutex.Unlock()
+	if fake.CellStatusStub != nil {
+		return fake.CellStatusStub(cellID)
+	} else {
+		return fake.cellStatusReturns.result1, fake.cellStatusReturns.result2
+	}
+}
+
+func (fake *FakeAppExaminer) CellStatusCallCount() int {
+	fake.cellStatusMutex.RLock()
+	defer fake.cellStatusMutex.RUnlock()
+	return len(fake.cellStatusArgsForCall)
+}
+
+func (fake *FakeAppExaminer) CellStatusArgsForCall(i int) string {
+	fake.cellStatusMutex.RLock()
+	defer fake.cellStatusMutex.RUnlock()
+	return fake.cellStatusArgsForCall[i].cellID
+}
+
+func (fake *FakeAppExaminer) CellStatusReturns(result1 app_examiner.CellInfo, result2 error) {
+	fake.CellStatusStub = nil
+	fake.cellStatusReturns = struct {
+		result1 app_examiner.CellInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAppExaminer) AppStatus(appName string) (app_examiner.AppInfo, error) {
 	fake.appStatusMutex.Lock()
 	fake.appStatusArgsForCall = append(fake.appStatusArgsForCall, struct {
@@ -0,0 +1,217 @@
+package autoscaler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-golang/clock"
+)
+
+// Tolerance is how far the usage may stray from a target, as a fraction of the
+// target, before the autoscaler reacts. It keeps noisy metrics from flapping the app.
+const Tolerance = 0.1
+
+const bytesPerMB = 1024 * 1024
+
+// Policy says how many instances the app may run and what average usage per
+// instance to aim for. A zero target disables scaling on that resource.
+type Policy struct {
+	MinInstances      int
+	MaxInstances      int
+	CPUPercentage     float64
+	MemoryPercentage  float64
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+}
+
+func (p Policy) Validate() error {
+	switch {
+	case p.MinInstances < 0:
+		return errors.New("--min cannot be negative")
+	case p.MaxInstances < 1:
+		return errors.New("--max must be at least 1")
+	case p.MinInstances > p.MaxInstances:
+		return errors.New("--min cannot be greater than --max")
+	case p.CPUPercentage < 0 || p.MemoryPercentage < 0:
+		return errors.New("--cpu and --memory cannot be negative")
+	case p.CPUPercentage == 0 && p.MemoryPercentage == 0:
+		return errors.New("--cpu or --memory is required")
+	}
+	return nil
+}
+
+// Autoscaler scales an app between the policy's bounds so the average usage of
+// its instances stays near the policy's targets, logging every decision it makes.
+type Autoscaler struct {
+	appName   string
+	memoryMB  int
+	policy    Policy
+	appRunner docker_app_runner.AppRunner
+	clock     clock.Clock
+	output    *output.Output
+
+	metricsMutex sync.Mutex
+	metrics      map[int32]*events.ContainerMetric
+
+	lastScaled time.Time
+}
+
+// New returns an Autoscaler for the app, whose instances are limited to memoryMB.
+func New(appName string, memoryMB int, policy Policy, appRunner docker_app_runner.AppRunner, clock clock.Clock, output *output.Output) *Autoscaler {
+	return &Autoscaler{
+		appName:   appName,
+		memoryMB:  memoryMB,
+		policy:    policy,
+		appRunner: appRunner,
+		clock:     clock,
+		output:    output,
+		metrics:   make(map[int32]*events.ContainerMetric),
+	}
+}
+
+// RecordMetric keeps the latest container metric of each instance until the next scaling.
+func (a *Autoscaler) RecordMetric(metric *events.ContainerMetric) {
+	a.metricsMutex.Lock()
+	defer a.metricsMutex.Unlock()
+
+	a.metrics[metric.GetInstanceIndex()] = metric
+}
+
+// Run evaluates the policy every interval until stopChan is closed.
+func (a *Autoscaler) Run(interval time.Duration, stopChan <-chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-a.clock.NewTimer(interval).C():
+			a.Evaluate()
+		}
+	}
+}
+
+// Evaluate compares the usage reported since the last scaling with the policy and
+// scales the app when it has drifted outside the tolerance and no cooldown applies.
+func (a *Autoscaler) Evaluate() {
+	instances, err := a.appRunner.DesiredInstances(a.appName)
+	if err != nil {
+		a.log("Error getting the instances of %s: %s", a.appName, err)
+		return
+	}
+
+	desired, reason := a.desiredInstances(instances)
+	if desired == instances {
+		a.log("Keeping %s at %d instances: %s", a.appName, instances, reason)
+		return
+	}
+
+	cooldown := a.policy.ScaleUpCooldown
+	if desired < instances {
+		cooldown = a.policy.ScaleDownCooldown
+	}
+	if remaining := a.lastScaled.Add(cooldown).Sub(a.clock.Now()); !a.lastScaled.IsZero() && remaining > 0 {
+		a.log("Not scaling %s from %d to %d instances for another %s: %s", a.appName, instances, desired, remaining, reason)
+		return
+	}
+
+	if err := a.appRunner.ScaleApp(a.appName, desired); err != nil {
+		a.log("Error scaling %s from %d to %d instances: %s", a.appName, instances, desired, err)
+		return
+	}
+
+	a.log("Scaled %s from %d to %d instances: %s", a.appName, instances, desired, reason)
+	a.lastScaled = a.clock.Now()
+
+	a.metricsMutex.Lock()
+	a.metrics = make(map[int32]*events.ContainerMetric)
+	a.metricsMutex.Unlock()
+}
+
+// desiredInstances returns the instances needed to bring the average usage back to
+// the targets, the most any resource needs, clamped to the policy's bounds.
+func (a *Autoscaler) desiredInstances(instances int) (int, string) {
+	if instances < a.policy.MinInstances {
+		return a.policy.MinInstances, fmt.Sprintf("below the minimum of %d", a.policy.MinInstances)
+	} else if instances > a.policy.MaxInstances {
+		return a.policy.MaxInstances, fmt.Sprintf("above the maximum of %d", a.policy.MaxInstances)
+	}
+
+	metrics := a.currentMetrics(instances)
+	if len(metrics) == 0 {
+		return instances, "waiting for metrics"
+	}
+
+	var cpuPercentage, memoryBytes float64
+	for _, metric := range metrics {
+		cpuPercentage += metric.GetCpuPercentage()
+		memoryBytes += float64(metric.GetMemoryBytes())
+	}
+	cpuPercentage /= float64(len(metrics))
+	memoryBytes /= float64(len(metrics))
+
+	desired := 0
+	usages := []string{}
+	if a.policy.CPUPercentage > 0 {
+		desired = maxInt(desired, scaleFor(instances, cpuPercentage, a.policy.CPUPercentage))
+		usages = append(usages, fmt.Sprintf("CPU %.1f%% (target %.0f%%)", cpuPercentage, a.policy.CPUPercentage))
+	}
+	if a.policy.MemoryPercentage > 0 && a.memoryMB > 0 {
+		memoryPercentage := memoryBytes * 100 / float64(a.memoryMB*bytesPerMB)
+		desired = maxInt(desired, scaleFor(instances, memoryPercentage, a.policy.MemoryPercentage))
+		usages = append(usages, fmt.Sprintf("memory %.1f%% (target %.0f%%)", memoryPercentage, a.policy.MemoryPercentage))
+	}
+
+	if len(usages) == 0 {
+		return instances, "no usage to compare with the targets"
+	}
+
+	reason := "average " + strings.Join(usages, ", ")
+	switch {
+	case desired < a.policy.MinInstances:
+		return a.policy.MinInstances, reason + fmt.Sprintf(", held at the minimum of %d", a.policy.MinInstances)
+	case desired > a.policy.MaxInstances:
+		return a.policy.MaxInstances, reason + fmt.Sprintf(", held at the maximum of %d", a.policy.MaxInstances)
+	}
+	return desired, reason
+}
+
+// currentMetrics returns the latest metric of each instance the app should be running.
+func (a *Autoscaler) currentMetrics(instances int) []*events.ContainerMetric {
+	a.metricsMutex.Lock()
+	defer a.metricsMutex.Unlock()
+
+	metrics := []*events.ContainerMetric{}
+	for index, metric := range a.metrics {
+		if int(index) < instances {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+func (a *Autoscaler) log(format string, args ...interface{}) {
+	a.output.SayLine(fmt.Sprintf("[%s] ", a.clock.Now().Format("15:04:05")) + fmt.Sprintf(format, args...))
+}
+
+// scaleFor returns the instances that would bring usage to target, or instances
+// when usage is already within the Tolerance of target.
+func scaleFor(instances int, usage, target float64) int {
+	ratio := usage / target
+	if math.Abs(ratio-1) <= Tolerance {
+		return instances
+	}
+	return int(math.Ceil(float64(instances) * ratio))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,282 @@
+package autoscaler_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/autoscaler"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("Autoscaler", func() {
+	var (
+		fakeAppRunner *fake_app_runner.FakeAppRunner
+		clock         *fakeclock.FakeClock
+		outputBuffer  *gbytes.Buffer
+		policy        autoscaler.Policy
+		appScaler     *autoscaler.Autoscaler
+	)
+
+	containerMetric := func(instanceIndex int32, cpuPercentage float64, memoryMB uint64) *events.ContainerMetric {
+		memoryBytes := memoryMB * 1024 * 1024
+		return &events.ContainerMetric{
+			InstanceIndex: &instanceIndex,
+			CpuPercentage: &cpuPercentage,
+			MemoryBytes:   &memoryBytes,
+		}
+	}
+
+	BeforeEach(func() {
+		fakeAppRunner = &fake_app_runner.FakeAppRunner{}
+		clock = fakeclock.NewFakeClock(time.Date(2015, 5, 1, 10, 30, 0, 0, time.UTC))
+		outputBuffer = gbytes.NewBuffer()
+		policy = autoscaler.Policy{
+			MinInstances:      1,
+			MaxInstances:      5,
+			CPUPercentage:     50,
+			ScaleUpCooldown:   time.Minute,
+			ScaleDownCooldown: 5 * time.Minute,
+		}
+	})
+
+	JustBeforeEach(func() {
+		appScaler = autoscaler.New("my-app", 128, policy, fakeAppRunner, clock, output.New(outputBuffer))
+	})
+
+	Describe("Evaluate", func() {
+		It("scales up to bring the average CPU back to the target", func() {
+			fakeAppRunner.DesiredInstancesReturns(2, nil)
+			appScaler.RecordMetric(containerMetric(0, 90, 64))
+			appScaler.RecordMetric(containerMetric(1, 70, 64))
+
+			appScaler.Evaluate()
+
+			Expect(fakeAppRunner.DesiredInstancesArgsForCall(0)).To(Equal("my-app"))
+			Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(1))
+			name, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+			Expect(name).To(Equal("my-app"))
+			Expect(instances).To(Equal(4))
+			Expect(outputBuffer).To(test_helpers.Say("[10:30:00] Scaled my-app from 2 to 4 instances: average CPU 80.0% (target 50%)\n"))
+		})
+
+		It("scales down when the app is underused", func() {
+			fakeAppRunner.DesiredInstancesReturns(4, nil)
+			for index := int32(0); index < 4; index++ {
+				appScaler.RecordMetric(containerMetric(index, 10, 64))
+			}
+
+			appScaler.Evaluate()
+
+			_, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+			Expect(instances).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.Say("Scaled my-app from 4 to 1 instances: average CPU 10.0% (target 50%)"))
+		})
+
+		It("keeps the app as it is within the tolerance of the target", func() {
+			fakeAppRunner.DesiredInstancesReturns(2, nil)
+			appScaler.RecordMetric(containerMetric(0, 52, 64))
+			appScaler.RecordMetric(containerMetric(1, 50, 64))
+
+			appScaler.Evaluate()
+
+			Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say("Keeping my-app at 2 instances: average CPU 51.0% (target 50%)"))
+		})
+
+		It("waits for metrics before scaling", func() {
+			fakeAppRunner.DesiredInstancesReturns(2, nil)
+
+			appScaler.Evaluate()
+
+			Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say("Keeping my-app at 2 instances: waiting for metrics"))
+		})
+
+		It("ignores the metrics of instances the app no longer runs", func() {
+			fakeAppRunner.DesiredInstancesReturns(1, nil)
+			appScaler.RecordMetric(containerMetric(0, 50, 64))
+			appScaler.RecordMetric(containerMetric(3, 100, 64))
+
+			appScaler.Evaluate()
+
+			Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(0))
+		})
+
+		It("holds the app within the policy's bounds", func() {
+			fakeAppRunner.DesiredInstancesReturns(4, nil)
+			for index := int32(0); index < 4; index++ {
+				appScaler.RecordMetric(containerMetric(index, 100, 64))
+			}
+
+			appScaler.Evaluate()
+
+			_, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+			Expect(instances).To(Equal(5))
+			Expect(outputBuffer).To(test_helpers.Say("Scaled my-app from 4 to 5 instances: average CPU 100.0% (target 50%), held at the maximum of 5"))
+		})
+
+		It("brings an app outside the bounds back within them without waiting for metrics", func() {
+			fakeAppRunner.DesiredInstancesReturns(0, nil)
+
+			appScaler.Evaluate()
+
+			_, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+			Expect(instances).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.Say("Scaled my-app from 0 to 1 instances: below the minimum of 1"))
+		})
+
+		Context("when scaling on memory", func() {
+			BeforeEach(func() {
+				policy.CPUPercentage = 0
+				policy.MemoryPercentage = 50
+			})
+
+			It("scales on the average memory against the app's memory limit", func() {
+				fakeAppRunner.DesiredInstancesReturns(1, nil)
+				appScaler.RecordMetric(containerMetric(0, 0, 96))
+
+				appScaler.Evaluate()
+
+				_, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+				Expect(instances).To(Equal(2))
+				Expect(outputBuffer).To(test_helpers.Say("Scaled my-app from 1 to 2 instances: average memory 75.0% (target 50%)"))
+			})
+		})
+
+		It("scales to whatever the busiest resource needs", func() {
+			policy.MemoryPercentage = 50
+			appScaler = autoscaler.New("my-app", 128, policy, fakeAppRunner, clock, output.New(outputBuffer))
+			fakeAppRunner.DesiredInstancesReturns(1, nil)
+			appScaler.RecordMetric(containerMetric(0, 10, 128))
+
+			appScaler.Evaluate()
+
+			_, instances := fakeAppRunner.ScaleAppArgsForCall(0)
+			Expect(instances).To(Equal(2))
+			Expect(outputBuffer).To(test_helpers.Say("average CPU 10.0% (target 50%), memory 100.0% (target 50%)"))
+		})
+
+		Context("after scaling", func() {
+			JustBeforeEach(func() {
+				fakeAppRunner.DesiredInstancesReturns(2, nil)
+				appScaler.RecordMetric(containerMetric(0, 90, 64))
+				appScaler.RecordMetric(containerMetric(1, 90, 64))
+				appScaler.Evaluate()
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(1))
+
+				fakeAppRunner.DesiredInstancesReturns(4, nil)
+			})
+
+			It("waits for fresh metrics", func() {
+				appScaler.Evaluate()
+
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(1))
+				Expect(outputBuffer).To(test_helpers.Say("Keeping my-app at 4 instances: waiting for metrics"))
+			})
+
+			It("does not scale up again until the scale up cooldown has passed", func() {
+				for index := int32(0); index < 4; index++ {
+					appScaler.RecordMetric(containerMetric(index, 100, 64))
+				}
+
+				clock.IncrementBySeconds(30)
+				appScaler.Evaluate()
+
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(1))
+				Expect(outputBuffer).To(test_helpers.Say("Not scaling my-app from 4 to 5 instances for another 30s"))
+
+				clock.IncrementBySeconds(30)
+				appScaler.Evaluate()
+
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(2))
+			})
+
+			It("does not scale down until the scale down cooldown has passed", func() {
+				for index := int32(0); index < 4; index++ {
+					appScaler.RecordMetric(containerMetric(index, 10, 64))
+				}
+
+				clock.IncrementBySeconds(60)
+				appScaler.Evaluate()
+
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(1))
+				Expect(outputBuffer).To(test_helpers.Say("Not scaling my-app from 4 to 1 instances for another 4m0s"))
+
+				clock.IncrementBySeconds(240)
+				appScaler.Evaluate()
+
+				Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(2))
+			})
+		})
+
+		It("logs errors getting the instances", func() {
+			fakeAppRunner.DesiredInstancesReturns(0, errors.New("App not found."))
+
+			appScaler.Evaluate()
+
+			Expect(fakeAppRunner.ScaleAppCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say("Error getting the instances of my-app: App not found."))
+		})
+
+		It("logs errors scaling the app", func() {
+			fakeAppRunner.DesiredInstancesReturns(2, nil)
+			fakeAppRunner.ScaleAppReturns(errors.New("receptor is down"))
+			appScaler.RecordMetric(containerMetric(0, 90, 64))
+
+			appScaler.Evaluate()
+
+			Expect(outputBuffer).To(test_helpers.Say("Error scaling my-app from 2 to 4 instances: receptor is down"))
+		})
+	})
+
+	Describe("Run", func() {
+		It("evaluates the policy every interval until stopped", func() {
+			fakeAppRunner.DesiredInstancesReturns(1, nil)
+			stopChan := make(chan struct{})
+			done := make(chan struct{})
+
+			go func() {
+				appScaler.Run(10*time.Second, stopChan)
+				close(done)
+			}()
+
+			Consistently(fakeAppRunner.DesiredInstancesCallCount).Should(Equal(0))
+
+			clock.IncrementBySeconds(10)
+			Eventually(fakeAppRunner.DesiredInstancesCallCount).Should(Equal(1))
+
+			close(stopChan)
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Describe("Policy", func() {
+		It("is valid with a target and bounds", func() {
+			Expect(policy.Validate()).To(Succeed())
+		})
+
+		It("requires a target", func() {
+			policy.CPUPercentage = 0
+			Expect(policy.Validate()).To(MatchError("--cpu or --memory is required"))
+		})
+
+		It("requires the bounds to be in order", func() {
+			policy.MinInstances = 6
+			Expect(policy.Validate()).To(MatchError("--min cannot be greater than --max"))
+		})
+
+		It("requires a positive maximum", func() {
+			policy.MinInstances = 0
+			policy.MaxInstances = 0
+			Expect(policy.Validate()).To(MatchError("--max must be at least 1"))
+		})
+	})
+})
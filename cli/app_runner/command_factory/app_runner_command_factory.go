@@ -1,17 +1,30 @@
 package command_factory
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/autoscaler"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_repository_name_formatter"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/environment"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/manifest"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
 	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter"
 
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
@@ -23,6 +36,14 @@ const (
 	InvalidPortErrorMessage          = "Invalid port specified. Ports must be a comma-delimited list of integers between 0-65535."
 	MalformedRouteErrorMessage       = "Malformed route. Routes must be of the format route:port"
 	MustSetMonitoredPortErrorMessage = "Must set monitored-port when specifying multiple exposed ports unless --no-monitor is set."
+
+	maxCPUWeight      = 100
+	maxAnnotationSize = 10 * 1024
+
+	defaultMemoryMB  = 128
+	defaultDiskMB    = 1024
+	defaultInstances = 1
+	defaultPort      = 8080
 )
 
 type AppRunnerCommandFactory struct {
@@ -31,6 +52,7 @@ type AppRunnerCommandFactory struct {
 
 type AppRunnerCommandFactoryConfig struct {
 	AppRunner             docker_app_runner.AppRunner
+	AppWatcher            app_watcher.AppWatcher
 	DockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
 	Output                *output.Output
 	Timeout               time.Duration
@@ -39,12 +61,18 @@ type AppRunnerCommandFactoryConfig struct {
 	Clock                 clock.Clock
 	Logger                lager.Logger
 	TailedLogsOutputter   console_tailed_logs_outputter.TailedLogsOutputter
+	LogReaderFactory      console_tailed_logs_outputter.LogReaderFactory
+	RegistryCredentials   registry_credentials.RegistryCredentials
+	AppExaminer           app_examiner.AppExaminer
+	Input                 io.Reader
+	ExitHandler           exit_handler.ExitHandler
 }
 
 func NewAppRunnerCommandFactory(config AppRunnerCommandFactoryConfig) *AppRunnerCommandFactory {
 	return &AppRunnerCommandFactory{
 		&appRunnerCommand{
 			appRunner:             config.AppRunner,
+			appWatcher:            config.AppWatcher,
 			dockerMetadataFetcher: config.DockerMetadataFetcher,
 			output:                config.Output,
 			timeout:               config.Timeout,
@@ -52,6 +80,11 @@ func NewAppRunnerCommandFactory(config AppRunnerCommandFactoryConfig) *AppRunner
 			env:                   config.Env,
 			clock:                 config.Clock,
 			tailedLogsOutputter:   config.TailedLogsOutputter,
+			logReaderFactory:      config.LogReaderFactory,
+			registryCredentials:   config.RegistryCredentials,
+			appExaminer:           config.AppExaminer,
+			input:                 config.Input,
+			exitHandler:           config.ExitHandler,
 		},
 	}
 }
@@ -76,12 +109,12 @@ func (commandFactory *AppRunnerCommandFactory) MakeStartAppCommand() cli.Command
 		cli.IntFlag{
 			Name:  "memory-mb, m",
 			Usage: "container memory limit in MB",
-			Value: 128,
+			Value: defaultMemoryMB,
 		},
 		cli.IntFlag{
 			Name:  "disk-mb, d",
 			Usage: "container disk limit in MB",
-			Value: 1024,
+			Value: defaultDiskMB,
 		},
 		cli.StringFlag{
 			Name:  "ports, p",
@@ -101,13 +134,49 @@ func (commandFactory *AppRunnerCommandFactory) MakeStartAppCommand() cli.Command
 		cli.IntFlag{
 			Name:  "instances",
 			Usage: "number of container instances to launch",
-			Value: 1,
+			Value: defaultInstances,
 		},
 		cli.BoolFlag{
 			Name:  "no-monitor",
 			Usage: "if set, lattice will not monitor that the app is listening on its port, and thus will not know if an app is running.",
 		},
+		cli.BoolFlag{
+			Name:  "pin",
+			Usage: "resolve DOCKER_IMAGE to its digest and run that exact image, so later scaling never pulls a different one",
+		},
+		cli.BoolFlag{
+			Name:  "registry-credentials",
+			Usage: "pass your credentials for the image's registry to lattice so it can pull a private image. They are visible to anyone who can read the app from the receptor.",
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight",
+			Usage: "relative share of CPU the containers get, between 1 and 100",
+		},
+		cli.StringFlag{
+			Name:  "log-source",
+			Usage: "source name the app's logs are tagged with",
+			Value: docker_app_runner.DefaultLogSource,
+		},
+		cli.StringFlag{
+			Name:  "stack",
+			Usage: "stack the app's cells must provide",
+			Value: docker_app_runner.DefaultStack,
+		},
+		cli.StringFlag{
+			Name:  "domain",
+			Usage: "diego domain to desire the app in",
+			Value: docker_app_runner.DefaultDomain,
+		},
+		cli.StringFlag{
+			Name:  "annotation",
+			Usage: "arbitrary annotation to store with the app",
+		},
+		cli.BoolFlag{
+			Name:  "unprivileged-container",
+			Usage: "run the app in an unprivileged container. --run-as-root still controls the user of the process.",
+		},
 	}
+	startFlags = append(startFlags, healthCheckFlags()...)
 
 	var startCommand = cli.Command{
 		Name:      "start",
@@ -131,7 +200,19 @@ func (commandFactory *AppRunnerCommandFactory) MakeStartAppCommand() cli.Command
    ltc start APP_NAME DOCKER_IMAGE --working-dir=/foo/app-folder -- START_COMMAND APP_ARG1 APP_ARG2 ...
 
    To specify environment variables:
-   ltc start APP_NAME DOCKER_IMAGE -e FOO=BAR -e BAZ=WIBBLE`,
+   ltc start APP_NAME DOCKER_IMAGE -e FOO=BAR -e BAZ=WIBBLE
+
+   ltc reads image metadata with the credentials saved by ltc registry-login or docker login.
+   To let lattice pull a private image with those credentials as well:
+   ltc start APP_NAME DOCKER_IMAGE --registry-credentials
+
+   To pin the app to the image the tag points at right now (requires a v2 registry):
+   ltc start APP_NAME DOCKER_IMAGE --pin
+
+   By default lattice checks that the app is listening on its monitored port.
+   To check an HTTP endpoint, or run a command inside the container instead:
+   ltc start APP_NAME DOCKER_IMAGE --health-check http --health-check-path /health
+   ltc start APP_NAME DOCKER_IMAGE --health-check command --health-check-command "redis-cli ping"`,
 		Action: commandFactory.appRunnerCommand.startApp,
 		Flags:  startFlags,
 	}
@@ -139,6 +220,138 @@ func (commandFactory *AppRunnerCommandFactory) MakeStartAppCommand() cli.Command
 	return startCommand
 }
 
+func (commandFactory *AppRunnerCommandFactory) MakeDeployCommand() cli.Command {
+	var deployFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "path to the manifest describing the apps to deploy",
+			Value: manifest.DefaultManifestFile,
+		},
+	}
+
+	var deployCommand = cli.Command{
+		Name:  "deploy",
+		Usage: "ltc deploy [-f MANIFEST_FILE]",
+		Description: `Deploy the docker apps described in a manifest to lattice
+
+   Apps that do not exist yet are started. Apps that already exist have their
+   instances, routes and annotation updated in place, and are recreated when
+   anything else declared in the manifest changed. A hostname ending in a dot
+   is a full hostname on another domain than the target's.
+
+   e.g. lattice.yml
+   applications:
+   - name: lattice-app
+     docker_image: cloudfoundry/lattice-app
+     env:
+       GREETING: hello
+     ports: [8080]
+     routes:
+     - hostname: lattice-app
+       port: 8080
+     memory_mb: 128
+     disk_mb: 1024
+     instances: 2
+     cpu_weight: 50
+     health_check:
+       type: http
+       path: /ping
+       timeout: 5s
+       start_timeout: 2m`,
+		Action: commandFactory.appRunnerCommand.deployApps,
+		Flags:  deployFlags,
+	}
+
+	return deployCommand
+}
+
+func (commandFactory *AppRunnerCommandFactory) MakeUpdateAppCommand() cli.Command {
+	var updateFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "instances",
+			Usage: "number of container instances to run",
+		},
+		cli.StringFlag{
+			Name:  "routes",
+			Usage: "mapping of port to hostname, replacing the current routes. eg routes=8080:foo,443:bar",
+		},
+		cli.StringFlag{
+			Name:  "annotation",
+			Usage: "arbitrary annotation to store with the app",
+		},
+		cli.StringFlag{
+			Name:  "docker-image",
+			Usage: "docker image to run. Changing the image recreates the app",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "environment variables to set, NAME[=VALUE]. Changing the environment recreates the app",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "container memory limit in MB. Changing the memory limit recreates the app",
+		},
+	}
+	updateFlags = append(updateFlags, healthCheckFlags()...)
+
+	var updateCommand = cli.Command{
+		Name:  "update",
+		Usage: "ltc update APP_NAME",
+		Description: `Update a running docker app on lattice
+
+   Instances, routes and annotation are updated in place.
+   Changing the docker image, environment variables, memory limit or health check
+   requires lattice to recreate the app, keeping its other settings. The app is
+   stopped until the new version starts: its instances are removed, and the new
+   version is only started once they are gone.`,
+		Action: commandFactory.appRunnerCommand.updateApp,
+		Flags:  updateFlags,
+	}
+
+	return updateCommand
+}
+
+func (commandFactory *AppRunnerCommandFactory) MakeRedeployCommand() cli.Command {
+	var redeployFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "rolling",
+			Usage: "shift instances over to the new version in batches instead of all at once",
+		},
+		cli.IntFlag{
+			Name:  "batch-size",
+			Usage: "number of instances to shift over at a time in a rolling redeploy",
+			Value: 1,
+		},
+	}
+
+	var redeployCommand = cli.Command{
+		Name:  "redeploy",
+		Usage: "ltc redeploy APP_NAME DOCKER_IMAGE",
+		Description: `Redeploy a docker app on lattice with a new docker image
+
+   A versioned copy of APP_NAME (e.g. APP_NAME-v2) running DOCKER_IMAGE is started
+   next to the existing app, keeping its start command, ports and environment.
+
+   By default the new version is started with all of its instances. Once they are
+   running, the routes of APP_NAME are moved over and the old version is removed.
+
+   With --rolling, the new version shares the routes of APP_NAME and instances are
+   shifted over --batch-size at a time.
+
+   If the new version fails to start, the redeploy is rolled back.
+
+   The app is renamed: once the redeploy finishes, it only exists as APP_NAME-v2
+   (or the next version), and that is the name to give ltc status, scale, logs and
+   the other commands, and to use in any manifest it is deployed from. Its routes
+   keep their hostnames.`,
+		Action: commandFactory.appRunnerCommand.redeployApp,
+		Flags:  redeployFlags,
+	}
+
+	return redeployCommand
+}
+
 func (commandFactory *AppRunnerCommandFactory) MakeScaleAppCommand() cli.Command {
 	var scaleCommand = cli.Command{
 		Name:        "scale",
@@ -150,6 +363,82 @@ func (commandFactory *AppRunnerCommandFactory) MakeScaleAppCommand() cli.Command
 	return scaleCommand
 }
 
+func (commandFactory *AppRunnerCommandFactory) MakeAutoscaleCommand() cli.Command {
+	var autoscaleFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "min",
+			Usage: "fewest instances to run",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  "max",
+			Usage: "most instances to run",
+		},
+		cli.IntFlag{
+			Name:  "cpu",
+			Usage: "average CPU percentage per instance to aim for",
+		},
+		cli.IntFlag{
+			Name:  "memory",
+			Usage: "average memory usage per instance to aim for, as a percentage of its memory limit",
+		},
+		cli.DurationFlag{
+			Name:  "cooldown",
+			Usage: "how long to wait after scaling before scaling up again",
+			Value: time.Minute,
+		},
+		cli.DurationFlag{
+			Name:  "scale-down-cooldown",
+			Usage: "how long to wait after scaling before scaling down again",
+			Value: 5 * time.Minute,
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Usage: "how often to evaluate the usage",
+			Value: 10 * time.Second,
+		},
+	}
+
+	var autoscaleCommand = cli.Command{
+		Name:  "autoscale",
+		Usage: "ltc autoscale APP_NAME --max N [--min N] [--cpu PERCENT] [--memory PERCENT]",
+		Description: `Keep scaling a docker app on lattice to follow its load
+
+   Every interval the average usage reported by the app's instances is compared
+   with the --cpu and --memory targets, and the app is scaled to the number of
+   instances that would bring it back to them, between --min and --max. Usage
+   within 10% of a target is left alone, and no scaling happens within the
+   cooldowns of the last one. Every decision is logged. Press Ctrl-C to stop.`,
+		Action: commandFactory.appRunnerCommand.autoscaleApp,
+		Flags:  autoscaleFlags,
+	}
+
+	return autoscaleCommand
+}
+
+func (commandFactory *AppRunnerCommandFactory) MakeRestartAppCommand() cli.Command {
+	var restartFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "index, i",
+			Usage: "index of the single instance to restart",
+		},
+	}
+
+	var restartCommand = cli.Command{
+		Name:  "restart",
+		Usage: "ltc restart APP_NAME [--index N]",
+		Description: `Restart the instances of a docker app on lattice
+
+   By default every instance is restarted in turn, waiting for each replacement
+   to be running before moving on to the next one. With --index only that
+   instance is restarted.`,
+		Action: commandFactory.appRunnerCommand.restartApp,
+		Flags:  restartFlags,
+	}
+
+	return restartCommand
+}
+
 func (commandFactory *AppRunnerCommandFactory) MakeStopAppCommand() cli.Command {
 
 	var stopCommand = cli.Command{
@@ -175,8 +464,138 @@ func (commandFactory *AppRunnerCommandFactory) MakeRemoveAppCommand() cli.Comman
 	return removeCommand
 }
 
+func (commandFactory *AppRunnerCommandFactory) MakeExportCommand() cli.Command {
+	var exportFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "path to write the manifest to instead of printing it",
+		},
+		cli.StringFlag{
+			Name:  "domain",
+			Usage: "diego domain to export the apps of",
+			Value: docker_app_runner.DefaultDomain,
+		},
+	}
+
+	var exportCommand = cli.Command{
+		Name:  "export",
+		Usage: "ltc export [-f MANIFEST_FILE]",
+		Description: `Export the docker apps running on lattice to a manifest
+
+   The manifest records the image, start command, environment, ports, routes,
+   resources and instances of every app, and can be read by ltc import or ltc deploy.
+   Routes are written relative to the lattice target, so the manifest can be imported
+   into another cluster. Registry credentials are not exported.`,
+		Action: commandFactory.appRunnerCommand.exportApps,
+		Flags:  exportFlags,
+	}
+
+	return exportCommand
+}
+
+func (commandFactory *AppRunnerCommandFactory) MakeImportCommand() cli.Command {
+	var importFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "path to the manifest to import",
+			Value: manifest.DefaultManifestFile,
+		},
+		cli.StringFlag{
+			Name:  "domain",
+			Usage: "diego domain to import the apps into, unless the manifest gives one",
+			Value: docker_app_runner.DefaultDomain,
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only show what would change",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "import without asking for confirmation",
+		},
+	}
+
+	var importCommand = cli.Command{
+		Name:  "import",
+		Usage: "ltc import [-f MANIFEST_FILE] [--domain DOMAIN] [--dry-run] [--yes]",
+		Description: `Recreate the docker apps in a manifest written by ltc export
+
+   ltc import shows how each app differs from what is running on lattice and asks
+   before changing anything. New apps are started, changed apps are updated or
+   recreated, and apps that are not in the manifest are left alone. Apps are
+   imported into the domain the manifest gives them, or else into --domain.`,
+		Action: commandFactory.appRunnerCommand.importApps,
+		Flags:  importFlags,
+	}
+
+	return importCommand
+}
+
+func (commandFactory *AppRunnerCommandFactory) MakeDiffCommand() cli.Command {
+	var diffFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "path to the manifest to compare against",
+			Value: manifest.DefaultManifestFile,
+		},
+		cli.StringFlag{
+			Name:  "domain",
+			Usage: "diego domain of the apps to compare, unless the manifest gives one",
+			Value: docker_app_runner.DefaultDomain,
+		},
+	}
+
+	var diffCommand = cli.Command{
+		Name:  "diff",
+		Usage: "ltc diff [-f MANIFEST_FILE]",
+		Description: `Compare the apps running on lattice with a manifest or an export
+
+   Shows apps that are declared but not running (+), running but not declared (-),
+   and apps whose settings have drifted (~), compared the same way as ltc import --dry-run.
+   Fields left out of the manifest are compared with the defaults import would use.
+   Apps running in the domain the manifest gives them, or else in --domain, that the
+   manifest does not declare are shown as removed.
+   Exits with status 1 when anything differs and 2 when the comparison fails. Use --output json or --output yaml for
+   machine-readable output.`,
+		Action: commandFactory.appRunnerCommand.diffManifest,
+		Flags:  diffFlags,
+	}
+
+	return diffCommand
+}
+
+func healthCheckFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "health-check",
+			Usage: "how lattice checks that the app is healthy: port (the default), http, command or none",
+		},
+		cli.StringFlag{
+			Name:  "health-check-path",
+			Usage: "path the http health check requests on the monitored port (default /)",
+		},
+		cli.IntFlag{
+			Name:  "health-check-status",
+			Usage: "HTTP status the http health check expects. Only 200 is supported, as it is the only status lattice's healthcheck accepts.",
+		},
+		cli.StringFlag{
+			Name:  "health-check-command",
+			Usage: "shell command run inside the container by the command health check. The app is healthy when it exits 0.",
+		},
+		cli.DurationFlag{
+			Name:  "health-check-timeout",
+			Usage: "how long a single health check may take, e.g. 5s. Diego runs the checks on a fixed interval that cannot be set per app.",
+		},
+		cli.DurationFlag{
+			Name:  "start-timeout",
+			Usage: "how long lattice waits for a new instance to pass its health check, e.g. 2m",
+		},
+	}
+}
+
 type appRunnerCommand struct {
 	appRunner             docker_app_runner.AppRunner
+	appWatcher            app_watcher.AppWatcher
 	dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
 	output                *output.Output
 	timeout               time.Duration
@@ -184,6 +603,11 @@ type appRunnerCommand struct {
 	env                   []string
 	clock                 clock.Clock
 	tailedLogsOutputter   console_tailed_logs_outputter.TailedLogsOutputter
+	logReaderFactory      console_tailed_logs_outputter.LogReaderFactory
+	registryCredentials   registry_credentials.RegistryCredentials
+	appExaminer           app_examiner.AppExaminer
+	input                 io.Reader
+	exitHandler           exit_handler.ExitHandler
 }
 
 func (cmd *appRunnerCommand) startApp(context *cli.Context) {
@@ -214,29 +638,81 @@ func (cmd *appRunnerCommand) startApp(context *cli.Context) {
 		appArgs = context.Args()[4:]
 	}
 
+	healthCheck, err := parseHealthCheck(context)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+	noMonitorFlag = noMonitorFlag || healthCheck.Type == docker_app_runner.NoHealthCheck
+
+	if err := validateContainerOptions(context); err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+
 	repoName, tag := docker_repository_name_formatter.ParseRepoNameAndTagFromImageReference(dockerImage)
-	imageMetadata, err := cmd.dockerMetadataFetcher.FetchMetadata(repoName, tag)
 
+	var registryCredentials registry_credentials.Credentials
+	if context.Bool("registry-credentials") {
+		registry := docker_repository_name_formatter.RegistryFromRepoName(repoName)
+		credentials, found, err := cmd.registryCredentials.Lookup(registry)
+		if err != nil {
+			cmd.output.Say(fmt.Sprintf("Error reading registry credentials: %s", err))
+			return
+		} else if !found {
+			cmd.output.Say(fmt.Sprintf("No credentials found for %s. Run ltc registry-login first.", registry_credentials.ServerFor(registry)))
+			return
+		}
+		registryCredentials = credentials
+	}
+
+	imageMetadata, err := cmd.dockerMetadataFetcher.FetchMetadata(repoName, tag)
 	if err != nil {
 		cmd.output.Say(fmt.Sprintf("Error fetching image metadata: %s", err))
 		return
 	}
 
+	annotation := context.String("annotation")
+	if context.Bool("pin") {
+		pinnedImage, err := pinImage(dockerImage, imageMetadata.Digest)
+		if err != nil {
+			cmd.output.Say(fmt.Sprintf("Error pinning image: %s", err))
+			return
+		}
+
+		cmd.output.Say(fmt.Sprintf("Pinned %s to %s\n", dockerImage, imageMetadata.Digest))
+		dockerImage = pinnedImage
+		if !context.IsSet("annotation") {
+			annotation = "Pinned image: " + pinnedImage
+		}
+	}
+
+	defaults, err := parseImageDefaults(imageMetadata.Labels)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+	imagePorts := defaults.applyToPorts(imageMetadata.Ports)
+
+	if !isSet(context, "memory-mb", "m") && defaults.memoryMB != 0 {
+		memoryMBFlag = defaults.memoryMB
+	}
+
 	var portConfig docker_app_runner.PortConfig
-	if portsFlag == "" && !imageMetadata.Ports.IsEmpty() {
+	if portsFlag == "" && !imagePorts.IsEmpty() {
 		portStrs := make([]string, 0)
-		for _, port := range imageMetadata.Ports.Exposed {
+		for _, port := range imagePorts.Exposed {
 			portStrs = append(portStrs, strconv.Itoa(int(port)))
 		}
 
 		cmd.output.Say(fmt.Sprintf("No port specified, using exposed ports from the image metadata.\n\tExposed Ports: %s\n", strings.Join(portStrs, ", ")))
-		portConfig = imageMetadata.Ports
-	} else if portsFlag == "" && imageMetadata.Ports.IsEmpty() && noMonitorFlag {
+		portConfig = imagePorts
+	} else if portsFlag == "" && imagePorts.IsEmpty() && noMonitorFlag {
 		portConfig = docker_app_runner.PortConfig{
 			Monitored: 0,
 			Exposed:   []uint16{8080},
 		}
-	} else if portsFlag == "" && imageMetadata.Ports.IsEmpty() {
+	} else if portsFlag == "" && imagePorts.IsEmpty() {
 		cmd.output.Say(fmt.Sprintf("No port specified, image metadata did not contain exposed ports. Defaulting to 8080.\n"))
 		portConfig = docker_app_runner.PortConfig{
 			Monitored: 8080,
@@ -286,10 +762,15 @@ func (cmd *appRunnerCommand) startApp(context *cli.Context) {
 		}
 	}
 
-	if !noMonitorFlag {
-		cmd.output.Say(fmt.Sprintf("Monitoring the app on port %d...\n", portConfig.Monitored))
-	} else {
+	switch {
+	case noMonitorFlag:
 		cmd.output.Say("No ports will be monitored.\n")
+	case healthCheck.Type == docker_app_runner.HTTPHealthCheck:
+		cmd.output.Say(fmt.Sprintf("Monitoring the app on port %d at %s...\n", portConfig.Monitored, healthCheck.Path))
+	case healthCheck.Type == docker_app_runner.CommandHealthCheck:
+		cmd.output.Say(fmt.Sprintf("Monitoring the app with: %s\n", healthCheck.Command))
+	default:
+		cmd.output.Say(fmt.Sprintf("Monitoring the app on port %d...\n", portConfig.Monitored))
 	}
 
 	if startCommand == "" {
@@ -302,38 +783,38 @@ func (cmd *appRunnerCommand) startApp(context *cli.Context) {
 		appArgs = imageMetadata.StartCommand[1:]
 	}
 
-	var routeOverrides docker_app_runner.RouteOverrides
-
-	for _, routeStr := range strings.Split(routesFlag, ",") {
-		if routeStr == "" {
-			continue
-		}
-		routeArr := strings.Split(routeStr, ":")
-		maybePort, err := strconv.Atoi(routeArr[0])
-		if err != nil || len(routeArr) < 2 {
-			cmd.output.Say(MalformedRouteErrorMessage)
-			return
-		}
-
-		port := uint16(maybePort)
-		hostnamePrefix := routeArr[1]
-		routeOverrides = append(routeOverrides, docker_app_runner.RouteOverride{HostnamePrefix: hostnamePrefix, Port: port})
+	routeOverrides, err := parseRouteOverrides(routesFlag)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+	if routesFlag == "" {
+		routeOverrides = defaults.routeOverrides
 	}
 
 	err = cmd.appRunner.StartDockerApp(docker_app_runner.StartDockerAppParams{
-		Name:                 name,
-		DockerImagePath:      dockerImage,
-		StartCommand:         startCommand,
-		AppArgs:              appArgs,
-		EnvironmentVariables: cmd.buildEnvironment(envVarsFlag),
-		Privileged:           context.Bool("run-as-root"),
-		Monitor:              !noMonitorFlag,
-		Instances:            instancesFlag,
-		MemoryMB:             memoryMBFlag,
-		DiskMB:               diskMBFlag,
-		Ports:                portConfig,
-		WorkingDir:           workingDirFlag,
-		RouteOverrides:       routeOverrides,
+		Name:                  name,
+		DockerImagePath:       dockerImage,
+		StartCommand:          startCommand,
+		AppArgs:               appArgs,
+		EnvironmentVariables:  environment.Merge(imageMetadata.Env, environment.Build(envVarsFlag, cmd.env)),
+		Privileged:            cmd.runAsRoot(imageMetadata, context.Bool("run-as-root")),
+		Monitor:               !noMonitorFlag,
+		HealthCheck:           healthCheck,
+		Instances:             instancesFlag,
+		MemoryMB:              memoryMBFlag,
+		DiskMB:                diskMBFlag,
+		Ports:                 portConfig,
+		WorkingDir:            workingDirFlag,
+		RouteOverrides:        routeOverrides,
+		RegistryUsername:      registryCredentials.Username,
+		RegistryPassword:      registryCredentials.Password,
+		Annotation:            annotation,
+		CPUWeight:             uint(context.Int("cpu-weight")),
+		LogSource:             context.String("log-source"),
+		Stack:                 context.String("stack"),
+		Domain:                context.String("domain"),
+		UnprivilegedContainer: context.Bool("unprivileged-container"),
 	})
 
 	if err != nil {
@@ -345,7 +826,9 @@ func (cmd *appRunnerCommand) startApp(context *cli.Context) {
 
 	go cmd.tailedLogsOutputter.OutputTailedLogs(name)
 
-	ok := cmd.pollUntilSuccess(func() bool {
+	ok := cmd.waitForApp(name, func(app app_watcher.AppState) bool {
+		return app.RunningInstances == instancesFlag
+	}, func() bool {
 		numberOfRunningInstances, _ := cmd.appRunner.NumOfRunningAppInstances(name)
 		return numberOfRunningInstances == instancesFlag
 	}, false)
@@ -360,90 +843,900 @@ func (cmd *appRunnerCommand) startApp(context *cli.Context) {
 
 }
 
-func (cmd *appRunnerCommand) scaleApp(c *cli.Context) {
-	appName := c.Args().First()
-	instancesArg := c.Args().Get(1)
+func (cmd *appRunnerCommand) deployApps(context *cli.Context) {
+	manifestPath := context.String("file")
 
-	switch {
-	case appName == "":
-		cmd.output.IncorrectUsage("App Name required")
-		return
-	case instancesArg == "":
-		cmd.output.IncorrectUsage("Number of Instances Required")
+	manifestFile, err := os.Open(manifestPath)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error reading manifest: %s", err))
 		return
 	}
+	defer manifestFile.Close()
 
-	instances, err := strconv.Atoi(instancesArg)
+	appManifest, err := manifest.Parse(manifestFile)
 	if err != nil {
-		cmd.output.IncorrectUsage("Number of Instances must be an integer")
+		cmd.output.Say(err.Error())
 		return
 	}
 
-	cmd.setAppInstances(appName, instances)
-}
-
-func (cmd *appRunnerCommand) stopApp(c *cli.Context) {
-	appName := c.Args().First()
-
-	if appName == "" {
-		cmd.output.IncorrectUsage("App Name required")
-		return
+	for _, app := range appManifest.Applications {
+		if err := cmd.deployApp(app); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error Deploying App %s: %s", app.Name, err))
+			return
+		}
 	}
-
-	cmd.setAppInstances(appName, 0)
 }
 
-func (cmd *appRunnerCommand) setAppInstances(appName string, instances int) {
-	err := cmd.appRunner.ScaleApp(appName, instances)
+func (cmd *appRunnerCommand) deployApp(app manifest.AppManifest) error {
+	repoName, tag := docker_repository_name_formatter.ParseRepoNameAndTagFromImageReference(app.DockerImage)
+	imageMetadata, err := cmd.dockerMetadataFetcher.FetchMetadata(repoName, tag)
+	if err != nil {
+		return fmt.Errorf("Error fetching image metadata: %s", err)
+	}
 
+	params, err := cmd.paramsFromManifest(app, imageMetadata)
 	if err != nil {
-		cmd.output.Say(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
-		return
+		return err
 	}
 
-	cmd.output.Say(fmt.Sprintf("Scaling %s to %d instances", appName, instances))
+	if err := cmd.appRunner.UpsertDockerApp(params); err != nil {
+		return err
+	}
 
-	ok := cmd.pollUntilSuccess(func() bool {
-		numRunning, _ := cmd.appRunner.NumOfRunningAppInstances(appName)
-		return numRunning == instances
-	}, true)
+	cmd.output.Say("Deploying App: " + app.Name)
+
+	ok := cmd.waitForInstances(app.Name, params.Instances)
 
 	if ok {
-		cmd.output.Say(colors.Green("App Scaled Successfully"))
+		cmd.output.Say(colors.Green(app.Name + " is now running.\n"))
+		cmd.output.Say(colors.Green(cmd.urlForApp(app.Name)))
+		cmd.output.NewLine()
 	} else {
-		cmd.output.Say(colors.Red(appName + " took too long to scale."))
+		cmd.output.Say(colors.Red(app.Name + " took too long to start."))
 	}
+
+	return nil
 }
 
-func (cmd *appRunnerCommand) removeApp(c *cli.Context) {
-	appName := c.Args().First()
-	if appName == "" {
-		cmd.output.IncorrectUsage("App Name required")
-		return
+func (cmd *appRunnerCommand) paramsFromManifest(app manifest.AppManifest, imageMetadata *docker_metadata_fetcher.ImageMetadata) (docker_app_runner.StartDockerAppParams, error) {
+	params := docker_app_runner.StartDockerAppParams{
+		Name:                  app.Name,
+		DockerImagePath:       app.DockerImage,
+		StartCommand:          app.StartCommand,
+		AppArgs:               app.AppArgs,
+		EnvironmentVariables:  environment.Merge(imageMetadata.Env, environment.BuildFromMap(app.Env, cmd.env)),
+		Privileged:            cmd.runAsRoot(imageMetadata, app.RunAsRoot),
+		Monitor:               !app.NoMonitor,
+		Instances:             intOrDefault(app.Instances, defaultInstances),
+		MemoryMB:              intOrDefault(app.MemoryMB, defaultMemoryMB),
+		DiskMB:                intOrDefault(app.DiskMB, defaultDiskMB),
+		WorkingDir:            app.WorkingDir,
+		CPUWeight:             app.CPUWeight,
+		LogSource:             app.LogSource,
+		Domain:                app.Domain,
+		Annotation:            app.Annotation,
+		UnprivilegedContainer: app.Unprivileged,
 	}
 
-	err := cmd.appRunner.RemoveApp(appName)
+	if app.CPUWeight > maxCPUWeight {
+		return docker_app_runner.StartDockerAppParams{}, fmt.Errorf("Invalid CPU weight: %d. Must be between 1 and %d.", app.CPUWeight, maxCPUWeight)
+	}
+	if len(app.Annotation) > maxAnnotationSize {
+		return docker_app_runner.StartDockerAppParams{}, fmt.Errorf("Annotation is too long. Must be at most %d bytes.", maxAnnotationSize)
+	}
+
+	if app.HealthCheck != nil {
+		healthCheck, err := healthCheckFromManifest(*app.HealthCheck)
+		if err != nil {
+			return docker_app_runner.StartDockerAppParams{}, err
+		}
+		params.HealthCheck = healthCheck
+		params.Monitor = params.Monitor && healthCheck.Type != docker_app_runner.NoHealthCheck
+	}
+
+	defaults, err := parseImageDefaults(imageMetadata.Labels)
 	if err != nil {
-		cmd.output.Say(fmt.Sprintf("Error Stopping App: %s", err))
-		return
+		return docker_app_runner.StartDockerAppParams{}, err
 	}
+	imagePorts := defaults.applyToPorts(imageMetadata.Ports)
 
-	cmd.output.Say(fmt.Sprintf("Removing %s", appName))
-	ok := cmd.pollUntilSuccess(func() bool {
-		appExists, err := cmd.appRunner.AppExists(appName)
-		return err == nil && !appExists
-	}, true)
+	if app.MemoryMB == nil && defaults.memoryMB != 0 {
+		params.MemoryMB = defaults.memoryMB
+	}
 
-	if ok {
-		cmd.output.Say(colors.Green("Successfully Removed " + appName + "."))
-	} else {
-		cmd.output.Say(colors.Red(fmt.Sprintf("Failed to remove %s.", appName)))
+	switch {
+	case len(app.Ports) > 0:
+		exposedPorts := make([]uint16, len(app.Ports))
+		copy(exposedPorts, app.Ports)
+		sort.Sort(uint16Slice(exposedPorts))
+
+		monitoredPort := app.MonitoredPort
+		if monitoredPort == 0 && len(exposedPorts) == 1 {
+			monitoredPort = exposedPorts[0]
+		}
+		params.Ports = docker_app_runner.PortConfig{Monitored: monitoredPort, Exposed: exposedPorts}
+	case !imagePorts.IsEmpty():
+		params.Ports = imagePorts
+	case app.NoMonitor:
+		params.Ports = docker_app_runner.PortConfig{Monitored: 0, Exposed: []uint16{defaultPort}}
+	default:
+		params.Ports = docker_app_runner.PortConfig{Monitored: defaultPort, Exposed: []uint16{defaultPort}}
 	}
-}
 
-func (cmd *appRunnerCommand) pollUntilSuccess(pollingFunc func() bool, outputProgress bool) (ok bool) {
-	startingTime := cmd.clock.Now()
-	for startingTime.Add(cmd.timeout).After(cmd.clock.Now()) {
+	if params.WorkingDir == "" {
+		params.WorkingDir = imageMetadata.WorkingDir
+	}
+	if params.WorkingDir == "" {
+		params.WorkingDir = "/"
+	}
+
+	if params.StartCommand == "" {
+		if len(imageMetadata.StartCommand) == 0 {
+			return docker_app_runner.StartDockerAppParams{}, fmt.Errorf("No start command specified and the image metadata did not contain one")
+		}
+		params.StartCommand = imageMetadata.StartCommand[0]
+		params.AppArgs = imageMetadata.StartCommand[1:]
+	}
+
+	for _, route := range app.Routes {
+		params.RouteOverrides = append(params.RouteOverrides, docker_app_runner.RouteOverride{HostnamePrefix: route.Hostname, Port: route.Port})
+	}
+	if len(app.Routes) == 0 {
+		params.RouteOverrides = defaults.routeOverrides
+	}
+
+	return params, nil
+}
+
+func (cmd *appRunnerCommand) updateApp(context *cli.Context) {
+	appName := context.Args().First()
+	if appName == "" {
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	}
+
+	routeOverrides, err := parseRouteOverrides(context.String("routes"))
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+
+	params := docker_app_runner.UpdateAppParams{
+		Name:            appName,
+		RouteOverrides:  routeOverrides,
+		DockerImagePath: context.String("docker-image"),
+		MemoryMB:        context.Int("memory-mb"),
+	}
+
+	if envVars := context.StringSlice("env"); len(envVars) > 0 {
+		params.EnvironmentVariables = environment.Build(envVars, cmd.env)
+	}
+
+	if context.IsSet("instances") {
+		instances := context.Int("instances")
+		params.Instances = &instances
+	}
+
+	if context.IsSet("annotation") {
+		annotation := context.String("annotation")
+		if len(annotation) > maxAnnotationSize {
+			cmd.output.Say(fmt.Sprintf("Annotation is too long. Must be at most %d bytes.", maxAnnotationSize))
+			return
+		}
+		params.Annotation = &annotation
+	}
+
+	if isSet(context, "health-check", "health-check-path", "health-check-status", "health-check-command", "health-check-timeout", "start-timeout") {
+		healthCheck, err := parseHealthCheck(context)
+		if err != nil {
+			cmd.output.Say(err.Error())
+			return
+		}
+		params.HealthCheck = &healthCheck
+	}
+
+	if err := cmd.appRunner.UpdateApp(params); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Updating App: %s", err))
+		return
+	}
+
+	cmd.output.Say("Updating App: " + appName)
+
+	instances, err := cmd.appRunner.DesiredInstances(appName)
+	if err != nil {
+		cmd.output.NewLine()
+		cmd.output.Say(fmt.Sprintf("Error Updating App: %s", err))
+		return
+	}
+
+	ok := cmd.waitForInstances(appName, instances)
+
+	if ok {
+		cmd.output.Say(colors.Green("App Updated Successfully"))
+	} else {
+		cmd.output.Say(colors.Red(appName + " took too long to update."))
+	}
+}
+
+func (cmd *appRunnerCommand) redeployApp(context *cli.Context) {
+	appName := context.Args().Get(0)
+	dockerImage := context.Args().Get(1)
+	batchSize := context.Int("batch-size")
+
+	switch {
+	case appName == "" || dockerImage == "":
+		cmd.output.IncorrectUsage("APP_NAME and DOCKER_IMAGE are required")
+		return
+	case batchSize < 1:
+		cmd.output.IncorrectUsage("batch-size must be greater than 0")
+		return
+	}
+
+	instances, err := cmd.appRunner.DesiredInstances(appName)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Redeploying App: %s", err))
+		return
+	}
+
+	versionedName := nextVersionedName(appName)
+	rolling := context.Bool("rolling")
+
+	params := docker_app_runner.VersionedAppParams{
+		Name:            appName,
+		VersionedName:   versionedName,
+		DockerImagePath: dockerImage,
+		Instances:       instances,
+		Routed:          rolling,
+	}
+	if rolling {
+		params.Instances = 0
+	}
+
+	if err := cmd.appRunner.StartVersionedDockerApp(params); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Redeploying App: %s", err))
+		return
+	}
+
+	cmd.output.Say(fmt.Sprintf("Starting %s next to %s", versionedName, appName))
+
+	var ok bool
+	if rolling {
+		ok = cmd.rollInstances(appName, versionedName, instances, batchSize)
+	} else {
+		ok = cmd.waitForInstances(versionedName, instances)
+		if ok {
+			cmd.output.Say(fmt.Sprintf("Moving routes from %s to %s", appName, versionedName))
+			if err := cmd.appRunner.MoveRoutes(appName, versionedName); err != nil {
+				cmd.output.NewLine()
+				cmd.output.Say(fmt.Sprintf("Error Moving Routes: %s", err))
+				ok = false
+			}
+		}
+	}
+
+	if !ok {
+		cmd.rollback(appName, versionedName, instances)
+		return
+	}
+
+	if err := cmd.appRunner.RemoveApp(appName); err != nil {
+		cmd.output.NewLine()
+		cmd.output.Say(fmt.Sprintf("Error Removing %s: %s", appName, err))
+		return
+	}
+
+	cmd.output.NewLine()
+	cmd.output.Say(colors.Green(fmt.Sprintf("%s has been redeployed as %s.\n", appName, versionedName)))
+	cmd.output.Say(fmt.Sprintf("The app is now named %s. Use that name with ltc status, scale, logs and the other commands, and in any manifest you deploy it from.\n", versionedName))
+	cmd.output.Say(colors.Green(cmd.urlForApp(appName)))
+	cmd.output.NewLine()
+}
+
+func (cmd *appRunnerCommand) rollInstances(appName, versionedName string, instances, batchSize int) bool {
+	for shifted := 0; shifted < instances; {
+		shifted += batchSize
+		if shifted > instances {
+			shifted = instances
+		}
+
+		if err := cmd.appRunner.ScaleApp(versionedName, shifted); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error Scaling %s: %s", versionedName, err))
+			return false
+		}
+
+		if !cmd.waitForInstances(versionedName, shifted) {
+			return false
+		}
+
+		if err := cmd.appRunner.ScaleApp(appName, instances-shifted); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error Scaling %s: %s", appName, err))
+			return false
+		}
+
+		cmd.output.Say(fmt.Sprintf("Shifted %d/%d instances to %s", shifted, instances, versionedName))
+		cmd.output.NewLine()
+	}
+
+	return true
+}
+
+func (cmd *appRunnerCommand) waitForInstances(appName string, instances int) bool {
+	return cmd.waitForApp(appName, func(app app_watcher.AppState) bool {
+		return app.RunningInstances == instances
+	}, func() bool {
+		numRunning, _ := cmd.appRunner.NumOfRunningAppInstances(appName)
+		return numRunning == instances
+	}, true)
+}
+
+func (cmd *appRunnerCommand) rollback(appName, versionedName string, instances int) {
+	cmd.output.Say(colors.Red(fmt.Sprintf("%s failed to start. Rolling back to %s.", versionedName, appName)))
+	cmd.output.NewLine()
+
+	if err := cmd.appRunner.ScaleApp(appName, instances); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Restoring %s to %d instances: %s", appName, instances, err))
+		cmd.output.NewLine()
+	}
+
+	if err := cmd.appRunner.RemoveApp(versionedName); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Removing %s: %s", versionedName, err))
+		cmd.output.NewLine()
+	}
+}
+
+func (cmd *appRunnerCommand) scaleApp(c *cli.Context) {
+	appName := c.Args().First()
+	instancesArg := c.Args().Get(1)
+
+	switch {
+	case appName == "":
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	case instancesArg == "":
+		cmd.output.IncorrectUsage("Number of Instances Required")
+		return
+	}
+
+	instances, err := strconv.Atoi(instancesArg)
+	if err != nil {
+		cmd.output.IncorrectUsage("Number of Instances must be an integer")
+		return
+	}
+
+	cmd.setAppInstances(appName, instances)
+}
+
+func (cmd *appRunnerCommand) autoscaleApp(context *cli.Context) {
+	appName := context.Args().First()
+	if appName == "" {
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	}
+
+	policy := autoscaler.Policy{
+		MinInstances:      context.Int("min"),
+		MaxInstances:      context.Int("max"),
+		CPUPercentage:     float64(context.Int("cpu")),
+		MemoryPercentage:  float64(context.Int("memory")),
+		ScaleUpCooldown:   context.Duration("cooldown"),
+		ScaleDownCooldown: context.Duration("scale-down-cooldown"),
+	}
+	if err := policy.Validate(); err != nil {
+		cmd.output.IncorrectUsage(err.Error())
+		return
+	}
+
+	interval := context.Duration("interval")
+	if interval <= 0 {
+		cmd.output.IncorrectUsage("--interval must be positive")
+		return
+	}
+
+	appInfo, err := cmd.appExaminer.AppStatus(appName)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Autoscaling App: %s", err))
+		return
+	}
+
+	appScaler := autoscaler.New(appName, appInfo.MemoryMB, policy, cmd.appRunner, cmd.clock, cmd.output)
+	logReader := cmd.logReaderFactory()
+	go logReader.TailContainerMetrics(appName, appScaler.RecordMetric, func(err error) {
+		cmd.output.SayLine(err.Error())
+	})
+
+	stopChan := make(chan struct{})
+	cmd.exitHandler.OnExit(func() {
+		logReader.StopTailing()
+		close(stopChan)
+	})
+
+	cmd.output.SayLine(fmt.Sprintf("Autoscaling %s between %d and %d instances every %s. Press Ctrl-C to stop.", appName, policy.MinInstances, policy.MaxInstances, interval))
+	appScaler.Run(interval, stopChan)
+}
+
+func (cmd *appRunnerCommand) restartApp(context *cli.Context) {
+	appName := context.Args().First()
+	if appName == "" {
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	}
+
+	instances, err := cmd.appRunner.DesiredInstances(appName)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Restarting App: %s", err))
+		return
+	}
+
+	var indexes []int
+	if isSet(context, "index", "i") {
+		indexes = []int{context.Int("index")}
+	} else {
+		for index := 0; index < instances; index++ {
+			indexes = append(indexes, index)
+		}
+	}
+
+	if len(indexes) == 0 {
+		cmd.output.Say(fmt.Sprintf("%s has no instances to restart.", appName))
+		return
+	}
+
+	for restarted, index := range indexes {
+		killed, _ := cmd.instanceAt(appName, index)
+		if err := cmd.appRunner.RestartInstance(appName, index); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error Restarting Instance %d: %s", index, err))
+			return
+		}
+
+		cmd.output.Say(fmt.Sprintf("Restarting instance %d of %s", index, appName))
+		if !cmd.waitForRestart(appName, index, killed) {
+			cmd.output.Say(colors.Red(fmt.Sprintf("Instance %d of %s took too long to restart.", index, appName)))
+			return
+		}
+
+		if len(indexes) > 1 {
+			cmd.output.Say(fmt.Sprintf("Restarted %d/%d instances", restarted+1, len(indexes)))
+			cmd.output.NewLine()
+		}
+	}
+
+	if len(indexes) == 1 {
+		cmd.output.Say(colors.Green(fmt.Sprintf("Restarted instance %d of %s.", indexes[0], appName)))
+	} else {
+		cmd.output.Say(colors.Green(fmt.Sprintf("Restarted all %d instances of %s.", len(indexes), appName)))
+	}
+	cmd.output.NewLine()
+}
+
+// waitForRestart waits for the killed instance to be replaced by a running one. The
+// killed instance may still be reported as running for a moment, so the replacement
+// is told apart by its instance guid, or by when it entered its state. The app
+// state of an event does not say which instance changed, so every event is checked
+// against the instance itself.
+func (cmd *appRunnerCommand) waitForRestart(appName string, index int, killed app_examiner.InstanceInfo) bool {
+	replaced := func() bool {
+		instance, found := cmd.instanceAt(appName, index)
+		return found && instance.State == "RUNNING" && (instance.InstanceGuid != killed.InstanceGuid || instance.Since != killed.Since)
+	}
+
+	return cmd.waitForApp(appName, func(app_watcher.AppState) bool {
+		return replaced()
+	}, replaced, true)
+}
+
+func (cmd *appRunnerCommand) instanceAt(appName string, index int) (app_examiner.InstanceInfo, bool) {
+	appInfo, err := cmd.appExaminer.AppStatus(appName)
+	if err != nil {
+		return app_examiner.InstanceInfo{}, false
+	}
+
+	for _, instance := range appInfo.ActualInstances {
+		if instance.Index == index {
+			return instance, true
+		}
+	}
+	return app_examiner.InstanceInfo{}, false
+}
+
+func (cmd *appRunnerCommand) stopApp(c *cli.Context) {
+	appName := c.Args().First()
+
+	if appName == "" {
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	}
+
+	cmd.setAppInstances(appName, 0)
+}
+
+func (cmd *appRunnerCommand) setAppInstances(appName string, instances int) {
+	err := cmd.appRunner.ScaleApp(appName, instances)
+
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
+		return
+	}
+
+	cmd.output.Say(fmt.Sprintf("Scaling %s to %d instances", appName, instances))
+
+	ok := cmd.waitForApp(appName, func(app app_watcher.AppState) bool {
+		return app.RunningInstances == instances
+	}, func() bool {
+		numRunning, _ := cmd.appRunner.NumOfRunningAppInstances(appName)
+		return numRunning == instances
+	}, true)
+
+	if ok {
+		cmd.output.Say(colors.Green("App Scaled Successfully"))
+	} else {
+		cmd.output.Say(colors.Red(appName + " took too long to scale."))
+	}
+}
+
+func (cmd *appRunnerCommand) removeApp(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" {
+		cmd.output.IncorrectUsage("App Name required")
+		return
+	}
+
+	err := cmd.appRunner.RemoveApp(appName)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Stopping App: %s", err))
+		return
+	}
+
+	cmd.output.Say(fmt.Sprintf("Removing %s", appName))
+	ok := cmd.waitForApp(appName, func(app app_watcher.AppState) bool {
+		return !app.Desired && app.ActualInstances == 0
+	}, func() bool {
+		appExists, err := cmd.appRunner.AppExists(appName)
+		return err == nil && !appExists
+	}, true)
+
+	if ok {
+		cmd.output.Say(colors.Green("Successfully Removed " + appName + "."))
+	} else {
+		cmd.output.Say(colors.Red(fmt.Sprintf("Failed to remove %s.", appName)))
+	}
+}
+
+func (cmd *appRunnerCommand) exportApps(context *cli.Context) {
+	domain := context.String("domain")
+
+	apps, err := cmd.appExaminer.ListApps()
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error listing apps: %s", err))
+		return
+	}
+
+	exported := &manifest.Manifest{}
+	for _, app := range apps {
+		if app.Domain != domain {
+			continue
+		}
+
+		params, err := cmd.appRunner.DesiredApp(app.ProcessGuid)
+		if err != nil {
+			cmd.output.Say(fmt.Sprintf("Error exporting app %s: %s", app.ProcessGuid, err))
+			return
+		}
+		exported.Applications = append(exported.Applications, appManifestFromParams(params))
+	}
+
+	if len(exported.Applications) == 0 {
+		cmd.output.Say("No apps to export.")
+		return
+	}
+
+	filePath := context.String("file")
+	if filePath == "" {
+		if err := manifest.Write(cmd.output, exported); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error writing manifest: %s", err))
+		}
+		return
+	}
+
+	manifestFile, err := os.Create(filePath)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error writing manifest: %s", err))
+		return
+	}
+	defer manifestFile.Close()
+
+	if err := manifest.Write(manifestFile, exported); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error writing manifest: %s", err))
+		return
+	}
+
+	cmd.output.Say(fmt.Sprintf("Exported %d apps to %s", len(exported.Applications), filePath))
+}
+
+func (cmd *appRunnerCommand) importApps(context *cli.Context) {
+	manifestFile, err := os.Open(context.String("file"))
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error reading manifest: %s", err))
+		return
+	}
+	defer manifestFile.Close()
+
+	appManifest, err := manifest.Parse(manifestFile)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+
+	apps, err := cmd.appExaminer.ListApps()
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error listing apps: %s", err))
+		return
+	}
+	existingApps := make(map[string]struct{})
+	for _, app := range apps {
+		existingApps[app.ProcessGuid] = struct{}{}
+	}
+
+	var changedApps []docker_app_runner.StartDockerAppParams
+	for _, app := range appManifest.Applications {
+		params, err := cmd.importParams(app, context.String("domain"))
+		if err != nil {
+			cmd.output.Say(err.Error())
+			return
+		}
+
+		if _, exists := existingApps[app.Name]; !exists {
+			cmd.output.SayLine(colors.Green("+ " + app.Name))
+			changedApps = append(changedApps, params)
+			continue
+		}
+
+		fields, err := cmd.diffApp(app, params)
+		if err != nil {
+			cmd.output.Say(err.Error())
+			return
+		}
+		if len(fields) == 0 {
+			cmd.output.SayLine("= " + app.Name)
+			continue
+		}
+
+		cmd.output.SayLine(colors.Yellow("~ " + app.Name))
+		cmd.sayFieldDrifts(fields)
+		changedApps = append(changedApps, params)
+	}
+
+	if len(changedApps) == 0 {
+		cmd.output.Say("Nothing to import.")
+		return
+	}
+	if context.Bool("dry-run") {
+		return
+	}
+	if !context.Bool("yes") && !cmd.confirm(fmt.Sprintf("Import %d apps? [y/N]: ", len(changedApps))) {
+		cmd.output.Say("Import cancelled.")
+		return
+	}
+
+	for _, params := range changedApps {
+		if err := cmd.appRunner.UpsertDockerApp(params); err != nil {
+			cmd.output.Say(fmt.Sprintf("Error Importing App %s: %s", params.Name, err))
+			return
+		}
+		cmd.output.SayLine("Imported App: " + params.Name)
+	}
+
+	cmd.output.Say(colors.Green(fmt.Sprintf("Imported %d apps.", len(changedApps))))
+}
+
+// importParams builds the params ltc import would apply for an app of a manifest.
+// The image metadata is only fetched for apps without a start command.
+func (cmd *appRunnerCommand) importParams(app manifest.AppManifest, domain string) (docker_app_runner.StartDockerAppParams, error) {
+	imageMetadata := &docker_metadata_fetcher.ImageMetadata{}
+	if app.StartCommand == "" {
+		repoName, tag := docker_repository_name_formatter.ParseRepoNameAndTagFromImageReference(app.DockerImage)
+		var err error
+		if imageMetadata, err = cmd.dockerMetadataFetcher.FetchMetadata(repoName, tag); err != nil {
+			return docker_app_runner.StartDockerAppParams{}, fmt.Errorf("Error fetching image metadata for %s: %s", app.Name, err)
+		}
+	}
+
+	params, err := cmd.paramsFromManifest(app, imageMetadata)
+	if err != nil {
+		return docker_app_runner.StartDockerAppParams{}, fmt.Errorf("Error importing app %s: %s", app.Name, err)
+	}
+	params.Domain = stringOrDefault(params.Domain, domain)
+
+	return params, nil
+}
+
+// diffApp compares the params of a manifest app with the app running on lattice.
+// ltc import and ltc diff both use it, so diff shows what import would change.
+func (cmd *appRunnerCommand) diffApp(app manifest.AppManifest, params docker_app_runner.StartDockerAppParams) ([]FieldDrift, error) {
+	existing, err := cmd.appRunner.DesiredApp(app.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading app %s: %s", app.Name, err)
+	}
+
+	return diffApps(existing, params, len(app.Routes) > 0), nil
+}
+
+// AppDrift describes how an app running on lattice differs from its manifest.
+type AppDrift struct {
+	Name   string       `json:"name" yaml:"name"`
+	Change string       `json:"change" yaml:"change"`
+	Fields []FieldDrift `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+type FieldDrift struct {
+	Field    string `json:"field" yaml:"field"`
+	Live     string `json:"live" yaml:"live"`
+	Declared string `json:"declared" yaml:"declared"`
+}
+
+const (
+	AppAdded   = "added"
+	AppRemoved = "removed"
+	AppChanged = "changed"
+)
+
+func (cmd *appRunnerCommand) diffManifest(context *cli.Context) {
+	manifestFile, err := os.Open(context.String("file"))
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error reading manifest: %s", err))
+		cmd.exitHandler.Exit(exit_codes.DiffFailed)
+		return
+	}
+	defer manifestFile.Close()
+
+	appManifest, err := manifest.Parse(manifestFile)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		cmd.exitHandler.Exit(exit_codes.DiffFailed)
+		return
+	}
+
+	apps, err := cmd.appExaminer.ListApps()
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error listing apps: %s", err))
+		cmd.exitHandler.Exit(exit_codes.DiffFailed)
+		return
+	}
+
+	liveApps := make(map[string]bool)
+	for _, app := range apps {
+		liveApps[app.ProcessGuid] = true
+	}
+
+	drifts := []AppDrift{}
+	declaredApps := make(map[string]bool)
+	declaredDomains := map[string]bool{context.String("domain"): true}
+	for _, app := range appManifest.Applications {
+		declaredApps[app.Name] = true
+		declaredDomains[stringOrDefault(app.Domain, context.String("domain"))] = true
+
+		if !liveApps[app.Name] {
+			drifts = append(drifts, AppDrift{Name: app.Name, Change: AppAdded})
+			continue
+		}
+
+		params, err := cmd.importParams(app, context.String("domain"))
+		if err != nil {
+			cmd.output.Say(err.Error())
+			cmd.exitHandler.Exit(exit_codes.DiffFailed)
+			return
+		}
+
+		fields, err := cmd.diffApp(app, params)
+		if err != nil {
+			cmd.output.Say(err.Error())
+			cmd.exitHandler.Exit(exit_codes.DiffFailed)
+			return
+		}
+		if len(fields) > 0 {
+			drifts = append(drifts, AppDrift{Name: app.Name, Change: AppChanged, Fields: fields})
+		}
+	}
+	for _, app := range apps {
+		if declaredDomains[app.Domain] && !declaredApps[app.ProcessGuid] {
+			drifts = append(drifts, AppDrift{Name: app.ProcessGuid, Change: AppRemoved})
+		}
+	}
+
+	if cmd.output.IsStructured() {
+		if err := cmd.output.SayFormatted(drifts); err != nil {
+			cmd.output.Say("Error formatting output: " + err.Error())
+			cmd.exitHandler.Exit(exit_codes.DiffFailed)
+			return
+		}
+	} else {
+		cmd.sayDrifts(drifts)
+	}
+
+	if len(drifts) > 0 {
+		cmd.exitHandler.Exit(exit_codes.AppsDrifted)
+	}
+}
+
+func (cmd *appRunnerCommand) sayDrifts(drifts []AppDrift) {
+	if len(drifts) == 0 {
+		cmd.output.Say(colors.Green("No differences."))
+		return
+	}
+
+	for _, drift := range drifts {
+		switch drift.Change {
+		case AppAdded:
+			cmd.output.SayLine(colors.Green("+ " + drift.Name))
+		case AppRemoved:
+			cmd.output.SayLine(colors.Red("- " + drift.Name))
+		default:
+			cmd.output.SayLine(colors.Yellow("~ " + drift.Name))
+		}
+
+		cmd.sayFieldDrifts(drift.Fields)
+	}
+}
+
+func (cmd *appRunnerCommand) sayFieldDrifts(fields []FieldDrift) {
+	for _, field := range fields {
+		line := "    " + field.Field + ":"
+		if field.Live != "" {
+			line += " " + field.Live
+		}
+		line += " =>"
+		if field.Declared != "" {
+			line += " " + field.Declared
+		}
+		cmd.output.SayLine(line)
+	}
+}
+
+func (cmd *appRunnerCommand) confirm(prompt string) bool {
+	cmd.output.Say(prompt)
+
+	answer, _ := bufio.NewReader(cmd.input).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// waitForApp waits on the event stream for condition to hold, falling back to
+// pollingFunc for whatever is left of the timeout when the stream is unavailable
+// or closes.
+func (cmd *appRunnerCommand) waitForApp(appName string, condition func(app_watcher.AppState) bool, pollingFunc func() bool, outputProgress bool) (ok bool) {
+	deadline := cmd.clock.Now().Add(cmd.timeout)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	transitions, err := cmd.appWatcher.WatchApps(appName, stop)
+	if err != nil {
+		return cmd.pollUntil(deadline, pollingFunc, outputProgress)
+	}
+
+	timer := cmd.clock.NewTimer(cmd.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case transition, open := <-transitions:
+			if !open {
+				return cmd.pollUntil(deadline, pollingFunc, outputProgress)
+			}
+			if condition(transition.App) {
+				cmd.output.NewLine()
+				return true
+			} else if outputProgress {
+				cmd.output.Say(".")
+			}
+		case <-timer.C():
+			cmd.output.NewLine()
+			return false
+		}
+	}
+}
+
+func (cmd *appRunnerCommand) pollUntil(deadline time.Time, pollingFunc func() bool, outputProgress bool) (ok bool) {
+	for deadline.After(cmd.clock.Now()) {
 		if result := pollingFunc(); result {
 			cmd.output.NewLine()
 			return true
@@ -461,36 +1754,400 @@ func (cmd *appRunnerCommand) urlForApp(name string) string {
 	return fmt.Sprintf("http://%s.%s", name, cmd.domain)
 }
 
-func (cmd *appRunnerCommand) buildEnvironment(envVars []string) map[string]string {
-	environment := make(map[string]string)
+// runAsRoot honors an image that asks to run as root, and points out what lattice
+// cannot honor: other users and volumes.
+func (cmd *appRunnerCommand) runAsRoot(imageMetadata *docker_metadata_fetcher.ImageMetadata, runAsRoot bool) bool {
+	switch imageMetadata.User {
+	case "", "root", "0":
+		if imageMetadata.User != "" && !runAsRoot {
+			cmd.output.Say("The image runs as root, running the process as a privileged user.\n")
+			runAsRoot = true
+		}
+	default:
+		if !runAsRoot {
+			cmd.output.Say(fmt.Sprintf("Ignoring the image user %s, lattice runs processes as vcap unless --run-as-root is set.\n", imageMetadata.User))
+		}
+	}
+
+	if len(imageMetadata.Volumes) > 0 {
+		cmd.output.Say(fmt.Sprintf("The image declares volumes that lattice does not support, data written to them will not persist.\n\tVolumes: %s\n", strings.Join(imageMetadata.Volumes, ", ")))
+	}
+
+	return runAsRoot
+}
+
+var versionedNameRegexp = regexp.MustCompile(`^(.+)-v(\d+)$`)
+
+func pinImage(dockerImage, digest string) (string, error) {
+	reference, err := docker_repository_name_formatter.ParseImageReference(dockerImage)
+	if err != nil {
+		return "", err
+	}
+
+	if digest == "" {
+		return "", fmt.Errorf("the registry did not report a digest for %s. Pinning requires a registry that supports the v2 API.", dockerImage)
+	}
+
+	return reference.Pinned(digest).String(), nil
+}
+
+func nextVersionedName(appName string) string {
+	if matches := versionedNameRegexp.FindStringSubmatch(appName); matches != nil {
+		version, _ := strconv.Atoi(matches[2])
+		return fmt.Sprintf("%s-v%d", matches[1], version+1)
+	}
+
+	return appName + "-v2"
+}
+
+func parseRouteOverrides(routesFlag string) (docker_app_runner.RouteOverrides, error) {
+	var routeOverrides docker_app_runner.RouteOverrides
+
+	for _, routeStr := range strings.Split(routesFlag, ",") {
+		if routeStr == "" {
+			continue
+		}
+		routeArr := strings.Split(routeStr, ":")
+		maybePort, err := strconv.Atoi(routeArr[0])
+		if err != nil || len(routeArr) < 2 {
+			return nil, errors.New(MalformedRouteErrorMessage)
+		}
+
+		port := uint16(maybePort)
+		hostnamePrefix := routeArr[1]
+		routeOverrides = append(routeOverrides, docker_app_runner.RouteOverride{HostnamePrefix: hostnamePrefix, Port: port})
+	}
+
+	return routeOverrides, nil
+}
+
+var domainRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func validateContainerOptions(context *cli.Context) error {
+	if cpuWeight := context.Int("cpu-weight"); cpuWeight > maxCPUWeight || (context.IsSet("cpu-weight") && cpuWeight < 1) {
+		return fmt.Errorf("Invalid CPU weight: %d. Must be between 1 and %d.", cpuWeight, maxCPUWeight)
+	}
+	if strings.TrimSpace(context.String("log-source")) == "" {
+		return errors.New("Incorrect Usage: --log-source must not be empty")
+	}
+	if strings.TrimSpace(context.String("stack")) == "" {
+		return errors.New("Incorrect Usage: --stack must not be empty")
+	}
+	if domain := context.String("domain"); !domainRegexp.MatchString(domain) {
+		return fmt.Errorf("Invalid domain: %q. Domains may contain letters, digits, '.', '-' and '_'.", domain)
+	}
+	if len(context.String("annotation")) > maxAnnotationSize {
+		return fmt.Errorf("Annotation is too long. Must be at most %d bytes.", maxAnnotationSize)
+	}
+	return nil
+}
+
+// parseHealthCheck reads the health check flags. Without --health-check, the
+// type follows from the other flags given, falling back to a port check.
+func parseHealthCheck(context *cli.Context) (docker_app_runner.HealthCheck, error) {
+	healthCheck := docker_app_runner.HealthCheck{
+		Type:         docker_app_runner.HealthCheckType(context.String("health-check")),
+		Path:         context.String("health-check-path"),
+		Command:      context.String("health-check-command"),
+		Timeout:      context.Duration("health-check-timeout"),
+		StartTimeout: context.Duration("start-timeout"),
+	}
+
+	return validateHealthCheck(healthCheck, context.Int("health-check-status"))
+}
+
+// healthCheckFromManifest reads the health check of a manifest the same way as the
+// health check flags.
+func healthCheckFromManifest(manifestHealthCheck manifest.HealthCheck) (docker_app_runner.HealthCheck, error) {
+	healthCheck := docker_app_runner.HealthCheck{
+		Type:    docker_app_runner.HealthCheckType(manifestHealthCheck.Type),
+		Path:    manifestHealthCheck.Path,
+		Command: manifestHealthCheck.Command,
+	}
+	if manifestHealthCheck.Timeout != "" {
+		healthCheck.Timeout, _ = time.ParseDuration(manifestHealthCheck.Timeout)
+	}
+	if manifestHealthCheck.StartTimeout != "" {
+		healthCheck.StartTimeout, _ = time.ParseDuration(manifestHealthCheck.StartTimeout)
+	}
+
+	return validateHealthCheck(healthCheck, manifestHealthCheck.Status)
+}
 
-	for _, envVarPair := range envVars {
-		name, value := parseEnvVarPair(envVarPair)
+// validateHealthCheck fills in the type of the health check and checks its
+// options. status is the HTTP status asked for, 0 when none was given.
+func validateHealthCheck(healthCheck docker_app_runner.HealthCheck, status int) (docker_app_runner.HealthCheck, error) {
+	if status != 0 && status != 200 {
+		return docker_app_runner.HealthCheck{}, fmt.Errorf("Invalid HTTP status for the health check: %d. The lattice healthcheck only passes on 200.", status)
+	}
 
-		if value == "" {
-			value = cmd.grabVarFromEnv(name)
+	if healthCheck.Type == "" {
+		switch {
+		case healthCheck.Command != "":
+			healthCheck.Type = docker_app_runner.CommandHealthCheck
+		case healthCheck.Path != "" || status != 0:
+			healthCheck.Type = docker_app_runner.HTTPHealthCheck
+		default:
+			healthCheck.Type = docker_app_runner.PortHealthCheck
 		}
+	}
 
-		environment[name] = value
+	switch healthCheck.Type {
+	case docker_app_runner.PortHealthCheck, docker_app_runner.NoHealthCheck:
+	case docker_app_runner.HTTPHealthCheck:
+		if healthCheck.Path == "" {
+			healthCheck.Path = "/"
+		} else if !strings.HasPrefix(healthCheck.Path, "/") {
+			healthCheck.Path = "/" + healthCheck.Path
+		}
+	case docker_app_runner.CommandHealthCheck:
+		if healthCheck.Command == "" {
+			return docker_app_runner.HealthCheck{}, errors.New("Incorrect Usage: --health-check-command is required for command health checks")
+		}
+	default:
+		return docker_app_runner.HealthCheck{}, fmt.Errorf("Invalid health check: %s. Must be one of port, http, command or none.", healthCheck.Type)
 	}
-	return environment
+
+	return healthCheck, nil
 }
 
-func (cmd *appRunnerCommand) grabVarFromEnv(name string) string {
-	for _, envVarPair := range cmd.env {
-		if strings.HasPrefix(envVarPair, name) {
-			_, value := parseEnvVarPair(envVarPair)
-			return value
+func appManifestFromParams(params docker_app_runner.StartDockerAppParams) manifest.AppManifest {
+	instances, memoryMB, diskMB := params.Instances, params.MemoryMB, params.DiskMB
+
+	app := manifest.AppManifest{
+		Name:         params.Name,
+		DockerImage:  params.DockerImagePath,
+		StartCommand: params.StartCommand,
+		AppArgs:      params.AppArgs,
+		WorkingDir:   params.WorkingDir,
+		RunAsRoot:    params.Privileged,
+		Env:          params.EnvironmentVariables,
+		Ports:        params.Ports.Exposed,
+		NoMonitor:    !params.Monitor,
+		Instances:    &instances,
+		MemoryMB:     &memoryMB,
+		DiskMB:       &diskMB,
+		CPUWeight:    params.CPUWeight,
+		Annotation:   params.Annotation,
+		Unprivileged: params.UnprivilegedContainer,
+	}
+	if params.LogSource != docker_app_runner.DefaultLogSource {
+		app.LogSource = params.LogSource
+	}
+	if params.Domain != docker_app_runner.DefaultDomain {
+		app.Domain = params.Domain
+	}
+	if params.Monitor {
+		app.MonitoredPort = params.Ports.Monitored
+		if healthCheck := defaultHealthCheck(params.HealthCheck); healthCheck != (docker_app_runner.HealthCheck{Type: docker_app_runner.PortHealthCheck}) {
+			app.HealthCheck = manifestHealthCheck(healthCheck)
 		}
 	}
-	return ""
+	for _, route := range params.RouteOverrides {
+		app.Routes = append(app.Routes, manifest.RouteManifest{Hostname: route.HostnamePrefix, Port: route.Port})
+	}
+
+	return app
+}
+
+func manifestHealthCheck(healthCheck docker_app_runner.HealthCheck) *manifest.HealthCheck {
+	manifestHealthCheck := &manifest.HealthCheck{
+		Type:    string(healthCheck.Type),
+		Path:    healthCheck.Path,
+		Command: healthCheck.Command,
+	}
+	if healthCheck.Timeout != 0 {
+		manifestHealthCheck.Timeout = healthCheck.Timeout.String()
+	}
+	if healthCheck.StartTimeout != 0 {
+		manifestHealthCheck.StartTimeout = healthCheck.StartTimeout.String()
+	}
+	return manifestHealthCheck
 }
 
-func parseEnvVarPair(envVarPair string) (name, value string) {
-	s := strings.Split(envVarPair, "=")
-	if len(s) > 1 {
-		return s[0], s[1]
-	} else {
-		return s[0], ""
+// defaultHealthCheck fills in the port check that an app without a health check gets.
+func defaultHealthCheck(healthCheck docker_app_runner.HealthCheck) docker_app_runner.HealthCheck {
+	if healthCheck.Type == "" {
+		healthCheck.Type = docker_app_runner.PortHealthCheck
+	}
+	return healthCheck
+}
+
+func describeHealthCheck(healthCheck docker_app_runner.HealthCheck) string {
+	healthCheck = defaultHealthCheck(healthCheck)
+
+	description := []string{string(healthCheck.Type)}
+	switch healthCheck.Type {
+	case docker_app_runner.HTTPHealthCheck:
+		description = append(description, healthCheck.Path)
+	case docker_app_runner.CommandHealthCheck:
+		description = append(description, strconv.Quote(healthCheck.Command))
+	}
+	if healthCheck.Timeout != 0 {
+		description = append(description, "timeout "+healthCheck.Timeout.String())
+	}
+	if healthCheck.StartTimeout != 0 {
+		description = append(description, "start_timeout "+healthCheck.StartTimeout.String())
+	}
+
+	return strings.Join(description, " ")
+}
+
+// diffApps describes how an imported app differs from the one running on lattice.
+// Routes are only compared when the manifest declares them, since an app without
+// routes gets default ones.
+func diffApps(existing, imported docker_app_runner.StartDockerAppParams, compareRoutes bool) []FieldDrift {
+	var differences []FieldDrift
+	differ := func(field string, from, to interface{}) {
+		if fmt.Sprint(from) != fmt.Sprint(to) {
+			differences = append(differences, FieldDrift{Field: field, Live: fmt.Sprint(from), Declared: fmt.Sprint(to)})
+		}
+	}
+
+	existingImage, _ := docker_repository_name_formatter.FormatForReceptor(existing.DockerImagePath)
+	importedImage, _ := docker_repository_name_formatter.FormatForReceptor(imported.DockerImagePath)
+	if existingImage != importedImage {
+		differences = append(differences, FieldDrift{Field: "docker_image", Live: existing.DockerImagePath, Declared: imported.DockerImagePath})
+	}
+
+	differ("start_command", strings.Join(append([]string{existing.StartCommand}, existing.AppArgs...), " "), strings.Join(append([]string{imported.StartCommand}, imported.AppArgs...), " "))
+	differ("working_dir", existing.WorkingDir, imported.WorkingDir)
+	differ("run_as_root", existing.Privileged, imported.Privileged)
+
+	envNames := make([]string, 0)
+	for name := range existing.EnvironmentVariables {
+		envNames = append(envNames, name)
+	}
+	for name := range imported.EnvironmentVariables {
+		if _, ok := existing.EnvironmentVariables[name]; !ok {
+			envNames = append(envNames, name)
+		}
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		from, existed := existing.EnvironmentVariables[name]
+		to, imports := imported.EnvironmentVariables[name]
+		if !existed || !imports || from != to {
+			differences = append(differences, FieldDrift{Field: "env " + name, Live: from, Declared: to})
+		}
+	}
+
+	differ("ports", existing.Ports.Exposed, imported.Ports.Exposed)
+	differ("no_monitor", !existing.Monitor, !imported.Monitor)
+	if existing.Monitor && imported.Monitor {
+		differ("monitored_port", existing.Ports.Monitored, imported.Ports.Monitored)
+		differ("health_check", describeHealthCheck(existing.HealthCheck), describeHealthCheck(imported.HealthCheck))
+	}
+	if compareRoutes {
+		differ("routes", routeStrings(existing.RouteOverrides), routeStrings(imported.RouteOverrides))
+	}
+	differ("memory_mb", existing.MemoryMB, imported.MemoryMB)
+	differ("disk_mb", existing.DiskMB, imported.DiskMB)
+	differ("cpu_weight", existing.CPUWeight, imported.CPUWeight)
+	differ("unprivileged", existing.UnprivilegedContainer, imported.UnprivilegedContainer)
+	differ("log_source", stringOrDefault(existing.LogSource, docker_app_runner.DefaultLogSource), stringOrDefault(imported.LogSource, docker_app_runner.DefaultLogSource))
+	differ("domain", stringOrDefault(existing.Domain, docker_app_runner.DefaultDomain), stringOrDefault(imported.Domain, docker_app_runner.DefaultDomain))
+	differ("annotation", existing.Annotation, imported.Annotation)
+	differ("instances", existing.Instances, imported.Instances)
+
+	return differences
+}
+
+func routeStrings(routeOverrides docker_app_runner.RouteOverrides) []string {
+	routes := make([]string, 0, len(routeOverrides))
+	for _, route := range routeOverrides {
+		routes = append(routes, fmt.Sprintf("%s:%d", route.HostnamePrefix, route.Port))
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// imageDefaults are the lattice defaults an image supplies through its labels.
+type imageDefaults struct {
+	memoryMB       int
+	monitoredPort  uint16
+	routeOverrides docker_app_runner.RouteOverrides
+}
+
+func parseImageDefaults(labels map[string]string) (imageDefaults, error) {
+	var defaults imageDefaults
+
+	if memoryMB, ok := labels[docker_metadata_fetcher.MemoryMBLabel]; ok {
+		value, err := strconv.Atoi(memoryMB)
+		if err != nil || value <= 0 {
+			return imageDefaults{}, fmt.Errorf("Invalid %s image label: %s", docker_metadata_fetcher.MemoryMBLabel, memoryMB)
+		}
+		defaults.memoryMB = value
+	}
+
+	if monitoredPort, ok := labels[docker_metadata_fetcher.MonitoredPortLabel]; ok {
+		value, err := strconv.ParseUint(monitoredPort, 10, 16)
+		if err != nil || value == 0 {
+			return imageDefaults{}, fmt.Errorf("Invalid %s image label: %s", docker_metadata_fetcher.MonitoredPortLabel, monitoredPort)
+		}
+		defaults.monitoredPort = uint16(value)
+	}
+
+	if routes, ok := labels[docker_metadata_fetcher.RoutesLabel]; ok {
+		routeOverrides, err := parseRouteOverrides(routes)
+		if err != nil {
+			return imageDefaults{}, fmt.Errorf("Invalid %s image label: %s", docker_metadata_fetcher.RoutesLabel, err)
+		}
+		defaults.routeOverrides = routeOverrides
+	}
+
+	return defaults, nil
+}
+
+func (defaults imageDefaults) applyToPorts(ports docker_app_runner.PortConfig) docker_app_runner.PortConfig {
+	if defaults.monitoredPort == 0 {
+		return ports
+	}
+
+	exposed := []uint16{defaults.monitoredPort}
+	for _, port := range ports.Exposed {
+		if port != defaults.monitoredPort {
+			exposed = append(exposed, port)
+		}
+	}
+	sort.Sort(uint16Slice(exposed))
+
+	return docker_app_runner.PortConfig{Monitored: defaults.monitoredPort, Exposed: exposed}
+}
+
+func isSet(context *cli.Context, names ...string) bool {
+	for _, name := range names {
+		if context.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func stringOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func intOrDefault(value *int, defaultValue int) int {
+	if value == nil {
+		return defaultValue
 	}
+	return *value
+}
+
+type uint16Slice []uint16
+
+func (x uint16Slice) Len() int {
+	return len(x)
+}
+
+func (x uint16Slice) Less(i, j int) bool {
+	return x[i] < x[j]
+}
+
+func (x uint16Slice) Swap(i, j int) {
+	x[i], x[j] = x[j], x[i]
 }
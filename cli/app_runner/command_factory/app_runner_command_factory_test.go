@@ -1,9 +1,19 @@
 package command_factory_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+	"github.com/cloudfoundry/noaa/events"
 	"github.com/codegangsta/cli"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,14 +22,26 @@ import (
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager"
 
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner/fake_app_examiner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/command_factory"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner/fake_app_runner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/manifest"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher/fake_app_watcher"
 	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials/fake_registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/fake_exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs/fake_log_reader"
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/route_helpers"
 	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
 )
 
@@ -27,6 +49,7 @@ var _ = Describe("CommandFactory", func() {
 
 	var (
 		appRunner                     *fake_app_runner.FakeAppRunner
+		appWatcher                    *fake_app_watcher.FakeAppWatcher
 		outputBuffer                  *gbytes.Buffer
 		timeout                       time.Duration = 10 * time.Second
 		domain                        string        = "192.168.11.11.xip.io"
@@ -35,14 +58,18 @@ var _ = Describe("CommandFactory", func() {
 		appRunnerCommandFactoryConfig command_factory.AppRunnerCommandFactoryConfig
 		logger                        lager.Logger
 		fakeTailedLogsOutputter       *fake_tailed_logs_outputter.FakeTailedLogsOutputter
+		fakeRegistryCredentials       *fake_registry_credentials.FakeRegistryCredentials
 	)
 
 	BeforeEach(func() {
 		appRunner = &fake_app_runner.FakeAppRunner{}
+		appWatcher = &fake_app_watcher.FakeAppWatcher{}
+		appWatcher.WatchAppsReturns(nil, errors.New("event stream unavailable"))
 		outputBuffer = gbytes.NewBuffer()
 		dockerMetadataFetcher = &fake_docker_metadata_fetcher.FakeDockerMetadataFetcher{}
 		logger = lager.NewLogger("ltc-test")
 		fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
+		fakeRegistryCredentials = &fake_registry_credentials.FakeRegistryCredentials{}
 	})
 
 	Describe("StartAppCommand", func() {
@@ -56,6 +83,7 @@ var _ = Describe("CommandFactory", func() {
 
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
 				DockerMetadataFetcher: dockerMetadataFetcher,
 				Output:                output.New(outputBuffer),
 				Timeout:               timeout,
@@ -64,6 +92,7 @@ var _ = Describe("CommandFactory", func() {
 				Clock:                 clock,
 				Logger:                logger,
 				TailedLogsOutputter:   fakeTailedLogsOutputter,
+				RegistryCredentials:   fakeRegistryCredentials,
 			}
 
 			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
@@ -128,6 +157,16 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://cool-web-app.192.168.11.11.xip.io")))
 		})
 
+		It("keeps any = in env values and looks up only whole names in the environment", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--env=QUERY=a=1&b=2", "--env=COL", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+			Expect(appRunner.StartDockerAppCallCount()).To(Equal(1))
+			Expect(appRunner.StartDockerAppArgsForCall(0).EnvironmentVariables).To(Equal(map[string]string{"QUERY": "a=1&b=2", "COL": ""}))
+		})
+
 		Context("malformed route", func() {
 			It("errors out when the port is not an int", func() {
 				args := []string{
@@ -205,6 +244,332 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("Error fetching image metadata: Docker Says No."))
 		})
 
+		Context("when --registry-credentials is set", func() {
+			BeforeEach(func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+			})
+
+			It("passes the credentials for the image's registry to the AppRunner", func() {
+				fakeRegistryCredentials.LookupReturns(registry_credentials.Credentials{Username: "jim", Password: "secret"}, true, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--registry-credentials", "cool-web-app", "registry.example.com:5000/fun/app:v2", "--", "/start-me-please"})
+
+				Expect(fakeRegistryCredentials.LookupCallCount()).To(Equal(1))
+				Expect(fakeRegistryCredentials.LookupArgsForCall(0)).To(Equal("registry.example.com:5000"))
+
+				repoName, tag := dockerMetadataFetcher.FetchMetadataArgsForCall(0)
+				Expect(repoName).To(Equal("registry.example.com:5000/fun/app"))
+				Expect(tag).To(Equal("v2"))
+
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(1))
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.RegistryUsername).To(Equal("jim"))
+				Expect(startDockerAppParameters.RegistryPassword).To(Equal("secret"))
+			})
+
+			It("does not start the app when there are no credentials for the registry", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--registry-credentials", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("No credentials found for https://index.docker.io/v1/. Run ltc registry-login first."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("outputs errors reading the credentials", func() {
+				fakeRegistryCredentials.LookupReturns(registry_credentials.Credentials{}, false, errors.New("bad docker config"))
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--registry-credentials", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error reading registry credentials: bad docker config"))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("health checks", func() {
+			BeforeEach(func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			})
+
+			It("checks the monitored port by default", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.HealthCheck).To(Equal(docker_app_runner.HealthCheck{Type: docker_app_runner.PortHealthCheck}))
+			})
+
+			It("passes an http health check with its timeouts", func() {
+				args := []string{
+					"cool-web-app",
+					"fun/app",
+					"--health-check=http",
+					"--health-check-path=health",
+					"--health-check-status=200",
+					"--health-check-timeout=5s",
+					"--start-timeout=2m",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, args)
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.Monitor).To(BeTrue())
+				Expect(startDockerAppParameters.HealthCheck).To(Equal(docker_app_runner.HealthCheck{
+					Type:         docker_app_runner.HTTPHealthCheck,
+					Path:         "/health",
+					Timeout:      5 * time.Second,
+					StartTimeout: 2 * time.Minute,
+				}))
+				Expect(outputBuffer).To(test_helpers.Say("Monitoring the app on port 8080 at /health..."))
+			})
+
+			It("rejects HTTP statuses the healthcheck does not support", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--health-check-status=204", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Invalid HTTP status for the health check: 204. The lattice healthcheck only passes on 200."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("infers a command health check from --health-check-command", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--health-check-command=redis-cli ping", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.HealthCheck.Type).To(Equal(docker_app_runner.CommandHealthCheck))
+				Expect(startDockerAppParameters.HealthCheck.Command).To(Equal("redis-cli ping"))
+				Expect(outputBuffer).To(test_helpers.Say("Monitoring the app with: redis-cli ping"))
+			})
+
+			It("does not monitor the app with --health-check=none", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--health-check=none", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.Monitor).To(BeFalse())
+				Expect(outputBuffer).To(test_helpers.Say("No ports will be monitored."))
+			})
+
+			It("requires a command for command health checks", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--health-check=command", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --health-check-command is required for command health checks"))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("rejects unknown health checks", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--health-check=ping", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Invalid health check: ping. Must be one of port, http, command or none."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("container options", func() {
+			BeforeEach(func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			})
+
+			It("defaults the stack, log source and domain", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.Stack).To(Equal("lucid64"))
+				Expect(startDockerAppParameters.LogSource).To(Equal("APP"))
+				Expect(startDockerAppParameters.Domain).To(Equal("lattice"))
+				Expect(startDockerAppParameters.CPUWeight).To(BeZero())
+				Expect(startDockerAppParameters.UnprivilegedContainer).To(BeFalse())
+			})
+
+			It("passes the container options to the app runner", func() {
+				args := []string{
+					"cool-web-app",
+					"fun/app",
+					"--cpu-weight=50",
+					"--log-source=WEB",
+					"--stack=trusty64",
+					"--domain=cool",
+					"--annotation=tuned",
+					"--unprivileged-container",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, args)
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.CPUWeight).To(Equal(uint(50)))
+				Expect(startDockerAppParameters.LogSource).To(Equal("WEB"))
+				Expect(startDockerAppParameters.Stack).To(Equal("trusty64"))
+				Expect(startDockerAppParameters.Domain).To(Equal("cool"))
+				Expect(startDockerAppParameters.Annotation).To(Equal("tuned"))
+				Expect(startDockerAppParameters.UnprivilegedContainer).To(BeTrue())
+			})
+
+			It("rejects CPU weights out of range", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--cpu-weight=101", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Invalid CPU weight: 101. Must be between 1 and 100."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("rejects an empty log source", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--log-source=", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --log-source must not be empty"))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("rejects invalid domains", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--domain=my domain", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say(`Invalid domain: "my domain".`))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("rejects annotations over 10KB", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--annotation=" + strings.Repeat("a", 10*1024+1), "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Annotation is too long. Must be at most 10240 bytes."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("honoring the image metadata", func() {
+			BeforeEach(func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+			})
+
+			It("merges the image's Env under the -e flags", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					Env:          map[string]string{"PATH": "/usr/local/bin", "COLOR": "Red"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"-e", "COLOR", "cool-web-app", "fun/app"})
+
+				Expect(appRunner.StartDockerAppArgsForCall(0).EnvironmentVariables).To(Equal(map[string]string{"PATH": "/usr/local/bin", "COLOR": "Blue"}))
+			})
+
+			It("takes lattice defaults from the image labels", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					Ports:        docker_app_runner.PortConfig{Monitored: 80, Exposed: []uint16{80, 9000}},
+					Labels: map[string]string{
+						docker_metadata_fetcher.MemoryMBLabel:      "512",
+						docker_metadata_fetcher.MonitoredPortLabel: "8080",
+						docker_metadata_fetcher.RoutesLabel:        "8080:web,9000:admin",
+					},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.MemoryMB).To(Equal(512))
+				Expect(startDockerAppParameters.Ports).To(Equal(docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{80, 8080, 9000}}))
+				Expect(startDockerAppParameters.RouteOverrides).To(ContainExactly(docker_app_runner.RouteOverrides{
+					docker_app_runner.RouteOverride{HostnamePrefix: "web", Port: 8080},
+					docker_app_runner.RouteOverride{HostnamePrefix: "admin", Port: 9000},
+				}))
+			})
+
+			It("lets flags override the image labels", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					Labels: map[string]string{
+						docker_metadata_fetcher.MemoryMBLabel: "512",
+						docker_metadata_fetcher.RoutesLabel:   "8080:web",
+					},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"-m", "64", "--routes", "8080:mine", "cool-web-app", "fun/app"})
+
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.MemoryMB).To(Equal(64))
+				Expect(startDockerAppParameters.RouteOverrides).To(ContainExactly(docker_app_runner.RouteOverrides{
+					docker_app_runner.RouteOverride{HostnamePrefix: "mine", Port: 8080},
+				}))
+			})
+
+			It("does not start the app when a label is invalid", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					Labels:       map[string]string{docker_metadata_fetcher.MemoryMBLabel: "lots"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Invalid io.lattice.memory-mb image label: lots"))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+
+			It("runs images that ask for root as a privileged user", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/start-me"}, User: "root"}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("The image runs as root, running the process as a privileged user."))
+				Expect(appRunner.StartDockerAppArgsForCall(0).Privileged).To(BeTrue())
+			})
+
+			It("warns about image users and volumes lattice cannot honor", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					User:         "app",
+					Volumes:      []string{"/data", "/logs"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Ignoring the image user app, lattice runs processes as vcap unless --run-as-root is set."))
+				Expect(outputBuffer).To(test_helpers.Say("Volumes: /data, /logs"))
+				Expect(appRunner.StartDockerAppArgsForCall(0).Privileged).To(BeFalse())
+			})
+		})
+
+		Context("when --pin is set", func() {
+			const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+			BeforeEach(func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+			})
+
+			It("starts the app from the digest the tag resolves to and records it in the annotation", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{Digest: digest}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--pin", "cool-web-app", "registry.example.com:5000/fun/app:v2", "--", "/start-me-please"})
+
+				repoName, tag := dockerMetadataFetcher.FetchMetadataArgsForCall(0)
+				Expect(repoName).To(Equal("registry.example.com:5000/fun/app"))
+				Expect(tag).To(Equal("v2"))
+
+				Expect(outputBuffer).To(test_helpers.Say("Pinned registry.example.com:5000/fun/app:v2 to " + digest))
+
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(1))
+				startDockerAppParameters := appRunner.StartDockerAppArgsForCall(0)
+				Expect(startDockerAppParameters.DockerImagePath).To(Equal("registry.example.com:5000/fun/app:v2@" + digest))
+				Expect(startDockerAppParameters.Annotation).To(Equal("Pinned image: registry.example.com:5000/fun/app:v2@" + digest))
+			})
+
+			It("does not start the app when the registry reports no digest", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--pin", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error pinning image: the registry did not report a digest for fun/app. Pinning requires a registry that supports the v2 API."))
+				Expect(appRunner.StartDockerAppCallCount()).To(Equal(0))
+			})
+		})
+
+		It("does not pass registry credentials unless asked to", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app", "fun/app", "--", "/start-me-please"})
+
+			Expect(fakeRegistryCredentials.LookupCallCount()).To(Equal(0))
+			Expect(appRunner.StartDockerAppArgsForCall(0).RegistryUsername).To(BeEmpty())
+		})
+
 		Describe("exposed/monitored port behavior", func() {
 			It("blows up when you pass bad port strings", func() {
 				args := []string{
@@ -445,6 +810,46 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when the app watcher is subscribed to the event stream", func() {
+			var transitions chan app_watcher.Transition
+
+			BeforeEach(func() {
+				transitions = make(chan app_watcher.Transition)
+				appWatcher.WatchAppsReturns(transitions, nil)
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			})
+
+			It("waits on app transitions instead of polling", func() {
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(startCommand, []string{"--instances=2", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Eventually(appWatcher.WatchAppsCallCount).Should(Equal(1))
+				appName, _ := appWatcher.WatchAppsArgsForCall(0)
+				Expect(appName).To(Equal("cool-web-app"))
+
+				transitions <- app_watcher.Transition{Type: app_watcher.AppSnapshot, App: app_watcher.AppState{Desired: true, DesiredInstances: 2}}
+				transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 2, RunningInstances: 1}}
+				Consistently(commandFinishChan).ShouldNot(BeClosed())
+
+				transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 2, RunningInstances: 2}}
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app is now running.\n")))
+			})
+
+			It("alerts the user if no matching transition arrives before the timeout", func() {
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(startCommand, []string{"--instances=2", "cool-web-app", "fun/app", "--", "/start-me-please"})
+
+				Eventually(appWatcher.WatchAppsCallCount).Should(Equal(1))
+				transitions <- app_watcher.Transition{Type: app_watcher.AppSnapshot, App: app_watcher.AppState{Desired: true, DesiredInstances: 2}}
+
+				clock.IncrementBySeconds(10)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app took too long to start.")))
+			})
+		})
+
 		It("polls for the app to start with correct number of instances, outputting logs while the app starts", func() {
 			args := []string{
 				"--instances=10",
@@ -549,81 +954,1218 @@ var _ = Describe("CommandFactory", func() {
 		})
 	})
 
-	Describe("ScaleAppCommand", func() {
-		var scaleCommand cli.Command
+	Describe("DeployCommand", func() {
+		var (
+			deployCommand cli.Command
+			manifestPath  string
+		)
+
+		writeManifest := func(contents string) string {
+			manifestFile, err := ioutil.TempFile("", "lattice-manifest")
+			Expect(err).ToNot(HaveOccurred())
+			defer manifestFile.Close()
+
+			_, err = manifestFile.WriteString(contents)
+			Expect(err).ToNot(HaveOccurred())
+
+			return manifestFile.Name()
+		}
+
 		BeforeEach(func() {
 			clock = fakeclock.NewFakeClock(time.Now())
 
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
 				DockerMetadataFetcher: dockerMetadataFetcher,
 				Output:                output.New(outputBuffer),
 				Timeout:               timeout,
 				Domain:                domain,
-				Env:                   []string{},
+				Env:                   []string{"SHELL=/bin/bash"},
 				Clock:                 clock,
 				Logger:                logger,
 			}
 
 			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
-			scaleCommand = commandFactory.MakeScaleAppCommand()
+			deployCommand = commandFactory.MakeDeployCommand()
+
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/start-me"}}, nil)
 		})
 
-		It("scales an with the specified number of instances", func() {
-			args := []string{
-				"cool-web-app",
-				"22",
+		AfterEach(func() {
+			if manifestPath != "" {
+				os.Remove(manifestPath)
 			}
+		})
 
-			appRunner.NumOfRunningAppInstancesReturns(22, nil)
+		It("deploys every application in the manifest", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image:beta
+  start_command: /start-me-please
+  args: ["AppArg0"]
+  working_dir: /applications
+  run_as_root: true
+  env:
+    TIMEZONE: CST
+    SHELL: ""
+  ports: [9090, 8080]
+  monitored_port: 8080
+  routes:
+  - hostname: route-3000
+    port: 8080
+  memory_mb: 12
+  disk_mb: 12
+  instances: 2
+- name: cool-worker
+  docker_image: cool-worker-image
+`)
+			appRunner.NumOfRunningAppInstancesStub = func(name string) (int, error) {
+				if name == "cool-web-app" {
+					return 2, nil
+				}
+				return 1, nil
+			}
 
-			test_helpers.ExecuteCommandWithArgs(scaleCommand, args)
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
 
-			Expect(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 22 instances"))
-			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+			Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(Equal(2))
+			repoName, tag := dockerMetadataFetcher.FetchMetadataArgsForCall(0)
+			Expect(repoName).To(Equal("cool-web-app-image"))
+			Expect(tag).To(Equal("beta"))
+
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(2))
+
+			webParams := appRunner.UpsertDockerAppArgsForCall(0)
+			Expect(webParams.Name).To(Equal("cool-web-app"))
+			Expect(webParams.DockerImagePath).To(Equal("cool-web-app-image:beta"))
+			Expect(webParams.StartCommand).To(Equal("/start-me-please"))
+			Expect(webParams.AppArgs).To(Equal([]string{"AppArg0"}))
+			Expect(webParams.WorkingDir).To(Equal("/applications"))
+			Expect(webParams.Privileged).To(BeTrue())
+			Expect(webParams.EnvironmentVariables).To(Equal(map[string]string{"TIMEZONE": "CST", "SHELL": "/bin/bash"}))
+			Expect(webParams.Ports).To(Equal(docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{8080, 9090}}))
+			Expect(webParams.Monitor).To(BeTrue())
+			Expect(webParams.RouteOverrides).To(ContainExactly(docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{HostnamePrefix: "route-3000", Port: 8080},
+			}))
+			Expect(webParams.MemoryMB).To(Equal(12))
+			Expect(webParams.DiskMB).To(Equal(12))
+			Expect(webParams.Instances).To(Equal(2))
 
-			Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
-			name, instances := appRunner.ScaleAppArgsForCall(0)
-			Expect(name).To(Equal("cool-web-app"))
-			Expect(instances).To(Equal(22))
+			Expect(outputBuffer).To(test_helpers.Say("Deploying App: cool-web-app"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app is now running.\n")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://cool-web-app.192.168.11.11.xip.io")))
+			Expect(outputBuffer).To(test_helpers.Say("Deploying App: cool-worker"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-worker is now running.\n")))
 		})
 
-		It("polls until the required number of instances are running", func() {
-			args := []string{
-				"cool-web-app",
-				"22",
-			}
+		It("deploys the container options and health check of the manifest", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  cpu_weight: 50
+  log_source: WEB
+  domain: staging
+  annotation: the web tier
+  unprivileged: true
+  health_check:
+    path: ping
+    status: 200
+    timeout: 5s
+    start_timeout: 2m
+`)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			params := appRunner.UpsertDockerAppArgsForCall(0)
+			Expect(params.CPUWeight).To(Equal(uint(50)))
+			Expect(params.LogSource).To(Equal("WEB"))
+			Expect(params.Domain).To(Equal("staging"))
+			Expect(params.Annotation).To(Equal("the web tier"))
+			Expect(params.UnprivilegedContainer).To(BeTrue())
+			Expect(params.Monitor).To(BeTrue())
+			Expect(params.HealthCheck).To(Equal(docker_app_runner.HealthCheck{
+				Type:         docker_app_runner.HTTPHealthCheck,
+				Path:         "/ping",
+				Timeout:      5 * time.Second,
+				StartTimeout: 2 * time.Minute,
+			}))
+		})
 
+		It("does not monitor apps whose health check is none", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  health_check:
+    type: none
+`)
 			appRunner.NumOfRunningAppInstancesReturns(1, nil)
 
-			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, args)
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
 
-			Eventually(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 22 instances"))
+			Expect(appRunner.UpsertDockerAppArgsForCall(0).Monitor).To(BeFalse())
+		})
 
-			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(1))
-			Expect(appRunner.NumOfRunningAppInstancesArgsForCall(0)).To(Equal("cool-web-app"))
+		It("rejects invalid health checks and CPU weights", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  health_check:
+    type: ping
+`)
 
-			clock.IncrementBySeconds(1)
-			Eventually(outputBuffer).Should(test_helpers.Say("."))
-			clock.IncrementBySeconds(1)
-			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
 
-			appRunner.NumOfRunningAppInstancesReturns(22, nil)
-			clock.IncrementBySeconds(1)
+			Expect(outputBuffer).To(test_helpers.Say("Error Deploying App cool-web-app: Invalid health check: ping. Must be one of port, http, command or none."))
 
-			Eventually(commandFinishChan).Should(BeClosed())
+			ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  cpu_weight: 101
+`), 0644)
 
-			Expect(outputBuffer).To(test_helpers.SayNewLine())
-			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Deploying App cool-web-app: Invalid CPU weight: 101. Must be between 1 and 100."))
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(0))
 		})
 
-		It("alerts the user if the app does not scale succesfully", func() {
+		It("honors the image's Env and labels unless the manifest overrides them", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  env:
+    COLOR: Blue
+`)
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+				StartCommand: []string{"/start-me"},
+				Env:          map[string]string{"PATH": "/usr/local/bin", "COLOR": "Red"},
+				Labels: map[string]string{
+					docker_metadata_fetcher.MemoryMBLabel:      "512",
+					docker_metadata_fetcher.MonitoredPortLabel: "3000",
+					docker_metadata_fetcher.RoutesLabel:        "3000:web",
+				},
+			}, nil)
 			appRunner.NumOfRunningAppInstancesReturns(1, nil)
 
-			args := []string{
-				"cool-web-app",
-				"22",
-			}
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			params := appRunner.UpsertDockerAppArgsForCall(0)
+			Expect(params.EnvironmentVariables).To(Equal(map[string]string{"PATH": "/usr/local/bin", "COLOR": "Blue"}))
+			Expect(params.MemoryMB).To(Equal(512))
+			Expect(params.Ports).To(Equal(docker_app_runner.PortConfig{Monitored: 3000, Exposed: []uint16{3000}}))
+			Expect(params.RouteOverrides).To(ContainExactly(docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{HostnamePrefix: "web", Port: 3000},
+			}))
+		})
+
+		It("fills in defaults from the image metadata and the start command defaults", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-worker
+  docker_image: cool-worker-image
+`)
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+				WorkingDir:   "/worker",
+				StartCommand: []string{"/worker-run", "--fast"},
+				Ports:        docker_app_runner.PortConfig{Monitored: 2000, Exposed: []uint16{2000}},
+			}, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"--file", manifestPath})
+
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(1))
+			params := appRunner.UpsertDockerAppArgsForCall(0)
+			Expect(params.StartCommand).To(Equal("/worker-run"))
+			Expect(params.AppArgs).To(Equal([]string{"--fast"}))
+			Expect(params.WorkingDir).To(Equal("/worker"))
+			Expect(params.Ports).To(Equal(docker_app_runner.PortConfig{Monitored: 2000, Exposed: []uint16{2000}}))
+			Expect(params.MemoryMB).To(Equal(128))
+			Expect(params.DiskMB).To(Equal(1024))
+			Expect(params.Instances).To(Equal(1))
+		})
+
+		It("monitors the default port when the manifest and the image specify no ports", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-worker
+  docker_image: cool-worker-image
+`)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(1))
+			params := appRunner.UpsertDockerAppArgsForCall(0)
+			Expect(params.Ports).To(Equal(docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{8080}}))
+			Expect(params.WorkingDir).To(Equal("/"))
+		})
+
+		It("polls until the app is running", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+`)
+			appRunner.NumOfRunningAppInstancesReturns(0, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Deploying App: cool-web-app"))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app is now running.\n")))
+		})
+
+		It("alerts the user if an app does not start in time", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+`)
+			appRunner.NumOfRunningAppInstancesReturns(0, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Deploying App: cool-web-app"))
+
+			clock.IncrementBySeconds(10)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app took too long to start.")))
+		})
+
+		It("outputs an error when the manifest cannot be read", func() {
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", "/does/not/exist.yml"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error reading manifest:"))
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(0))
+		})
+
+		It("outputs validation errors from the manifest", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+`)
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Application cool-web-app is missing a docker_image"))
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(0))
+		})
+
+		It("outputs an error when the image metadata cannot be fetched", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+`)
+			dockerMetadataFetcher.FetchMetadataReturns(nil, errors.New("Docker Says No."))
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Deploying App cool-web-app: Error fetching image metadata: Docker Says No."))
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(0))
+		})
+
+		It("stops deploying when an app fails to deploy", func() {
+			manifestPath = writeManifest(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+- name: cool-worker
+  docker_image: cool-worker-image
+`)
+			appRunner.UpsertDockerAppReturns(errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Deploying App cool-web-app: Major Fault"))
+			Expect(appRunner.UpsertDockerAppCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("ExportCommand", func() {
+		var (
+			exportCommand   cli.Command
+			fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		)
+
+		BeforeEach(func() {
+			fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:             appRunner,
+				AppExaminer:           fakeAppExaminer,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Output:                output.New(outputBuffer),
+				Domain:                domain,
+				ExitHandler:           fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			diffCommand = commandFactory.MakeDiffCommand()
+
+			manifestFile, err := ioutil.TempFile("", "lattice-manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifestFile.WriteString(`
+applications:
+- name: new-app
+  docker_image: new-app-image
+  start_command: /start-new
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  start_command: /start-cool
+  env:
+    TIMEZONE: PST
+    COLOR: blue
+  ports: [8080]
+  routes:
+  - hostname: cool
+    port: 8080
+  instances: 3
+  memory_mb: 256
+- name: same-app
+  docker_image: same-app-image
+  start_command: /start-same
+  instances: 1
+`)
+			Expect(err).ToNot(HaveOccurred())
+			manifestFile.Close()
+			manifestPath = manifestFile.Name()
+
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{ProcessGuid: "cool-web-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "old-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "same-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "cf-app", Domain: "cf-apps"},
+			}, nil)
+			appRunner.DesiredAppStub = func(name string) (docker_app_runner.StartDockerAppParams, error) {
+				params := docker_app_runner.StartDockerAppParams{
+					Name:                 name,
+					DockerImagePath:      name + "-image",
+					StartCommand:         "/start-" + strings.TrimSuffix(name, "-app"),
+					WorkingDir:           "/",
+					EnvironmentVariables: map[string]string{},
+					Monitor:              true,
+					Instances:            1,
+					MemoryMB:             128,
+					DiskMB:               1024,
+					Ports:                docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{8080}},
+					Domain:               "lattice",
+				}
+				if name == "cool-web-app" {
+					params.StartCommand = "/start-cool"
+					params.EnvironmentVariables = map[string]string{"TIMEZONE": "CST", "LANG": "en_US"}
+					params.RouteOverrides = docker_app_runner.RouteOverrides{docker_app_runner.RouteOverride{HostnamePrefix: "cool-web-app", Port: 8080}}
+				}
+				return params, nil
+			}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("shows the apps and fields that drifted from the manifest", func() {
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("+ new-app")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("~ cool-web-app")))
+			Expect(outputBuffer).To(test_helpers.Say("    env COLOR: => blue\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    env LANG: en_US =>\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    env TIMEZONE: CST => PST\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    routes: [cool-web-app:8080] => [cool:8080]\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    memory_mb: 128 => 256\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    instances: 1 => 3\n"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("- old-app")))
+			Expect(outputBuffer).ToNot(test_helpers.Say("same-app"))
+			Expect(outputBuffer).ToNot(test_helpers.Say("cf-app"))
+
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppsDrifted}))
+		})
+
+		It("compares the fields the manifest leaves out with the defaults import would use", func() {
+			ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: same-app
+  docker_image: same-app-image
+  start_command: /start-same
+`), 0644)
+			appRunner.DesiredAppReturns(docker_app_runner.StartDockerAppParams{
+				Name:            "same-app",
+				DockerImagePath: "same-app-image",
+				StartCommand:    "/start-same",
+				WorkingDir:      "/",
+				Monitor:         true,
+				Instances:       4,
+				MemoryMB:        128,
+				DiskMB:          1024,
+				Ports:           docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{8080}},
+			}, nil)
+			appRunner.DesiredAppStub = nil
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("~ same-app")))
+			Expect(outputBuffer).To(test_helpers.Say("    instances: 4 => 1\n"))
+		})
+
+		It("exits cleanly when nothing drifted", func() {
+			ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: old-app
+  docker_image: old-app-image
+  start_command: /start-old
+- name: same-app
+  docker_image: same-app-image
+  start_command: /start-same
+`), 0644)
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{ProcessGuid: "old-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "same-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "cf-app", Domain: "cf-apps"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("No differences.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("compares the apps in another domain", func() {
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath, "--domain", "cf-apps"})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("~ cool-web-app")))
+			Expect(outputBuffer).To(test_helpers.Say("    domain: lattice => cf-apps\n"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("- cf-app")))
+		})
+
+		It("matches the apps that give their own domain against it", func() {
+			ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: same-app
+  docker_image: same-app-image
+  start_command: /start-same
+- name: cf-app
+  docker_image: cf-app-image
+  start_command: /start-cf
+  domain: cf-apps
+`), 0644)
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{ProcessGuid: "same-app", Domain: "lattice"},
+				app_examiner.AppInfo{ProcessGuid: "cf-app", Domain: "cf-apps"},
+				app_examiner.AppInfo{ProcessGuid: "other-cf-app", Domain: "cf-apps"},
+			}, nil)
+			appRunner.DesiredAppStub = func(name string) (docker_app_runner.StartDockerAppParams, error) {
+				params := docker_app_runner.StartDockerAppParams{
+					Name:            name,
+					DockerImagePath: name + "-image",
+					StartCommand:    "/start-" + strings.TrimSuffix(name, "-app"),
+					WorkingDir:      "/",
+					Monitor:         true,
+					Instances:       1,
+					MemoryMB:        128,
+					DiskMB:          1024,
+					Ports:           docker_app_runner.PortConfig{Monitored: 8080, Exposed: []uint16{8080}},
+					Domain:          "lattice",
+				}
+				if name == "cf-app" {
+					params.Domain = "cf-apps"
+				}
+				return params, nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("- other-cf-app")))
+			Expect(string(outputBuffer.Contents())).ToNot(ContainSubstring(" cf-app"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppsDrifted}))
+		})
+
+		It("writes the drift as structured output", func() {
+			appRunnerCommandFactoryConfig.Output.SetFormat("json")
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			var drifts []command_factory.AppDrift
+			Expect(json.Unmarshal(outputBuffer.Contents(), &drifts)).To(Succeed())
+			Expect(drifts).To(HaveLen(3))
+			Expect(drifts[0]).To(Equal(command_factory.AppDrift{Name: "new-app", Change: command_factory.AppAdded}))
+			Expect(drifts[1].Change).To(Equal(command_factory.AppChanged))
+			Expect(drifts[1].Fields).To(ContainElement(command_factory.FieldDrift{Field: "instances", Live: "1", Declared: "3"}))
+			Expect(drifts[2]).To(Equal(command_factory.AppDrift{Name: "old-app", Change: command_factory.AppRemoved}))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppsDrifted}))
+		})
+
+		It("exits with an error when listing the apps fails", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("Receptor is down"))
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error listing apps: Receptor is down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.DiffFailed}))
+		})
+
+		It("exits with an error when the manifest cannot be read", func() {
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", "/nonexistent/lattice.yml"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error reading manifest: open /nonexistent/lattice.yml"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.DiffFailed}))
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(Equal(0))
+		})
+
+		It("exits with an error when the manifest is invalid", func() {
+			ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: cool-web-app
+`), 0644)
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.Say("Application cool-web-app is missing a docker_image"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.DiffFailed}))
+		})
+
+		Context("after deploying the same manifest", func() {
+			var (
+				fakeReceptorClient *fake_receptor.FakeClient
+				deployCommand      cli.Command
+			)
+
+			BeforeEach(func() {
+				ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  env:
+    GREETING: hello
+  ports: [8080, 9090]
+  monitored_port: 8080
+  routes:
+  - hostname: cool
+    port: 8080
+  - hostname: cool-admin
+    port: 9090
+  instances: 2
+  memory_mb: 256
+  disk_mb: 512
+`), 0644)
+
+				fakeReceptorClient = &fake_receptor.FakeClient{}
+				fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+				fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+					receptor.ActualLRPResponse{ProcessGuid: "cool-web-app", Index: 0, State: receptor.ActualLRPStateRunning},
+					receptor.ActualLRPResponse{ProcessGuid: "cool-web-app", Index: 1, State: receptor.ActualLRPStateRunning},
+				}, nil)
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start-me"},
+					Env:          map[string]string{"PATH": "/usr/local/bin:/usr/bin", "LANG": "en_US"},
+				}, nil)
+
+				deployCommand = command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+					AppRunner:             docker_app_runner.New(fakeReceptorClient, domain, "http://file-server.example.com", fakeclock.NewFakeClock(time.Now())),
+					AppWatcher:            appWatcher,
+					DockerMetadataFetcher: dockerMetadataFetcher,
+					Output:                output.New(gbytes.NewBuffer()),
+					Timeout:               timeout,
+					Domain:                domain,
+					Clock:                 fakeclock.NewFakeClock(time.Now()),
+					Logger:                logger,
+				}).MakeDeployCommand()
+				test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+				requestJSON, err := json.Marshal(fakeReceptorClient.CreateDesiredLRPArgsForCall(0))
+				Expect(err).ToNot(HaveOccurred())
+				var desiredLRP receptor.DesiredLRPResponse
+				Expect(json.Unmarshal(requestJSON, &desiredLRP)).To(Succeed())
+				fakeReceptorClient.GetDesiredLRPReturns(desiredLRP, nil)
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{desiredLRP}, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+					receptor.ActualLRPResponse{ProcessGuid: "cool-web-app", Index: 0, State: receptor.ActualLRPStateRunning},
+					receptor.ActualLRPResponse{ProcessGuid: "cool-web-app", Index: 1, State: receptor.ActualLRPStateRunning},
+				}, nil)
+
+				appRunnerCommandFactoryConfig.AppExaminer = app_examiner.New(fakeReceptorClient)
+				diffCommand = command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig).MakeDiffCommand()
+			})
+
+			It("reports no differences, even though the image added to the environment", func() {
+				test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("No differences.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("leaves the app in place when the manifest is deployed again", func() {
+				test_helpers.ExecuteCommandWithArgs(deployCommand, []string{"-f", manifestPath})
+
+				Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(0))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			})
+
+			It("reports a changed declared variable", func() {
+				ioutil.WriteFile(manifestPath, []byte(`
+applications:
+- name: cool-web-app
+  docker_image: cool-web-app-image
+  env:
+    GREETING: goodbye
+`), 0644)
+
+				test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"-f", manifestPath})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("~ cool-web-app")))
+				Expect(outputBuffer).To(test_helpers.Say("    env GREETING: hello => goodbye\n"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppsDrifted}))
+			})
+		})
+	})
+
+	Describe("UpdateAppCommand", func() {
+		var updateCommand cli.Command
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Output:                output.New(outputBuffer),
+				Timeout:               timeout,
+				Domain:                domain,
+				Env:                   []string{"SHELL=/bin/bash"},
+				Clock:                 clock,
+				Logger:                logger,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			updateCommand = commandFactory.MakeUpdateAppCommand()
+		})
+
+		It("updates the app with the specified flags", func() {
+			args := []string{
+				"--instances=3",
+				"--routes=8080:wiggle,9090:swang",
+				"--annotation=some annotation",
+				"--docker-image=cool-web-app-image:v2",
+				"--memory-mb=256",
+				"--env=TIMEZONE=CST",
+				"--env=SHELL",
+				"cool-web-app",
+			}
+			appRunner.DesiredInstancesReturns(3, nil)
+			appRunner.NumOfRunningAppInstancesReturns(3, nil)
+
+			test_helpers.ExecuteCommandWithArgs(updateCommand, args)
+
+			Expect(appRunner.UpdateAppCallCount()).To(Equal(1))
+			params := appRunner.UpdateAppArgsForCall(0)
+			Expect(params.Name).To(Equal("cool-web-app"))
+			Expect(*params.Instances).To(Equal(3))
+			Expect(*params.Annotation).To(Equal("some annotation"))
+			Expect(params.DockerImagePath).To(Equal("cool-web-app-image:v2"))
+			Expect(params.MemoryMB).To(Equal(256))
+			Expect(params.EnvironmentVariables).To(Equal(map[string]string{"TIMEZONE": "CST", "SHELL": "/bin/bash"}))
+			Expect(params.RouteOverrides).To(ContainExactly(docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{HostnamePrefix: "wiggle", Port: 8080},
+				docker_app_runner.RouteOverride{HostnamePrefix: "swang", Port: 9090},
+			}))
+
+			Expect(outputBuffer).To(test_helpers.Say("Updating App: cool-web-app"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Updated Successfully")))
+		})
+
+		It("leaves unspecified fields unset", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"cool-web-app"})
+
+			Expect(appRunner.UpdateAppCallCount()).To(Equal(1))
+			params := appRunner.UpdateAppArgsForCall(0)
+			Expect(params.Instances).To(BeNil())
+			Expect(params.Annotation).To(BeNil())
+			Expect(params.RouteOverrides).To(BeEmpty())
+			Expect(params.DockerImagePath).To(BeEmpty())
+			Expect(params.EnvironmentVariables).To(BeNil())
+			Expect(params.MemoryMB).To(BeZero())
+			Expect(params.HealthCheck).To(BeNil())
+		})
+
+		It("waits for the desired instances to be running when the instances are not changed", func() {
+			appRunner.DesiredInstancesReturns(2, nil)
+			appRunner.NumOfRunningAppInstancesReturns(0, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(updateCommand, []string{"--memory-mb=256", "cool-web-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Updating App: cool-web-app"))
+			Expect(appRunner.DesiredInstancesArgsForCall(0)).To(Equal("cool-web-app"))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			Consistently(commandFinishChan).ShouldNot(BeClosed())
+
+			appRunner.NumOfRunningAppInstancesReturns(2, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Updated Successfully")))
+		})
+
+		It("outputs errors getting the desired instances", func() {
+			appRunner.DesiredInstancesReturns(0, errors.New("Minor Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Updating App: Minor Fault"))
+			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(0))
+		})
+
+		It("updates the health check", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"--health-check-path=/ping", "cool-web-app"})
+
+			params := appRunner.UpdateAppArgsForCall(0)
+			Expect(params.HealthCheck).To(Equal(&docker_app_runner.HealthCheck{Type: docker_app_runner.HTTPHealthCheck, Path: "/ping"}))
+		})
+
+		It("does not update the app when the health check is invalid", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"--health-check=ping", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Invalid health check: ping. Must be one of port, http, command or none."))
+			Expect(appRunner.UpdateAppCallCount()).To(Equal(0))
+		})
+
+		It("allows clearing the annotation", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"--annotation=", "cool-web-app"})
+
+			params := appRunner.UpdateAppArgsForCall(0)
+			Expect(*params.Annotation).To(BeEmpty())
+		})
+
+		It("polls until the requested number of instances are running", func() {
+			appRunner.DesiredInstancesReturns(3, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(updateCommand, []string{"--instances=3", "cool-web-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Updating App: cool-web-app"))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+
+			appRunner.NumOfRunningAppInstancesReturns(3, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Updated Successfully")))
+		})
+
+		It("waits on app transitions when the app watcher is subscribed to the event stream", func() {
+			transitions := make(chan app_watcher.Transition)
+			appWatcher.WatchAppsReturns(transitions, nil)
+			appRunner.DesiredInstancesReturns(3, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(updateCommand, []string{"--instances=3", "cool-web-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Updating App: cool-web-app"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 3, RunningInstances: 3}}
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Updated Successfully")))
+		})
+
+		It("alerts the user if the app does not update in time", func() {
+			appRunner.DesiredInstancesReturns(3, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(updateCommand, []string{"--instances=3", "cool-web-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Updating App: cool-web-app"))
+
+			clock.IncrementBySeconds(10)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app took too long to update.")))
+		})
+
+		It("outputs error messages", func() {
+			appRunner.UpdateAppReturns(errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Updating App: Major Fault"))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: App Name required"))
+			Expect(appRunner.UpdateAppCallCount()).To(Equal(0))
+		})
+
+		It("validates the routes", func() {
+			test_helpers.ExecuteCommandWithArgs(updateCommand, []string{"--routes=wiggle", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+			Expect(appRunner.UpdateAppCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("RedeployCommand", func() {
+		var redeployCommand cli.Command
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Output:                output.New(outputBuffer),
+				Timeout:               timeout,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			redeployCommand = commandFactory.MakeRedeployCommand()
+
+			appRunner.DesiredInstancesReturns(3, nil)
+		})
+
+		Context("blue/green", func() {
+			It("starts a versioned app, moves the routes over and removes the old app", func() {
+				appRunner.NumOfRunningAppInstancesReturns(3, nil)
+
+				test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+				Expect(appRunner.DesiredInstancesArgsForCall(0)).To(Equal("cool-web-app"))
+
+				Expect(appRunner.StartVersionedDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.StartVersionedDockerAppArgsForCall(0)).To(Equal(docker_app_runner.VersionedAppParams{
+					Name:            "cool-web-app",
+					VersionedName:   "cool-web-app-v2",
+					DockerImagePath: "cool-web-app-image:v2",
+					Instances:       3,
+					Routed:          false,
+				}))
+
+				Expect(appRunner.NumOfRunningAppInstancesArgsForCall(0)).To(Equal("cool-web-app-v2"))
+
+				Expect(appRunner.MoveRoutesCallCount()).To(Equal(1))
+				fromName, toName := appRunner.MoveRoutesArgsForCall(0)
+				Expect(fromName).To(Equal("cool-web-app"))
+				Expect(toName).To(Equal("cool-web-app-v2"))
+
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app"))
+
+				Expect(outputBuffer).To(test_helpers.Say("Starting cool-web-app-v2 next to cool-web-app"))
+				Expect(outputBuffer).To(test_helpers.Say("Moving routes from cool-web-app to cool-web-app-v2"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app has been redeployed as cool-web-app-v2.\n")))
+				Expect(outputBuffer).To(test_helpers.Say("The app is now named cool-web-app-v2. Use that name with ltc status, scale, logs and the other commands, and in any manifest you deploy it from.\n"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://cool-web-app.192.168.11.11.xip.io")))
+			})
+
+			It("bumps the version of an already versioned app", func() {
+				appRunner.NumOfRunningAppInstancesReturns(3, nil)
+
+				test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app-v9", "cool-web-app-image:v10"})
+
+				Expect(appRunner.StartVersionedDockerAppArgsForCall(0).VersionedName).To(Equal("cool-web-app-v10"))
+			})
+
+			It("does not move the routes until the new version is running", func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Starting cool-web-app-v2 next to cool-web-app"))
+
+				clock.IncrementBySeconds(1)
+				Eventually(outputBuffer).Should(test_helpers.Say("."))
+				Expect(appRunner.MoveRoutesCallCount()).To(Equal(0))
+
+				appRunner.NumOfRunningAppInstancesReturns(3, nil)
+				clock.IncrementBySeconds(1)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(appRunner.MoveRoutesCallCount()).To(Equal(1))
+			})
+
+			It("rolls back if the new version does not start in time", func() {
+				appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Starting cool-web-app-v2 next to cool-web-app"))
+
+				clock.IncrementBySeconds(10)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app-v2 failed to start. Rolling back to cool-web-app.")))
+				Expect(appRunner.MoveRoutesCallCount()).To(Equal(0))
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+				name, instances := appRunner.ScaleAppArgsForCall(0)
+				Expect(name).To(Equal("cool-web-app"))
+				Expect(instances).To(Equal(3))
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-v2"))
+			})
+
+			It("rolls back if the routes cannot be moved", func() {
+				appRunner.NumOfRunningAppInstancesReturns(3, nil)
+				appRunner.MoveRoutesReturns(errors.New("Major Fault"))
+
+				test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error Moving Routes: Major Fault"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app-v2 failed to start. Rolling back to cool-web-app.")))
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-v2"))
+			})
+		})
+
+		Context("rolling", func() {
+			var runningInstances map[string]int
+
+			BeforeEach(func() {
+				runningInstances = map[string]int{"cool-web-app": 3}
+				appRunner.ScaleAppStub = func(name string, instances int) error {
+					runningInstances[name] = instances
+					return nil
+				}
+				appRunner.NumOfRunningAppInstancesStub = func(name string) (int, error) {
+					return runningInstances[name], nil
+				}
+			})
+
+			It("shifts instances over to the new version in batches", func() {
+				test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"--rolling", "--batch-size=2", "cool-web-app", "cool-web-app-image:v2"})
+
+				Expect(appRunner.StartVersionedDockerAppArgsForCall(0)).To(Equal(docker_app_runner.VersionedAppParams{
+					Name:            "cool-web-app",
+					VersionedName:   "cool-web-app-v2",
+					DockerImagePath: "cool-web-app-image:v2",
+					Instances:       0,
+					Routed:          true,
+				}))
+
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(4))
+				scaleCalls := [][]interface{}{}
+				for i := 0; i < appRunner.ScaleAppCallCount(); i++ {
+					name, instances := appRunner.ScaleAppArgsForCall(i)
+					scaleCalls = append(scaleCalls, []interface{}{name, instances})
+				}
+				Expect(scaleCalls).To(Equal([][]interface{}{
+					{"cool-web-app-v2", 2},
+					{"cool-web-app", 1},
+					{"cool-web-app-v2", 3},
+					{"cool-web-app", 0},
+				}))
+
+				Expect(appRunner.MoveRoutesCallCount()).To(Equal(0))
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app"))
+
+				Expect(outputBuffer).To(test_helpers.Say("Shifted 2/3 instances to cool-web-app-v2"))
+				Expect(outputBuffer).To(test_helpers.Say("Shifted 3/3 instances to cool-web-app-v2"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app has been redeployed as cool-web-app-v2.\n")))
+			})
+
+			It("rolls back if a batch fails to start", func() {
+				appRunner.NumOfRunningAppInstancesStub = func(name string) (int, error) {
+					if name == "cool-web-app-v2" && runningInstances[name] > 1 {
+						return 1, nil
+					}
+					return runningInstances[name], nil
+				}
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(redeployCommand, []string{"--rolling", "cool-web-app", "cool-web-app-image:v2"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Shifted 1/3 instances to cool-web-app-v2"))
+
+				clock.IncrementBySeconds(10)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app-v2 failed to start. Rolling back to cool-web-app.")))
+				Expect(runningInstances["cool-web-app"]).To(Equal(3))
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-v2"))
+			})
+		})
+
+		It("outputs errors fetching the existing app", func() {
+			appRunner.DesiredInstancesReturns(0, errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Redeploying App: Major Fault"))
+			Expect(appRunner.StartVersionedDockerAppCallCount()).To(Equal(0))
+		})
+
+		It("outputs errors starting the new version", func() {
+			appRunner.StartVersionedDockerAppReturns(errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app", "cool-web-app-image:v2"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Redeploying App: Major Fault"))
+			Expect(appRunner.RemoveAppCallCount()).To(Equal(0))
+		})
+
+		It("validates that the name and image are passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: APP_NAME and DOCKER_IMAGE are required"))
+			Expect(appRunner.StartVersionedDockerAppCallCount()).To(Equal(0))
+		})
+
+		It("validates the batch size", func() {
+			test_helpers.ExecuteCommandWithArgs(redeployCommand, []string{"--rolling", "--batch-size=0", "cool-web-app", "cool-web-app-image:v2"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: batch-size must be greater than 0"))
+			Expect(appRunner.StartVersionedDockerAppCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("ScaleAppCommand", func() {
+		var scaleCommand cli.Command
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Output:                output.New(outputBuffer),
+				Timeout:               timeout,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			scaleCommand = commandFactory.MakeScaleAppCommand()
+		})
+
+		It("scales an with the specified number of instances", func() {
+			args := []string{
+				"cool-web-app",
+				"22",
+			}
+
+			appRunner.NumOfRunningAppInstancesReturns(22, nil)
+
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, args)
+
+			Expect(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 22 instances"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+
+			Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+			name, instances := appRunner.ScaleAppArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(instances).To(Equal(22))
+		})
+
+		It("waits on app transitions when the app watcher is subscribed to the event stream", func() {
+			transitions := make(chan app_watcher.Transition)
+			appWatcher.WatchAppsReturns(transitions, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, []string{"cool-web-app", "3"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 3 instances"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 3, RunningInstances: 1}}
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 3, RunningInstances: 3}}
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+		})
+
+		It("only polls for the rest of the timeout when the event stream closes", func() {
+			transitions := make(chan app_watcher.Transition)
+			appWatcher.WatchAppsReturns(transitions, nil)
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, []string{"cool-web-app", "3"})
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppChanged, App: app_watcher.AppState{Desired: true, DesiredInstances: 3, RunningInstances: 1}}
+			clock.Increment(timeout - time.Second)
+			close(transitions)
+
+			Eventually(appRunner.NumOfRunningAppInstancesCallCount).Should(Equal(1))
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("cool-web-app took too long to scale.")))
+		})
+
+		It("polls until the required number of instances are running", func() {
+			args := []string{
+				"cool-web-app",
+				"22",
+			}
+
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, args)
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 22 instances"))
+
+			Expect(appRunner.NumOfRunningAppInstancesCallCount()).To(Equal(1))
+			Expect(appRunner.NumOfRunningAppInstancesArgsForCall(0)).To(Equal("cool-web-app"))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+
+			appRunner.NumOfRunningAppInstancesReturns(22, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.SayNewLine())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+		})
+
+		It("alerts the user if the app does not scale succesfully", func() {
+			appRunner.NumOfRunningAppInstancesReturns(1, nil)
+
+			args := []string{
+				"cool-web-app",
+				"22",
+			}
 
 			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
 			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, args)
@@ -685,6 +2227,277 @@ var _ = Describe("CommandFactory", func() {
 		})
 	})
 
+	Describe("RestartAppCommand", func() {
+		var (
+			restartCommand  cli.Command
+			fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+			instancesMutex  sync.Mutex
+			replaced        bool
+			replacedState   string
+		)
+
+		setReplaced := func(isReplaced bool, state string) {
+			instancesMutex.Lock()
+			defer instancesMutex.Unlock()
+			replaced, replacedState = isReplaced, state
+		}
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+
+			fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppWatcher:  appWatcher,
+				AppExaminer: fakeAppExaminer,
+				Output:      output.New(outputBuffer),
+				Timeout:     timeout,
+				Domain:      domain,
+				Clock:       clock,
+				Logger:      logger,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			restartCommand = commandFactory.MakeRestartAppCommand()
+
+			appRunner.DesiredInstancesReturns(3, nil)
+
+			setReplaced(true, "RUNNING")
+			fakeAppExaminer.AppStatusStub = func(name string) (app_examiner.AppInfo, error) {
+				instancesMutex.Lock()
+				defer instancesMutex.Unlock()
+
+				appInfo := app_examiner.AppInfo{ProcessGuid: name}
+				for index := 0; index < 3; index++ {
+					generation := 0
+					for call := 0; replaced && call < appRunner.RestartInstanceCallCount(); call++ {
+						if _, restartedIndex := appRunner.RestartInstanceArgsForCall(call); restartedIndex == index {
+							generation++
+						}
+					}
+
+					state := "RUNNING"
+					if generation > 0 {
+						state = replacedState
+					}
+					appInfo.ActualInstances = append(appInfo.ActualInstances, app_examiner.InstanceInfo{
+						Index:        index,
+						InstanceGuid: fmt.Sprintf("guid-%d-%d", index, generation),
+						State:        state,
+						Since:        int64(generation),
+					})
+				}
+				return appInfo, nil
+			}
+		})
+
+		It("restarts every instance in turn, waiting for each to come back", func() {
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app"})
+
+			for index := 0; index < 3; index++ {
+				Eventually(outputBuffer).Should(test_helpers.Say(fmt.Sprintf("Restarting instance %d of cool-web-app", index)))
+				Eventually(outputBuffer).Should(test_helpers.Say(fmt.Sprintf("Restarted %d/3 instances", index+1)))
+				Expect(appRunner.RestartInstanceCallCount()).To(Equal(index + 1))
+			}
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Restarted all 3 instances of cool-web-app.")))
+
+			for index := 0; index < 3; index++ {
+				name, restartedIndex := appRunner.RestartInstanceArgsForCall(index)
+				Expect(name).To(Equal("cool-web-app"))
+				Expect(restartedIndex).To(Equal(index))
+			}
+			Expect(fakeAppExaminer.AppStatusArgsForCall(0)).To(Equal("cool-web-app"))
+		})
+
+		It("restarts a single instance with --index", func() {
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app", "--index", "2"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Restarting instance 2 of cool-web-app"))
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Restarted instance 2 of cool-web-app.")))
+
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(1))
+			_, index := appRunner.RestartInstanceArgsForCall(0)
+			Expect(index).To(Equal(2))
+		})
+
+		It("does not count the killed instance as restarted while it is still reported as running", func() {
+			setReplaced(false, "RUNNING")
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app", "-i", "0"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Restarting instance 0 of cool-web-app"))
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			Consistently(commandFinishChan).ShouldNot(BeClosed())
+
+			setReplaced(true, "RUNNING")
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Restarted instance 0 of cool-web-app.")))
+		})
+
+		It("waits for the replacement to be running", func() {
+			setReplaced(true, "CLAIMED")
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app", "-i", "1"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Restarting instance 1 of cool-web-app"))
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			Consistently(commandFinishChan).ShouldNot(BeClosed())
+
+			setReplaced(true, "RUNNING")
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Restarted instance 1 of cool-web-app.")))
+		})
+
+		It("is not held up by other instances that are crashed", func() {
+			fakeAppExaminer.AppStatusStub = func(name string) (app_examiner.AppInfo, error) {
+				return app_examiner.AppInfo{ActualInstances: []app_examiner.InstanceInfo{
+					app_examiner.InstanceInfo{Index: 0, InstanceGuid: fmt.Sprintf("guid-0-%d", appRunner.RestartInstanceCallCount()), State: "RUNNING"},
+					app_examiner.InstanceInfo{Index: 1, InstanceGuid: "guid-1", State: "CRASHED"},
+				}}, nil
+			}
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app", "-i", "0"})
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Restarted instance 0 of cool-web-app.")))
+		})
+
+		It("stops when an instance takes too long to restart", func() {
+			setReplaced(true, "CRASHED")
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"cool-web-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Restarting instance 0 of cool-web-app"))
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			clock.IncrementBySeconds(10)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("Instance 0 of cool-web-app took too long to restart.")))
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(1))
+		})
+
+		It("returns errors restarting an instance", func() {
+			appRunner.RestartInstanceReturns(errors.New("Invalid instance index: 5. cool-web-app has 3 instances."))
+
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"cool-web-app", "--index", "5"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Restarting Instance 5: Invalid instance index: 5. cool-web-app has 3 instances."))
+			Expect(fakeAppExaminer.AppStatusCallCount()).To(Equal(1))
+		})
+
+		It("returns errors for apps that are not started", func() {
+			appRunner.DesiredInstancesReturns(0, errors.New("cool-web-app, is not started. Please start an app first"))
+
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Restarting App: cool-web-app, is not started. Please start an app first"))
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(0))
+		})
+
+		It("requires an app name", func() {
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("AutoscaleCommand", func() {
+		var (
+			autoscaleCommand cli.Command
+			appExaminer      *fake_app_examiner.FakeAppExaminer
+			fakeLogReader    *fake_log_reader.FakeLogReader
+			exitHandler      *fake_exit_handler.FakeExitHandler
+		)
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+			appExaminer = &fake_app_examiner.FakeAppExaminer{}
+			fakeLogReader = fake_log_reader.NewFakeLogReader()
+			exitHandler = &fake_exit_handler.FakeExitHandler{}
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:        appRunner,
+				AppExaminer:      appExaminer,
+				Output:           output.New(outputBuffer),
+				Clock:            clock,
+				Logger:           logger,
+				LogReaderFactory: func() logs.LogReader { return fakeLogReader },
+				ExitHandler:      exitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			autoscaleCommand = commandFactory.MakeAutoscaleCommand()
+
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-web-app", MemoryMB: 128}, nil)
+			appRunner.DesiredInstancesReturns(1, nil)
+		})
+
+		It("scales the app on the container metrics every interval until interrupted", func() {
+			cpuPercentage := float64(100)
+			instanceIndex := int32(0)
+			fakeLogReader.AddContainerMetric(&events.ContainerMetric{InstanceIndex: &instanceIndex, CpuPercentage: &cpuPercentage})
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(autoscaleCommand, []string{"cool-web-app", "--max=4", "--cpu=50", "--interval=5s"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Autoscaling cool-web-app between 1 and 4 instances every 5s. Press Ctrl-C to stop."))
+			Eventually(fakeLogReader.GetAppGuid).Should(Equal("cool-web-app"))
+			Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("cool-web-app"))
+
+			clock.IncrementBySeconds(5)
+
+			Eventually(appRunner.ScaleAppCallCount).Should(Equal(1))
+			name, instances := appRunner.ScaleAppArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(instances).To(Equal(2))
+			Eventually(outputBuffer).Should(test_helpers.Say("Scaled cool-web-app from 1 to 2 instances: average CPU 100.0% (target 50%)"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Eventually(fakeLogReader.IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("reports errors getting the status of the app", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New("App not found."))
+
+			test_helpers.ExecuteCommandWithArgs(autoscaleCommand, []string{"cool-web-app", "--max=4", "--cpu=50"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Autoscaling App: App not found."))
+			Expect(fakeLogReader.GetAppGuid()).To(BeEmpty())
+		})
+
+		It("requires an app name", func() {
+			test_helpers.ExecuteCommandWithArgs(autoscaleCommand, []string{"--max=4", "--cpu=50"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: App Name required"))
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(0))
+		})
+
+		It("validates the policy", func() {
+			test_helpers.ExecuteCommandWithArgs(autoscaleCommand, []string{"cool-web-app", "--max=4"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --cpu or --memory is required"))
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(0))
+		})
+
+		It("requires a positive interval", func() {
+			test_helpers.ExecuteCommandWithArgs(autoscaleCommand, []string{"cool-web-app", "--max=4", "--cpu=50", "--interval=0s"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --interval must be positive"))
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(0))
+		})
+	})
+
 	Describe("StopAppCommand", func() {
 		var stopCommand cli.Command
 		BeforeEach(func() {
@@ -692,6 +2505,7 @@ var _ = Describe("CommandFactory", func() {
 
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
 				DockerMetadataFetcher: dockerMetadataFetcher,
 				Output:                output.New(outputBuffer),
 				Timeout:               timeout,
@@ -781,6 +2595,7 @@ var _ = Describe("CommandFactory", func() {
 			clock = fakeclock.NewFakeClock(time.Now())
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:             appRunner,
+				AppWatcher:            appWatcher,
 				DockerMetadataFetcher: dockerMetadataFetcher,
 				Output:                output.New(outputBuffer),
 				Timeout:               timeout,
@@ -810,6 +2625,39 @@ var _ = Describe("CommandFactory", func() {
 			Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool"))
 		})
 
+		It("waits until the app and all of its instances are gone when the app watcher is subscribed to the event stream", func() {
+			transitions := make(chan app_watcher.Transition)
+			appWatcher.WatchAppsReturns(transitions, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"cool"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Removing cool"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppRemoved, App: app_watcher.AppState{ActualInstances: 1}}
+			Consistently(commandFinishChan).ShouldNot(BeClosed())
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceRemoved, App: app_watcher.AppState{}}
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appRunner.AppExistsCallCount()).To(Equal(0))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Successfully Removed cool.")))
+		})
+
+		It("falls back to polling when the event stream closes", func() {
+			transitions := make(chan app_watcher.Transition)
+			appWatcher.WatchAppsReturns(transitions, nil)
+			appRunner.AppExistsReturns(false, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"cool"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Removing cool"))
+			close(transitions)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appRunner.AppExistsCallCount()).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Successfully Removed cool.")))
+		})
+
 		It("polls until the app is removed", func() {
 			args := []string{
 				"cool",
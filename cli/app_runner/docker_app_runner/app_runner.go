@@ -2,18 +2,31 @@ package docker_app_runner
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_repository_name_formatter"
 	"github.com/pivotal-cf-experimental/lattice-cli/route_helpers"
+	"github.com/pivotal-golang/clock"
 )
 
 //go:generate counterfeiter -o fake_app_runner/fake_app_runner.go . AppRunner
 type AppRunner interface {
 	StartDockerApp(params StartDockerAppParams) error
+	UpsertDockerApp(params StartDockerAppParams) error
+	UpdateApp(params UpdateAppParams) error
+	StartVersionedDockerApp(params VersionedAppParams) error
+	MoveRoutes(fromName, toName string) error
+	DesiredInstances(name string) (int, error)
+	DesiredApp(name string) (StartDockerAppParams, error)
 	ScaleApp(name string, instances int) error
+	RestartInstance(name string, index int) error
 	RemoveApp(name string) error
 	AppExists(name string) (bool, error)
 	NumOfRunningAppInstances(name string) (int, error)
@@ -43,26 +56,65 @@ type StartDockerAppParams struct {
 	EnvironmentVariables map[string]string
 	Privileged           bool
 	Monitor              bool
+	HealthCheck          HealthCheck
 	Instances            int
 	MemoryMB             int
 	DiskMB               int
 	Ports                PortConfig
 	WorkingDir           string
 	RouteOverrides       RouteOverrides
+	RegistryUsername     string
+	RegistryPassword     string
+	Annotation           string
+	CPUWeight            uint
+	LogSource            string
+	Stack                string
+	Domain               string
+	// UnprivilegedContainer runs the app in an unprivileged container.
+	// Privileged only controls the user the process runs as.
+	UnprivilegedContainer bool
+}
+
+type UpdateAppParams struct {
+	Name                 string
+	Instances            *int
+	Annotation           *string
+	RouteOverrides       RouteOverrides
+	DockerImagePath      string
+	EnvironmentVariables map[string]string
+	MemoryMB             int
+	HealthCheck          *HealthCheck
+}
+
+type VersionedAppParams struct {
+	Name            string
+	VersionedName   string
+	DockerImagePath string
+	Instances       int
+	Routed          bool
 }
 
 const (
-	healthcheckDownloadUrl string = "http://file_server.service.dc1.consul:8080/v1/static/healthcheck.tgz"
-	lrpDomain              string = "lattice"
+	healthcheckDownloadPath string = "/v1/static/healthcheck.tgz"
+
+	DefaultDomain    string = "lattice"
+	DefaultStack     string = "lucid64"
+	DefaultLogSource string = "APP"
+
+	// StopTimeout bounds how long a recreate waits for the instances of the
+	// previous version to stop before desiring the new one.
+	StopTimeout time.Duration = 2 * time.Minute
 )
 
 type appRunner struct {
 	receptorClient receptor.Client
 	systemDomain   string
+	fileServerURL  string
+	clock          clock.Clock
 }
 
-func New(receptorClient receptor.Client, systemDomain string) AppRunner {
-	return &appRunner{receptorClient, systemDomain}
+func New(receptorClient receptor.Client, systemDomain, fileServerURL string, clock clock.Clock) AppRunner {
+	return &appRunner{receptorClient, systemDomain, fileServerURL, clock}
 }
 
 func (appRunner *appRunner) StartDockerApp(params StartDockerAppParams) error {
@@ -72,13 +124,180 @@ func (appRunner *appRunner) StartDockerApp(params StartDockerAppParams) error {
 		return newExistingAppError(params.Name)
 	}
 
-	if err := appRunner.receptorClient.UpsertDomain(lrpDomain, 0); err != nil {
+	if err := appRunner.receptorClient.UpsertDomain(stringOrDefault(params.Domain, DefaultDomain), 0); err != nil {
+		return err
+	}
+
+	return appRunner.desireLrp(params)
+}
+
+func (appRunner *appRunner) UpsertDockerApp(params StartDockerAppParams) error {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(params.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := appRunner.receptorClient.UpsertDomain(stringOrDefault(params.Domain, DefaultDomain), 0); err != nil {
 		return err
 	}
 
+	if exists {
+		return appRunner.convergeLrp(desiredLRP, params)
+	}
 	return appRunner.desireLrp(params)
 }
 
+func (appRunner *appRunner) UpdateApp(params UpdateAppParams) error {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(params.Name)
+	if err != nil {
+		return err
+	} else if !exists {
+		return newAppNotStartedError(params.Name)
+	}
+
+	createRequest, recreate, err := appRunner.applyUpdate(desiredLRP, params)
+	if err != nil {
+		return err
+	}
+
+	if recreate {
+		return appRunner.recreateLrp(desiredLRP, createRequest)
+	}
+
+	updateRequest := receptor.DesiredLRPUpdateRequest{
+		Instances:  params.Instances,
+		Annotation: params.Annotation,
+	}
+	if len(params.RouteOverrides) > 0 {
+		updateRequest.Routes = appRunner.routesFromOverrides(params.RouteOverrides).RoutingInfo()
+	}
+
+	return appRunner.receptorClient.UpdateDesiredLRP(params.Name, updateRequest)
+}
+
+func (appRunner *appRunner) StartVersionedDockerApp(params VersionedAppParams) error {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(params.Name)
+	if err != nil {
+		return err
+	} else if !exists {
+		return newAppNotStartedError(params.Name)
+	}
+
+	if exists, err := appRunner.desiredLRPExists(params.VersionedName); err != nil {
+		return err
+	} else if exists {
+		return newExistingAppError(params.VersionedName)
+	}
+
+	dockerImageUrl, err := formatKeepingCredentials(params.DockerImagePath, desiredLRP.RootFSPath)
+	if err != nil {
+		return err
+	}
+
+	req := createRequestFromDesiredLRP(desiredLRP)
+	req.ProcessGuid = params.VersionedName
+	req.LogGuid = params.VersionedName
+	req.RootFSPath = dockerImageUrl
+	req.Instances = params.Instances
+	if !params.Routed {
+		req.Routes = route_helpers.AppRoutes{}.RoutingInfo()
+	}
+
+	return appRunner.receptorClient.CreateDesiredLRP(req)
+}
+
+func (appRunner *appRunner) MoveRoutes(fromName, toName string) error {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(fromName)
+	if err != nil {
+		return err
+	} else if !exists {
+		return newAppNotStartedError(fromName)
+	}
+
+	if err := appRunner.receptorClient.UpdateDesiredLRP(toName, receptor.DesiredLRPUpdateRequest{Routes: desiredLRP.Routes}); err != nil {
+		return err
+	}
+
+	return appRunner.receptorClient.UpdateDesiredLRP(fromName, receptor.DesiredLRPUpdateRequest{Routes: route_helpers.AppRoutes{}.RoutingInfo()})
+}
+
+func (appRunner *appRunner) DesiredInstances(name string) (int, error) {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return 0, err
+	} else if !exists {
+		return 0, newAppNotStartedError(name)
+	}
+
+	return desiredLRP.Instances, nil
+}
+
+// DesiredApp reads a running app back into the params it would be started with, so that
+// it can be exported and started elsewhere. Registry credentials are not included. Routes
+// on the system domain are read back as hostname prefixes, and any other route as a
+// fully qualified hostname ending in a dot.
+func (appRunner *appRunner) DesiredApp(name string) (StartDockerAppParams, error) {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return StartDockerAppParams{}, err
+	} else if !exists {
+		return StartDockerAppParams{}, newAppNotStartedError(name)
+	}
+
+	reference, err := docker_repository_name_formatter.ParseReceptorRootFS(desiredLRP.RootFSPath)
+	if err != nil {
+		return StartDockerAppParams{}, err
+	}
+
+	runAction, ok := desiredLRP.Action.(*models.RunAction)
+	if !ok {
+		return StartDockerAppParams{}, fmt.Errorf("App %s was not started by ltc and cannot be exported", name)
+	}
+
+	params := StartDockerAppParams{
+		Name:                  name,
+		DockerImagePath:       reference.String(),
+		StartCommand:          runAction.Path,
+		AppArgs:               runAction.Args,
+		WorkingDir:            runAction.Dir,
+		Privileged:            runAction.Privileged,
+		EnvironmentVariables:  make(map[string]string),
+		Monitor:               desiredLRP.Monitor != nil,
+		HealthCheck:           healthCheckFromMonitor(desiredLRP.Monitor, desiredLRP.StartTimeout),
+		Instances:             desiredLRP.Instances,
+		MemoryMB:              desiredLRP.MemoryMB,
+		DiskMB:                desiredLRP.DiskMB,
+		Ports:                 PortConfig{Monitored: monitoredPort(desiredLRP.EnvironmentVariables), Exposed: desiredLRP.Ports},
+		Annotation:            desiredLRP.Annotation,
+		CPUWeight:             desiredLRP.CPUWeight,
+		LogSource:             desiredLRP.LogSource,
+		Stack:                 desiredLRP.Stack,
+		Domain:                desiredLRP.Domain,
+		UnprivilegedContainer: !desiredLRP.Privileged,
+	}
+
+	for _, envVar := range desiredLRP.EnvironmentVariables {
+		if envVar.Name != "PORT" {
+			params.EnvironmentVariables[envVar.Name] = envVar.Value
+		}
+	}
+
+	for _, route := range route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes) {
+		for _, hostname := range route.Hostnames {
+			hostnamePrefix := hostname + "."
+			if strings.HasSuffix(hostname, "."+appRunner.systemDomain) {
+				hostnamePrefix = strings.TrimSuffix(hostname, "."+appRunner.systemDomain)
+			}
+			params.RouteOverrides = append(params.RouteOverrides, RouteOverride{
+				HostnamePrefix: hostnamePrefix,
+				Port:           route.Port,
+			})
+		}
+	}
+
+	return params, nil
+}
+
 func (appRunner *appRunner) ScaleApp(name string, instances int) error {
 	if exists, err := appRunner.desiredLRPExists(name); err != nil {
 		return err
@@ -89,6 +308,23 @@ func (appRunner *appRunner) ScaleApp(name string, instances int) error {
 	return appRunner.updateLrp(name, instances)
 }
 
+// RestartInstance kills a single instance of an app. Diego starts a replacement at
+// the same index.
+func (appRunner *appRunner) RestartInstance(name string, index int) error {
+	desiredLRP, exists, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	} else if !exists {
+		return newAppNotStartedError(name)
+	}
+
+	if index < 0 || index >= desiredLRP.Instances {
+		return fmt.Errorf("Invalid instance index: %d. %s has %d instances.", index, name, desiredLRP.Instances)
+	}
+
+	return appRunner.receptorClient.KillActualLRPByProcessGuidAndIndex(name, index)
+}
+
 func (appRunner *appRunner) RemoveApp(name string) error {
 	if lrpExists, err := appRunner.desiredLRPExists(name); err != nil {
 		return err
@@ -145,50 +381,55 @@ func (appRunner *appRunner) desiredLRPExists(name string) (exists bool, err erro
 	return false, nil
 }
 
+func (appRunner *appRunner) getDesiredLRP(name string) (desiredLRP receptor.DesiredLRPResponse, exists bool, err error) {
+	desiredLRP, err = appRunner.receptorClient.GetDesiredLRP(name)
+	if err != nil {
+		if receptorError, ok := err.(receptor.Error); ok && receptorError.Type == receptor.DesiredLRPNotFound {
+			return receptor.DesiredLRPResponse{}, false, nil
+		}
+		return receptor.DesiredLRPResponse{}, false, err
+	}
+
+	return desiredLRP, true, nil
+}
+
 func (appRunner *appRunner) desireLrp(params StartDockerAppParams) error {
-	dockerImageUrl, err := docker_repository_name_formatter.FormatForReceptor(params.DockerImagePath)
+	req, err := appRunner.buildCreateRequest(params)
 	if err != nil {
 		return err
 	}
 
+	return appRunner.receptorClient.CreateDesiredLRP(req)
+}
+
+func (appRunner *appRunner) buildCreateRequest(params StartDockerAppParams) (receptor.DesiredLRPCreateRequest, error) {
+	dockerImageUrl, err := docker_repository_name_formatter.FormatForReceptorWithCredentials(params.DockerImagePath, params.RegistryUsername, params.RegistryPassword)
+	if err != nil {
+		return receptor.DesiredLRPCreateRequest{}, err
+	}
+
 	envVars := buildEnvironmentVariables(params.EnvironmentVariables)
 	envVars = append(envVars, receptor.EnvironmentVariable{Name: "PORT", Value: fmt.Sprintf("%d", params.Ports.Monitored)})
 
-	var appRoutes route_helpers.AppRoutes
-
-	if len(params.RouteOverrides) > 0 {
-		routeMap := make(map[uint16][]string)
-		for _, override := range params.RouteOverrides {
-			routeMap[override.Port] = append(routeMap[override.Port], fmt.Sprintf("%s.%s", override.HostnamePrefix, appRunner.systemDomain))
-		}
-		for port, hostnames := range routeMap {
-			appRoutes = append(appRoutes, route_helpers.AppRoute{
-				Hostnames: hostnames,
-				Port:      port,
-			})
-		}
-	} else {
-		appRoutes = appRunner.buildRoutingInfo(params.Name, params.Ports)
-	}
+	appRoutes := appRunner.buildRoutes(params)
 
 	req := receptor.DesiredLRPCreateRequest{
 		ProcessGuid:          params.Name,
-		Domain:               lrpDomain,
+		Domain:               stringOrDefault(params.Domain, DefaultDomain),
 		RootFSPath:           dockerImageUrl,
 		Instances:            params.Instances,
-		Stack:                "lucid64",
+		Stack:                stringOrDefault(params.Stack, DefaultStack),
 		Routes:               appRoutes.RoutingInfo(),
 		MemoryMB:             params.MemoryMB,
 		DiskMB:               params.DiskMB,
-		Privileged:           true,
+		CPUWeight:            params.CPUWeight,
+		Privileged:           !params.UnprivilegedContainer,
 		Ports:                params.Ports.Exposed,
 		LogGuid:              params.Name,
-		LogSource:            "APP",
+		LogSource:            stringOrDefault(params.LogSource, DefaultLogSource),
 		EnvironmentVariables: envVars,
-		Setup: &models.DownloadAction{
-			From: healthcheckDownloadUrl,
-			To:   "/tmp",
-		},
+		Annotation:           params.Annotation,
+		Setup:                appRunner.healthcheckSetup(),
 		Action: &models.RunAction{
 			Path:       params.StartCommand,
 			Args:       params.AppArgs,
@@ -198,15 +439,11 @@ func (appRunner *appRunner) desireLrp(params StartDockerAppParams) error {
 	}
 
 	if params.Monitor {
-		req.Monitor = &models.RunAction{
-			Path:      "/tmp/healthcheck",
-			Args:      []string{"-port", fmt.Sprintf("%d", params.Ports.Monitored)},
-			LogSource: "HEALTH",
-		}
+		req.Monitor = monitorAction(params.Ports.Monitored, params.HealthCheck)
+		req.StartTimeout = uint(params.HealthCheck.StartTimeout / time.Second)
 	}
-	err = appRunner.receptorClient.CreateDesiredLRP(req)
 
-	return err
+	return req, nil
 }
 
 func (appRunner *appRunner) updateLrp(name string, instances int) error {
@@ -220,6 +457,171 @@ func (appRunner *appRunner) updateLrp(name string, instances int) error {
 	return err
 }
 
+func (appRunner *appRunner) convergeLrp(desiredLRP receptor.DesiredLRPResponse, params StartDockerAppParams) error {
+	createRequest, err := appRunner.buildCreateRequest(params)
+	if err != nil {
+		return err
+	}
+
+	if params.RegistryUsername == "" {
+		if createRequest.RootFSPath, err = formatKeepingCredentials(params.DockerImagePath, desiredLRP.RootFSPath); err != nil {
+			return err
+		}
+	}
+
+	if immutableFieldsChanged(createRequest, createRequestFromDesiredLRP(desiredLRP)) {
+		return appRunner.recreateLrp(desiredLRP, createRequest)
+	}
+
+	instances := params.Instances
+	annotation := params.Annotation
+	err = appRunner.receptorClient.UpdateDesiredLRP(
+		params.Name,
+		receptor.DesiredLRPUpdateRequest{
+			Instances:  &instances,
+			Routes:     createRequest.Routes,
+			Annotation: &annotation,
+		},
+	)
+
+	return err
+}
+
+func (appRunner *appRunner) applyUpdate(desiredLRP receptor.DesiredLRPResponse, params UpdateAppParams) (req receptor.DesiredLRPCreateRequest, recreate bool, err error) {
+	req = createRequestFromDesiredLRP(desiredLRP)
+
+	if params.DockerImagePath != "" {
+		dockerImageUrl, err := formatKeepingCredentials(params.DockerImagePath, req.RootFSPath)
+		if err != nil {
+			return receptor.DesiredLRPCreateRequest{}, false, err
+		}
+		recreate = recreate || dockerImageUrl != req.RootFSPath
+		req.RootFSPath = dockerImageUrl
+	}
+
+	if params.MemoryMB != 0 {
+		recreate = recreate || params.MemoryMB != req.MemoryMB
+		req.MemoryMB = params.MemoryMB
+	}
+
+	if len(params.EnvironmentVariables) > 0 {
+		envVars := mergeEnvironmentVariables(req.EnvironmentVariables, params.EnvironmentVariables)
+		recreate = recreate || !sameEnvironmentVariables(envVars, req.EnvironmentVariables)
+		req.EnvironmentVariables = envVars
+	}
+
+	if params.HealthCheck != nil {
+		monitor := monitorAction(monitoredPort(req.EnvironmentVariables), *params.HealthCheck)
+		startTimeout := req.StartTimeout
+		if params.HealthCheck.StartTimeout != 0 {
+			startTimeout = uint(params.HealthCheck.StartTimeout / time.Second)
+		}
+		recreate = recreate || !reflect.DeepEqual(monitor, req.Monitor) || startTimeout != req.StartTimeout
+		req.Monitor = monitor
+		req.StartTimeout = startTimeout
+		if req.Setup == nil {
+			req.Setup = appRunner.healthcheckSetup()
+		}
+	}
+
+	if params.Instances != nil {
+		req.Instances = *params.Instances
+	}
+	if params.Annotation != nil {
+		req.Annotation = *params.Annotation
+	}
+	if len(params.RouteOverrides) > 0 {
+		req.Routes = appRunner.routesFromOverrides(params.RouteOverrides).RoutingInfo()
+	}
+
+	return req, recreate, nil
+}
+
+// recreateLrp replaces the existing LRP with req. The receptor cannot desire two LRPs
+// under the same guid, so the existing one is deleted first. The new version is only
+// desired once every instance of the old one has stopped, so that none of them is
+// adopted by it. If the new version cannot be desired, the existing one is desired
+// again rather than leaving the app gone.
+func (appRunner *appRunner) recreateLrp(existing receptor.DesiredLRPResponse, req receptor.DesiredLRPCreateRequest) error {
+	if err := appRunner.receptorClient.DeleteDesiredLRP(req.ProcessGuid); err != nil {
+		return err
+	}
+
+	if err := appRunner.waitForInstancesToStop(req.ProcessGuid); err != nil {
+		return appRunner.restoreLrp(existing, err)
+	}
+
+	if err := appRunner.receptorClient.CreateDesiredLRP(req); err != nil {
+		return appRunner.restoreLrp(existing, err)
+	}
+
+	return nil
+}
+
+func (appRunner *appRunner) restoreLrp(existing receptor.DesiredLRPResponse, err error) error {
+	if restoreErr := appRunner.receptorClient.CreateDesiredLRP(createRequestFromDesiredLRP(existing)); restoreErr != nil {
+		return fmt.Errorf("%s, and the previous version of %s could not be restored: %s", err, existing.ProcessGuid, restoreErr)
+	}
+	return err
+}
+
+func (appRunner *appRunner) waitForInstancesToStop(processGuid string) error {
+	deadline := appRunner.clock.Now().Add(StopTimeout)
+	for {
+		actualLRPs, err := appRunner.receptorClient.ActualLRPsByProcessGuid(processGuid)
+		if err != nil {
+			return err
+		} else if len(actualLRPs) == 0 {
+			return nil
+		}
+
+		if !appRunner.clock.Now().Before(deadline) {
+			return fmt.Errorf("Timed out waiting for the instances of %s to stop", processGuid)
+		}
+		appRunner.clock.Sleep(time.Second)
+	}
+}
+
+func (appRunner *appRunner) healthcheckSetup() models.Action {
+	return &models.DownloadAction{
+		From: appRunner.fileServerURL + healthcheckDownloadPath,
+		To:   "/tmp",
+	}
+}
+
+func (appRunner *appRunner) buildRoutes(params StartDockerAppParams) route_helpers.AppRoutes {
+	if len(params.RouteOverrides) == 0 {
+		return appRunner.buildRoutingInfo(params.Name, params.Ports)
+	}
+
+	return appRunner.routesFromOverrides(params.RouteOverrides)
+}
+
+func (appRunner *appRunner) routesFromOverrides(routeOverrides RouteOverrides) route_helpers.AppRoutes {
+	var appRoutes route_helpers.AppRoutes
+	routeMap := make(map[uint16][]string)
+	for _, override := range routeOverrides {
+		routeMap[override.Port] = append(routeMap[override.Port], appRunner.routeHostname(override.HostnamePrefix))
+	}
+	for port, hostnames := range routeMap {
+		appRoutes = append(appRoutes, route_helpers.AppRoute{
+			Hostnames: hostnames,
+			Port:      port,
+		})
+	}
+
+	return appRoutes
+}
+
+// routeHostname puts a hostname prefix on the system domain. A prefix ending in a
+// dot is already a fully qualified hostname on another domain.
+func (appRunner *appRunner) routeHostname(hostnamePrefix string) string {
+	if strings.HasSuffix(hostnamePrefix, ".") {
+		return strings.TrimSuffix(hostnamePrefix, ".")
+	}
+	return fmt.Sprintf("%s.%s", hostnamePrefix, appRunner.systemDomain)
+}
+
 func (appRunner *appRunner) buildRoutingInfo(appName string, portConfig PortConfig) route_helpers.AppRoutes {
 	appRoutes := route_helpers.AppRoutes{}
 
@@ -246,3 +648,185 @@ func buildEnvironmentVariables(environmentVariables map[string]string) []recepto
 	}
 	return appEnvVars
 }
+
+func mergeEnvironmentVariables(existing []receptor.EnvironmentVariable, updates map[string]string) []receptor.EnvironmentVariable {
+	merged := make([]receptor.EnvironmentVariable, 0, len(existing)+len(updates))
+	seen := make(map[string]bool)
+	for _, envVar := range existing {
+		if value, ok := updates[envVar.Name]; ok {
+			envVar.Value = value
+		}
+		seen[envVar.Name] = true
+		merged = append(merged, envVar)
+	}
+
+	newNames := make([]string, 0, len(updates))
+	for name := range updates {
+		if !seen[name] {
+			newNames = append(newNames, name)
+		}
+	}
+	sort.Strings(newNames)
+
+	for _, name := range newNames {
+		merged = append(merged, receptor.EnvironmentVariable{Name: name, Value: updates[name]})
+	}
+	return merged
+}
+
+func sameEnvironmentVariables(a, b []receptor.EnvironmentVariable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	values := make(map[string]string, len(a))
+	for _, envVar := range a {
+		values[envVar.Name] = envVar.Value
+	}
+	for _, envVar := range b {
+		if value, ok := values[envVar.Name]; !ok || value != envVar.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// immutableFieldsChanged reports whether req differs from the existing LRP in any
+// field ltc sets that the receptor cannot update in place. Only the instances, routes
+// and annotation of an LRP can be updated; any other change needs a recreate.
+func immutableFieldsChanged(req, existing receptor.DesiredLRPCreateRequest) bool {
+	return !sameEnvironmentVariables(req.EnvironmentVariables, existing.EnvironmentVariables) ||
+		!reflect.DeepEqual(immutableSettings(req), immutableSettings(existing))
+}
+
+// lrpSettings holds the fields ltc sets on an LRP that cannot be updated in place,
+// normalized so that an LRP read back from the receptor matches the request that
+// desired it. The setup action is left out: it only downloads the healthcheck, and
+// its URL follows the file server of the target rather than the app.
+type lrpSettings struct {
+	Domain      string
+	RootFSPath  string
+	Stack       string
+	MemoryMB    int
+	DiskMB      int
+	CPUWeight   uint
+	Privileged  bool
+	Ports       []uint16
+	LogGuid     string
+	LogSource   string
+	Action      models.Action
+	HealthCheck HealthCheck
+}
+
+func immutableSettings(req receptor.DesiredLRPCreateRequest) lrpSettings {
+	settings := lrpSettings{
+		Domain:      req.Domain,
+		RootFSPath:  req.RootFSPath,
+		Stack:       req.Stack,
+		MemoryMB:    req.MemoryMB,
+		DiskMB:      req.DiskMB,
+		CPUWeight:   req.CPUWeight,
+		Privileged:  req.Privileged,
+		LogGuid:     req.LogGuid,
+		LogSource:   req.LogSource,
+		Action:      req.Action,
+		HealthCheck: healthCheckFromMonitor(req.Monitor, req.StartTimeout),
+	}
+
+	if len(req.Ports) > 0 {
+		settings.Ports = append([]uint16{}, req.Ports...)
+		sort.Sort(portsByNumber(settings.Ports))
+	}
+
+	if runAction, ok := req.Action.(*models.RunAction); ok {
+		settings.Action = &models.RunAction{
+			Path:       runAction.Path,
+			Args:       nilIfEmpty(runAction.Args),
+			Dir:        runAction.Dir,
+			Privileged: runAction.Privileged,
+		}
+	}
+	if settings.HealthCheck.Type == NoHealthCheck {
+		settings.HealthCheck.StartTimeout = 0
+	}
+
+	return settings
+}
+
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+type portsByNumber []uint16
+
+func (p portsByNumber) Len() int           { return len(p) }
+func (p portsByNumber) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p portsByNumber) Less(i, j int) bool { return p[i] < p[j] }
+
+// formatKeepingCredentials formats the image for the receptor with the registry
+// credentials of the existing rootfs, so that the cells can still pull a private image
+// after it changes. The credentials are only kept for images on the same registry.
+func formatKeepingCredentials(dockerImagePath, existingRootFSPath string) (string, error) {
+	existing, err := url.Parse(existingRootFSPath)
+	if err != nil || existing.User == nil {
+		return docker_repository_name_formatter.FormatForReceptor(dockerImagePath)
+	}
+
+	password, _ := existing.User.Password()
+	dockerImageUrl, err := docker_repository_name_formatter.FormatForReceptorWithCredentials(dockerImagePath, existing.User.Username(), password)
+	if err != nil {
+		return "", err
+	}
+
+	if rootFS, err := url.Parse(dockerImageUrl); err != nil || rootFS.Host != existing.Host {
+		return docker_repository_name_formatter.FormatForReceptor(dockerImagePath)
+	}
+	return dockerImageUrl, nil
+}
+
+func stringOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// monitoredPort recovers the monitored port of an existing app from the PORT
+// environment variable every app is started with.
+func monitoredPort(environmentVariables []receptor.EnvironmentVariable) uint16 {
+	for _, envVar := range environmentVariables {
+		if envVar.Name == "PORT" {
+			port, _ := strconv.Atoi(envVar.Value)
+			return uint16(port)
+		}
+	}
+	return 0
+}
+
+func createRequestFromDesiredLRP(desiredLRP receptor.DesiredLRPResponse) receptor.DesiredLRPCreateRequest {
+	return receptor.DesiredLRPCreateRequest{
+		ProcessGuid:          desiredLRP.ProcessGuid,
+		Domain:               desiredLRP.Domain,
+		RootFSPath:           desiredLRP.RootFSPath,
+		Instances:            desiredLRP.Instances,
+		Stack:                desiredLRP.Stack,
+		EnvironmentVariables: desiredLRP.EnvironmentVariables,
+		Setup:                desiredLRP.Setup,
+		Action:               desiredLRP.Action,
+		StartTimeout:         desiredLRP.StartTimeout,
+		Monitor:              desiredLRP.Monitor,
+		DiskMB:               desiredLRP.DiskMB,
+		MemoryMB:             desiredLRP.MemoryMB,
+		CPUWeight:            desiredLRP.CPUWeight,
+		Privileged:           desiredLRP.Privileged,
+		Ports:                desiredLRP.Ports,
+		Routes:               desiredLRP.Routes,
+		LogGuid:              desiredLRP.LogGuid,
+		LogSource:            desiredLRP.LogSource,
+		Annotation:           desiredLRP.Annotation,
+		EgressRules:          desiredLRP.EgressRules,
+	}
+}
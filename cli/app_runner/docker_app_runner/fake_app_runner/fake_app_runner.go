@@ -16,6 +16,57 @@ type FakeAppRunner struct {
 	startDockerAppReturns struct {
 		result1 error
 	}
+	UpsertDockerAppStub        func(params docker_app_runner.StartDockerAppParams) error
+	upsertDockerAppMutex       sync.RWMutex
+	upsertDockerAppArgsForCall []struct {
+		params docker_app_runner.StartDockerAppParams
+	}
+	upsertDockerAppReturns struct {
+		result1 error
+	}
+	UpdateAppStub        func(params docker_app_runner.UpdateAppParams) error
+	updateAppMutex       sync.RWMutex
+	updateAppArgsForCall []struct {
+		params docker_app_runner.UpdateAppParams
+	}
+	updateAppReturns struct {
+		result1 error
+	}
+	StartVersionedDockerAppStub        func(params docker_app_runner.VersionedAppParams) error
+	startVersionedDockerAppMutex       sync.RWMutex
+	startVersionedDockerAppArgsForCall []struct {
+		params docker_app_runner.VersionedAppParams
+	}
+	startVersionedDockerAppReturns struct {
+		result1 error
+	}
+	MoveRoutesStub        func(fromName, toName string) error
+	moveRoutesMutex       sync.RWMutex
+	moveRoutesArgsForCall []struct {
+		fromName string
+		toName   string
+	}
+	moveRoutesReturns struct {
+		result1 error
+	}
+	DesiredInstancesStub        func(name string) (int, error)
+	desiredInstancesMutex       sync.RWMutex
+	desiredInstancesArgsForCall []struct {
+		name string
+	}
+	desiredInstancesReturns struct {
+		result1 int
+		result2 error
+	}
+	DesiredAppStub        func(name string) (docker_app_runner.StartDockerAppParams, error)
+	desiredAppMutex       sync.RWMutex
+	desiredAppArgsForCall []struct {
+		name string
+	}
+	desiredAppReturns struct {
+		result1 docker_app_runner.StartDockerAppParams
+		result2 error
+	}
 	ScaleAppStub        func(name string, instances int) error
 	scaleAppMutex       sync.RWMutex
 	scaleAppArgsForCall []struct {
@@ -25,6 +76,15 @@ type FakeAppRunner struct {
 	scaleAppReturns struct {
 		result1 error
 	}
+	RestartInstanceStub        func(name string, index int) error
+	restartInstanceMutex       sync.RWMutex
+	restartInstanceArgsForCall []struct {
+		name  string
+		index int
+	}
+	restartInstanceReturns struct {
+		result1 error
+	}
 	RemoveAppStub        func(name string) error
 	removeAppMutex       sync.RWMutex
 	removeAppArgsForCall []struct {
@@ -85,6 +145,201 @@ func (fake *FakeAppRunner) StartDockerAppReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) UpsertDockerApp(params docker_app_runner.StartDockerAppParams) error {
+	fake.upsertDockerAppMutex.Lock()
+	fake.upsertDockerAppArgsForCall = append(fake.upsertDockerAppArgsForCall, struct {
+		params docker_app_runner.StartDockerAppParams
+	}{params})
+	fake.upsertDockerAppMutex.Unlock()
+	if fake.UpsertDockerAppStub != nil {
+		return fake.UpsertDockerAppStub(params)
+	} else {
+		return fake.upsertDockerAppReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) UpsertDockerAppCallCount() int {
+	fake.upsertDockerAppMutex.RLock()
+	defer fake.upsertDockerAppMutex.RUnlock()
+	return len(fake.upsertDockerAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) UpsertDockerAppArgsForCall(i int) docker_app_runner.StartDockerAppParams {
+	fake.upsertDockerAppMutex.RLock()
+	defer fake.upsertDockerAppMutex.RUnlock()
+	return fake.upsertDockerAppArgsForCall[i].params
+}
+
+func (fake *FakeAppRunner) UpsertDockerAppReturns(result1 error) {
+	fake.UpsertDockerAppStub = nil
+	fake.upsertDockerAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) UpdateApp(params docker_app_runner.UpdateAppParams) error {
+	fake.updateAppMutex.Lock()
+	fake.updateAppArgsForCall = append(fake.updateAppArgsForCall, struct {
+		params docker_app_runner.UpdateAppParams
+	}{params})
+	fake.updateAppMutex.Unlock()
+	if fake.UpdateAppStub != nil {
+		return fake.UpdateAppStub(params)
+	} else {
+		return fake.updateAppReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) UpdateAppCallCount() int {
+	fake.updateAppMutex.RLock()
+	defer fake.updateAppMutex.RUnlock()
+	return len(fake.updateAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) UpdateAppArgsForCall(i int) docker_app_runner.UpdateAppParams {
+	fake.updateAppMutex.RLock()
+	defer fake.updateAppMutex.RUnlock()
+	return fake.updateAppArgsForCall[i].params
+}
+
+func (fake *FakeAppRunner) UpdateAppReturns(result1 error) {
+	fake.UpdateAppStub = nil
+	fake.updateAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) StartVersionedDockerApp(params docker_app_runner.VersionedAppParams) error {
+	fake.startVersionedDockerAppMutex.Lock()
+	fake.startVersionedDockerAppArgsForCall = append(fake.startVersionedDockerAppArgsForCall, struct {
+		params docker_app_runner.VersionedAppParams
+	}{params})
+	fake.startVersionedDockerAppMutex.Unlock()
+	if fake.StartVersionedDockerAppStub != nil {
+		return fake.StartVersionedDockerAppStub(params)
+	} else {
+		return fake.startVersionedDockerAppReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) StartVersionedDockerAppCallCount() int {
+	fake.startVersionedDockerAppMutex.RLock()
+	defer fake.startVersionedDockerAppMutex.RUnlock()
+	return len(fake.startVersionedDockerAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) StartVersionedDockerAppArgsForCall(i int) docker_app_runner.VersionedAppParams {
+	fake.startVersionedDockerAppMutex.RLock()
+	defer fake.startVersionedDockerAppMutex.RUnlock()
+	return fake.startVersionedDockerAppArgsForCall[i].params
+}
+
+func (fake *FakeAppRunner) StartVersionedDockerAppReturns(result1 error) {
+	fake.StartVersionedDockerAppStub = nil
+	fake.startVersionedDockerAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) MoveRoutes(fromName string, toName string) error {
+	fake.moveRoutesMutex.Lock()
+	fake.moveRoutesArgsForCall = append(fake.moveRoutesArgsForCall, struct {
+		fromName string
+		toName   string
+	}{fromName, toName})
+	fake.moveRoutesMutex.Unlock()
+	if fake.MoveRoutesStub != nil {
+		return fake.MoveRoutesStub(fromName, toName)
+	} else {
+		return fake.moveRoutesReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) MoveRoutesCallCount() int {
+	fake.moveRoutesMutex.RLock()
+	defer fake.moveRoutesMutex.RUnlock()
+	return len(fake.moveRoutesArgsForCall)
+}
+
+func (fake *FakeAppRunner) MoveRoutesArgsForCall(i int) (string, string) {
+	fake.moveRoutesMutex.RLock()
+	defer fake.moveRoutesMutex.RUnlock()
+	return fake.moveRoutesArgsForCall[i].fromName, fake.moveRoutesArgsForCall[i].toName
+}
+
+func (fake *FakeAppRunner) MoveRoutesReturns(result1 error) {
+	fake.MoveRoutesStub = nil
+	fake.moveRoutesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) DesiredInstances(name string) (int, error) {
+	fake.desiredInstancesMutex.Lock()
+	fake.desiredInstancesArgsForCall = append(fake.desiredInstancesArgsForCall, struct {
+		name string
+	}{name})
+	fake.desiredInstancesMutex.Unlock()
+	if fake.DesiredInstancesStub != nil {
+		return fake.DesiredInstancesStub(name)
+	} else {
+		return fake.desiredInstancesReturns.result1, fake.desiredInstancesReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) DesiredInstancesCallCount() int {
+	fake.desiredInstancesMutex.RLock()
+	defer fake.desiredInstancesMutex.RUnlock()
+	return len(fake.desiredInstancesArgsForCall)
+}
+
+func (fake *FakeAppRunner) DesiredInstancesArgsForCall(i int) string {
+	fake.desiredInstancesMutex.RLock()
+	defer fake.desiredInstancesMutex.RUnlock()
+	return fake.desiredInstancesArgsForCall[i].name
+}
+
+func (fake *FakeAppRunner) DesiredInstancesReturns(result1 int, result2 error) {
+	fake.DesiredInstancesStub = nil
+	fake.desiredInstancesReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAppRunner) DesiredApp(name string) (docker_app_runner.StartDockerAppParams, error) {
+	fake.desiredAppMutex.Lock()
+	fake.desiredAppArgsForCall = append(fake.desiredAppArgsForCall, struct {
+		name string
+	}{name})
+	fake.desiredAppMutex.Unlock()
+	if fake.DesiredAppStub != nil {
+		return fake.DesiredAppStub(name)
+	} else {
+		return fake.desiredAppReturns.result1, fake.desiredAppReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) DesiredAppCallCount() int {
+	fake.desiredAppMutex.RLock()
+	defer fake.desiredAppMutex.RUnlock()
+	return len(fake.desiredAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) DesiredAppArgsForCall(i int) string {
+	fake.desiredAppMutex.RLock()
+	defer fake.desiredAppMutex.RUnlock()
+	return fake.desiredAppArgsForCall[i].name
+}
+
+func (fake *FakeAppRunner) DesiredAppReturns(result1 docker_app_runner.StartDockerAppParams, result2 error) {
+	fake.DesiredAppStub = nil
+	fake.desiredAppReturns = struct {
+		result1 docker_app_runner.StartDockerAppParams
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAppRunner) ScaleApp(name string, instances int) error {
 	fake.scaleAppMutex.Lock()
 	fake.scaleAppArgsForCall = append(fake.scaleAppArgsForCall, struct {
@@ -118,6 +373,39 @@ func (fake *FakeAppRunner) ScaleAppReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) RestartInstance(name string, index int) error {
+	fake.restartInstanceMutex.Lock()
+	fake.restartInstanceArgsForCall = append(fake.restartInstanceArgsForCall, struct {
+		name  string
+		index int
+	}{name, index})
+	fake.restartInstanceMutex.Unlock()
+	if fake.RestartInstanceStub != nil {
+		return fake.RestartInstanceStub(name, index)
+	} else {
+		return fake.restartInstanceReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) RestartInstanceCallCount() int {
+	fake.restartInstanceMutex.RLock()
+	defer fake.restartInstanceMutex.RUnlock()
+	return len(fake.restartInstanceArgsForCall)
+}
+
+func (fake *FakeAppRunner) RestartInstanceArgsForCall(i int) (string, int) {
+	fake.restartInstanceMutex.RLock()
+	defer fake.restartInstanceMutex.RUnlock()
+	return fake.restartInstanceArgsForCall[i].name, fake.restartInstanceArgsForCall[i].index
+}
+
+func (fake *FakeAppRunner) RestartInstanceReturns(result1 error) {
+	fake.RestartInstanceStub = nil
+	fake.restartInstanceReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAppRunner) RemoveApp(name string) error {
 	fake.removeAppMutex.Lock()
 	fake.removeAppArgsForCall = append(fake.removeAppArgsForCall, struct {
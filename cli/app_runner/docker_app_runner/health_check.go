@@ -0,0 +1,113 @@
+package docker_app_runner
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+type HealthCheckType string
+
+const (
+	PortHealthCheck    HealthCheckType = "port"
+	HTTPHealthCheck    HealthCheckType = "http"
+	CommandHealthCheck HealthCheckType = "command"
+	NoHealthCheck      HealthCheckType = "none"
+
+	healthcheckPath = "/tmp/healthcheck"
+)
+
+var HealthCheckTypes = []HealthCheckType{PortHealthCheck, HTTPHealthCheck, CommandHealthCheck, NoHealthCheck}
+
+// HealthCheck describes how lattice monitors an app. The zero value checks
+// that the app is listening on its monitored port. HTTP checks pass on a 200,
+// the only status the healthcheck binary accepts. Diego runs the checks on its
+// own fixed interval, which cannot be set per LRP.
+type HealthCheck struct {
+	Type         HealthCheckType
+	Path         string
+	Command      string
+	Timeout      time.Duration
+	StartTimeout time.Duration
+}
+
+// monitorAction builds the action diego runs to check the health of each instance.
+// Port and HTTP checks use the healthcheck binary downloaded into the container.
+func monitorAction(port uint16, healthCheck HealthCheck) models.Action {
+	var action models.Action
+
+	switch healthCheck.Type {
+	case NoHealthCheck:
+		return nil
+	case HTTPHealthCheck:
+		path := healthCheck.Path
+		if path == "" {
+			path = "/"
+		}
+		action = healthcheckAction(port, healthCheck.Timeout, "-uri", path)
+	case CommandHealthCheck:
+		action = shellAction(healthCheck.Command)
+		if healthCheck.Timeout != 0 {
+			action = &models.TimeoutAction{Action: action, Timeout: healthCheck.Timeout}
+		}
+	default:
+		action = healthcheckAction(port, healthCheck.Timeout)
+	}
+
+	return action
+}
+
+func healthcheckAction(port uint16, timeout time.Duration, extraArgs ...string) *models.RunAction {
+	args := append([]string{"-port", strconv.Itoa(int(port))}, extraArgs...)
+	if timeout != 0 {
+		args = append(args, "-timeout", timeout.String())
+	}
+
+	return &models.RunAction{
+		Path:      healthcheckPath,
+		Args:      args,
+		LogSource: "HEALTH",
+	}
+}
+
+func shellAction(command string) *models.RunAction {
+	return &models.RunAction{
+		Path:      "/bin/sh",
+		Args:      []string{"-c", command},
+		LogSource: "HEALTH",
+	}
+}
+
+// healthCheckFromMonitor reads back the health check that monitorAction turned into
+// the monitor action of an LRP.
+func healthCheckFromMonitor(monitor models.Action, startTimeout uint) HealthCheck {
+	healthCheck := HealthCheck{Type: PortHealthCheck, StartTimeout: time.Duration(startTimeout) * time.Second}
+
+	if timeoutAction, ok := monitor.(*models.TimeoutAction); ok {
+		healthCheck.Timeout = timeoutAction.Timeout
+		monitor = timeoutAction.Action
+	}
+
+	runAction, ok := monitor.(*models.RunAction)
+	switch {
+	case monitor == nil:
+		healthCheck.Type = NoHealthCheck
+	case !ok:
+	case runAction.Path == healthcheckPath:
+		for i := 0; i+1 < len(runAction.Args); i += 2 {
+			switch runAction.Args[i] {
+			case "-uri":
+				healthCheck.Type = HTTPHealthCheck
+				healthCheck.Path = runAction.Args[i+1]
+			case "-timeout":
+				healthCheck.Timeout, _ = time.ParseDuration(runAction.Args[i+1])
+			}
+		}
+	case runAction.Path == "/bin/sh" && len(runAction.Args) == 2:
+		healthCheck.Type = CommandHealthCheck
+		healthCheck.Command = runAction.Args[1]
+	}
+
+	return healthCheck
+}
@@ -3,18 +3,32 @@ package docker_metadata_fetcher
 //go:generate counterfeiter -o fake_docker_metadata_fetcher/fake_docker_metadata_fetcher.go . DockerMetadataFetcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/nat"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
 )
 
+// Labels an image can set to supply lattice defaults for ltc start and ltc deploy.
+const (
+	MemoryMBLabel      = "io.lattice.memory-mb"
+	MonitoredPortLabel = "io.lattice.monitored-port"
+	RoutesLabel        = "io.lattice.routes"
+)
+
 type ImageMetadata struct {
 	WorkingDir   string
 	Ports        docker_app_runner.PortConfig
 	StartCommand []string
+	Env          map[string]string
+	User         string
+	Volumes      []string
+	Labels       map[string]string
+	Digest       string
 }
 
 type DockerMetadataFetcher interface {
@@ -22,17 +36,63 @@ type DockerMetadataFetcher interface {
 }
 
 type dockerMetadataFetcher struct {
-	dockerSessionFactory DockerSessionFactory
+	dockerSessionFactory   DockerSessionFactory
+	dockerV2SessionFactory DockerV2SessionFactory
 }
 
-func New(sessionFactory DockerSessionFactory) DockerMetadataFetcher {
+func New(sessionFactory DockerSessionFactory, v2SessionFactory DockerV2SessionFactory) DockerMetadataFetcher {
 	return &dockerMetadataFetcher{
-		dockerSessionFactory: sessionFactory,
+		dockerSessionFactory:   sessionFactory,
+		dockerV2SessionFactory: v2SessionFactory,
 	}
 }
 
+// FetchMetadata uses the v2 registry API when the registry supports it and falls back to v1.
 func (fetcher *dockerMetadataFetcher) FetchMetadata(repoName string, tag string) (*ImageMetadata, error) {
+	v2Session, err := fetcher.dockerV2SessionFactory.MakeV2Session(repoName)
+	if err == ErrV2Unsupported {
+		return fetcher.fetchV1Metadata(repoName, tag)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return fetcher.fetchV2Metadata(v2Session, repoName, tag)
+}
+
+func (fetcher *dockerMetadataFetcher) fetchV2Metadata(session DockerV2Session, repoName string, tag string) (*ImageMetadata, error) {
+	manifest, err := session.GetManifest(tag)
+	if err == ErrManifestUnknown {
+		return nil, fmt.Errorf("Unknown tag: %s:%s", repoName, tag)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var imgJSON []byte
+	switch {
+	case manifest.SchemaVersion == 1:
+		if len(manifest.History) == 0 {
+			return nil, fmt.Errorf("Docker image manifest for %s:%s has no history", repoName, tag)
+		}
+		imgJSON = []byte(manifest.History[0].V1Compatibility)
+	case manifest.SchemaVersion == 2 && manifest.Config.Digest != "":
+		imgJSON, err = session.GetBlob(manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported Docker image manifest: schema version %d, media type %s", manifest.SchemaVersion, manifest.MediaType)
+	}
+
+	imageMetadata, err := imageMetadataFromJSON(imgJSON)
+	if err != nil {
+		return nil, err
+	}
+	imageMetadata.Digest = manifest.Digest
 
+	return imageMetadata, nil
+}
+
+func (fetcher *dockerMetadataFetcher) fetchV1Metadata(repoName string, tag string) (*ImageMetadata, error) {
 	session, err := fetcher.dockerSessionFactory.MakeSession(repoName)
 	if err != nil {
 		return nil, err
@@ -53,7 +113,6 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(repoName string, tag string)
 		return nil, fmt.Errorf("Unknown tag: %s:%s", repoName, tag)
 	}
 
-	var img *image.Image
 	endpoint := repoData.Endpoints[0]
 	imgJSON, _, err := session.GetRemoteImageJSON(imgID, endpoint, repoData.Tokens)
 
@@ -61,7 +120,11 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(repoName string, tag string)
 		return nil, err
 	}
 
-	img, err = image.NewImgJSON(imgJSON)
+	return imageMetadataFromJSON(imgJSON)
+}
+
+func imageMetadataFromJSON(imgJSON []byte) (*ImageMetadata, error) {
+	img, err := image.NewImgJSON(imgJSON)
 	if err != nil {
 		return nil, fmt.Errorf("Error parsing remote image json for specified docker image:\n%s", err.Error())
 	}
@@ -72,13 +135,24 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(repoName string, tag string)
 
 	startCommand := append(img.Config.Entrypoint, img.Config.Cmd...)
 
-	uintExposedPorts := sortPorts(img.ContainerConfig.ExposedPorts)
+	exposedPorts := img.Config.ExposedPorts
+	if img.ContainerConfig.ExposedPorts != nil {
+		exposedPorts = img.ContainerConfig.ExposedPorts
+	}
+
+	uintExposedPorts := sortPorts(exposedPorts)
 	var monitoredPort uint16
 
 	if len(uintExposedPorts) > 0 {
 		monitoredPort = uintExposedPorts[0]
 	}
 
+	volumes := make([]string, 0, len(img.Config.Volumes))
+	for volume := range img.Config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+
 	return &ImageMetadata{
 		WorkingDir:   img.Config.WorkingDir,
 		StartCommand: startCommand,
@@ -86,9 +160,42 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(repoName string, tag string)
 			Monitored: monitoredPort,
 			Exposed:   uintExposedPorts,
 		},
+		Env:     parseImageEnv(img.Config.Env),
+		User:    img.Config.User,
+		Volumes: volumes,
+		Labels:  parseImageLabels(imgJSON),
 	}, nil
 }
 
+func parseImageEnv(env []string) map[string]string {
+	environment := make(map[string]string)
+	for _, envVarPair := range env {
+		nameAndValue := strings.SplitN(envVarPair, "=", 2)
+		if len(nameAndValue) == 2 {
+			environment[nameAndValue[0]] = nameAndValue[1]
+		} else {
+			environment[nameAndValue[0]] = ""
+		}
+	}
+	return environment
+}
+
+// parseImageLabels reads the labels straight from the image json, since they are
+// not part of every docker image config version.
+func parseImageLabels(imgJSON []byte) map[string]string {
+	var labeledImage struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}
+	json.Unmarshal(imgJSON, &labeledImage)
+
+	if labeledImage.Config.Labels == nil {
+		return map[string]string{}
+	}
+	return labeledImage.Config.Labels
+}
+
 func sortPorts(dockerExposedPorts map[nat.Port]struct{}) []uint16 {
 	intPorts := make([]int, 0)
 	for natPort, _ := range dockerExposedPorts {
@@ -13,15 +13,20 @@ import (
 
 var _ = Describe("DockerMetaDataFetcher", func() {
 	var (
-		dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
-		dockerSessionFactory  *fake_docker_session.FakeDockerSessionFactory
-		fakeDockerSession     *fake_docker_session.FakeDockerSession
+		dockerMetadataFetcher  docker_metadata_fetcher.DockerMetadataFetcher
+		dockerSessionFactory   *fake_docker_session.FakeDockerSessionFactory
+		fakeDockerSession      *fake_docker_session.FakeDockerSession
+		dockerV2SessionFactory *fake_docker_session.FakeDockerV2SessionFactory
+		fakeDockerV2Session    *fake_docker_session.FakeDockerV2Session
 	)
 
 	BeforeEach(func() {
 		fakeDockerSession = &fake_docker_session.FakeDockerSession{}
 		dockerSessionFactory = &fake_docker_session.FakeDockerSessionFactory{}
-		dockerMetadataFetcher = docker_metadata_fetcher.New(dockerSessionFactory)
+		fakeDockerV2Session = &fake_docker_session.FakeDockerV2Session{}
+		dockerV2SessionFactory = &fake_docker_session.FakeDockerV2SessionFactory{}
+		dockerV2SessionFactory.MakeV2SessionReturns(nil, docker_metadata_fetcher.ErrV2Unsupported)
+		dockerMetadataFetcher = docker_metadata_fetcher.New(dockerSessionFactory, dockerV2SessionFactory)
 	})
 
 	Describe("FetchMetadata", func() {
@@ -85,6 +90,33 @@ var _ = Describe("DockerMetaDataFetcher", func() {
 			Expect(imageMetadata.Ports.Exposed).To(Equal([]uint16{uint16(27017), uint16(28321)}))
 		})
 
+		It("returns the image's Env, User, Volumes and Labels", func() {
+			dockerSessionFactory.MakeSessionReturns(fakeDockerSession, nil)
+			fakeDockerSession.GetRepositoryDataReturns(&registry.RepositoryData{Endpoints: []string{"https://registry-1.docker.io/v1/"}}, nil)
+			fakeDockerSession.GetRemoteTagsReturns(map[string]string{"latest": "29d531509fb"}, nil)
+			fakeDockerSession.GetRemoteImageJSONReturns(
+				[]byte(`{
+					"container_config":{},
+					"config":{
+						"Cmd":["/lattice-app"],
+						"Env":["PATH=/usr/bin:/bin","GREETING=hello=world","EMPTY"],
+						"User":"app",
+						"Volumes":{"/var/data":{},"/etc/app":{}},
+						"Labels":{"io.lattice.memory-mb":"256"}
+					}
+				}`),
+				0,
+				nil)
+
+			imageMetadata, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetApp", "latest")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageMetadata.Env).To(Equal(map[string]string{"PATH": "/usr/bin:/bin", "GREETING": "hello=world", "EMPTY": ""}))
+			Expect(imageMetadata.User).To(Equal("app"))
+			Expect(imageMetadata.Volumes).To(Equal([]string{"/etc/app", "/var/data"}))
+			Expect(imageMetadata.Labels).To(Equal(map[string]string{docker_metadata_fetcher.MemoryMBLabel: "256"}))
+		})
+
 		Context("when exposed ports are null in the docker metadata", func() {
 			It("doesn't blow up, and returns zero values", func() {
 
@@ -249,5 +281,94 @@ var _ = Describe("DockerMetaDataFetcher", func() {
 				})
 			})
 		})
+
+		Describe("with a v2 registry", func() {
+			imageConfig := `{
+				"container_config":{ "ExposedPorts":null },
+				"config":{
+					"WorkingDir":"/home/app",
+					"ExposedPorts":{"8080/tcp":{}, "53/udp":{}},
+					"Entrypoint":["/lattice-app"],
+					"Cmd":["--enableAwesomeMode=true"]
+				}
+			}`
+
+			BeforeEach(func() {
+				dockerV2SessionFactory.MakeV2SessionReturns(fakeDockerV2Session, nil)
+			})
+
+			It("reads the metadata from the config blob of a schema 2 manifest", func() {
+				fakeDockerV2Session.GetManifestReturns(&docker_metadata_fetcher.Manifest{
+					SchemaVersion: 2,
+					MediaType:     docker_metadata_fetcher.ManifestV2MediaType,
+					Config:        docker_metadata_fetcher.ManifestDescriptor{Digest: "sha256:config"},
+					Digest:        "sha256:manifest",
+				}, nil)
+				fakeDockerV2Session.GetBlobReturns([]byte(imageConfig), nil)
+
+				imageMetadata, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetApp", "v2")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(dockerV2SessionFactory.MakeV2SessionArgsForCall(0)).To(Equal("cool_user123/sweetApp"))
+				Expect(fakeDockerV2Session.GetManifestArgsForCall(0)).To(Equal("v2"))
+				Expect(fakeDockerV2Session.GetBlobArgsForCall(0)).To(Equal("sha256:config"))
+				Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+
+				Expect(imageMetadata.WorkingDir).To(Equal("/home/app"))
+				Expect(imageMetadata.StartCommand).To(Equal([]string{"/lattice-app", "--enableAwesomeMode=true"}))
+				Expect(imageMetadata.Ports.Monitored).To(Equal(uint16(8080)))
+				Expect(imageMetadata.Ports.Exposed).To(Equal([]uint16{8080}))
+				Expect(imageMetadata.Digest).To(Equal("sha256:manifest"))
+			})
+
+			It("reads the metadata from the history of a schema 1 manifest", func() {
+				fakeDockerV2Session.GetManifestReturns(&docker_metadata_fetcher.Manifest{
+					SchemaVersion: 1,
+					History:       []docker_metadata_fetcher.ManifestV1History{{V1Compatibility: imageConfig}, {V1Compatibility: "{}"}},
+					Digest:        "sha256:manifest",
+				}, nil)
+
+				imageMetadata, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetApp", "latest")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDockerV2Session.GetBlobCallCount()).To(Equal(0))
+				Expect(imageMetadata.WorkingDir).To(Equal("/home/app"))
+				Expect(imageMetadata.Digest).To(Equal("sha256:manifest"))
+			})
+
+			It("returns an error when the tag does not exist", func() {
+				fakeDockerV2Session.GetManifestReturns(nil, docker_metadata_fetcher.ErrManifestUnknown)
+
+				_, err := dockerMetadataFetcher.FetchMetadata("wiggle/app", "some-unknown-tag-v3245")
+
+				Expect(err).To(MatchError("Unknown tag: wiggle/app:some-unknown-tag-v3245"))
+			})
+
+			It("returns an error for manifests it cannot read", func() {
+				fakeDockerV2Session.GetManifestReturns(&docker_metadata_fetcher.Manifest{SchemaVersion: 2, MediaType: "application/vnd.docker.distribution.manifest.list.v2+json"}, nil)
+
+				_, err := dockerMetadataFetcher.FetchMetadata("wiggle/app", "latest")
+
+				Expect(err).To(MatchError("Unsupported Docker image manifest: schema version 2, media type application/vnd.docker.distribution.manifest.list.v2+json"))
+			})
+
+			It("returns errors fetching the config blob", func() {
+				fakeDockerV2Session.GetManifestReturns(&docker_metadata_fetcher.Manifest{SchemaVersion: 2, Config: docker_metadata_fetcher.ManifestDescriptor{Digest: "sha256:config"}}, nil)
+				fakeDockerV2Session.GetBlobReturns(nil, errors.New("blob went missing"))
+
+				_, err := dockerMetadataFetcher.FetchMetadata("wiggle/app", "latest")
+
+				Expect(err).To(MatchError("blob went missing"))
+			})
+
+			It("returns errors making the v2 session without falling back to v1", func() {
+				dockerV2SessionFactory.MakeV2SessionReturns(nil, errors.New("token denied"))
+
+				_, err := dockerMetadataFetcher.FetchMetadata("wiggle/app", "latest")
+
+				Expect(err).To(MatchError("token denied"))
+				Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+			})
+		})
 	})
 })
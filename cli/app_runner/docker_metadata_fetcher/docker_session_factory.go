@@ -5,8 +5,10 @@ package docker_metadata_fetcher
 
 import (
 	"fmt"
+
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
 )
 
 type DockerSession interface {
@@ -19,10 +21,12 @@ type DockerSessionFactory interface {
 	MakeSession(repoName string) (DockerSession, error)
 }
 
-type dockerSessionFactory struct{}
+type dockerSessionFactory struct {
+	registryCredentials registry_credentials.RegistryCredentials
+}
 
-func NewDockerSessionFactory() *dockerSessionFactory {
-	return &dockerSessionFactory{}
+func NewDockerSessionFactory(registryCredentials registry_credentials.RegistryCredentials) *dockerSessionFactory {
+	return &dockerSessionFactory{registryCredentials}
 }
 
 func (factory *dockerSessionFactory) MakeSession(repoName string) (DockerSession, error) {
@@ -36,7 +40,17 @@ func (factory *dockerSessionFactory) MakeSession(repoName string) (DockerSession
 	if err != nil {
 		return nil, fmt.Errorf("Error Connecting to Docker registry:\n" + err.Error())
 	}
+
 	authConfig := &registry.AuthConfig{}
+	credentials, found, err := factory.registryCredentials.Lookup(repositoryInfo.Index.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading Docker registry credentials:\n" + err.Error())
+	} else if found {
+		authConfig.Username = credentials.Username
+		authConfig.Password = credentials.Password
+		authConfig.ServerAddress = registry_credentials.ServerFor(repositoryInfo.Index.Name)
+	}
+
 	session, error := registry.NewSession(authConfig, utils.NewHTTPRequestFactory(), endpoint, true)
 	return session, error
 }
@@ -1,6 +1,7 @@
 package docker_metadata_fetcher_test
 
 import (
+	"errors"
 	"net/url"
 
 	. "github.com/onsi/ginkgo"
@@ -9,15 +10,23 @@ import (
 
 	"github.com/docker/docker/registry"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials/fake_registry_credentials"
 )
 
 var _ = Describe("DockerSessionFactory", func() {
 	Describe("MakeSession", func() {
-		var registryHost string
-		var dockerRegistryServer *ghttp.Server
+		var (
+			registryHost            string
+			dockerRegistryServer    *ghttp.Server
+			fakeRegistryCredentials *fake_registry_credentials.FakeRegistryCredentials
+			sessionFactory          docker_metadata_fetcher.DockerSessionFactory
+		)
 
 		BeforeEach(func() {
 			dockerRegistryServer = ghttp.NewServer()
+			fakeRegistryCredentials = &fake_registry_credentials.FakeRegistryCredentials{}
+			sessionFactory = docker_metadata_fetcher.NewDockerSessionFactory(fakeRegistryCredentials)
 		})
 
 		AfterEach(func() {
@@ -34,7 +43,6 @@ var _ = Describe("DockerSessionFactory", func() {
 			})
 
 			It("creates a registry session for the given repo", func() {
-				sessionFactory := docker_metadata_fetcher.NewDockerSessionFactory()
 				session, err := sessionFactory.MakeSession(registryHost + "/lattice-mappppppppppppappapapa")
 
 				Expect(err).ToNot(HaveOccurred())
@@ -42,13 +50,36 @@ var _ = Describe("DockerSessionFactory", func() {
 				Expect(ok).To(Equal(true))
 
 				Expect(*registrySession.GetAuthConfig(true)).To(Equal(registry.AuthConfig{}))
+				Expect(fakeRegistryCredentials.LookupArgsForCall(0)).To(Equal(registryHost))
+			})
+
+			It("authenticates with the credentials for the registry", func() {
+				fakeRegistryCredentials.LookupReturns(registry_credentials.Credentials{Username: "jim", Password: "secret"}, true, nil)
+
+				session, err := sessionFactory.MakeSession(registryHost + "/lattice-mappppppppppppappapapa")
 
+				Expect(err).ToNot(HaveOccurred())
+				registrySession, ok := session.(*registry.Session)
+				Expect(ok).To(Equal(true))
+
+				authConfig := registrySession.GetAuthConfig(true)
+				Expect(authConfig.Username).To(Equal("jim"))
+				Expect(authConfig.Password).To(Equal("secret"))
+				Expect(authConfig.ServerAddress).To(Equal(registryHost))
+			})
+
+			It("returns errors from reading the registry credentials", func() {
+				fakeRegistryCredentials.LookupReturns(registry_credentials.Credentials{}, false, errors.New("bad docker config"))
+
+				_, err := sessionFactory.MakeSession(registryHost + "/lattice-mappppppppppppappapapa")
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Error reading Docker registry credentials:\nbad docker config"))
 			})
 		})
 
 		Context("When resolving the repo name fails", func() {
 			It("returns errors from resolving the repo name", func() {
-				sessionFactory := docker_metadata_fetcher.NewDockerSessionFactory()
 				_, err := sessionFactory.MakeSession("¥Not-A-Valid-Repo-Name¥" + "/lattice-mappppppppppppappapapa")
 
 				Expect(err).To(HaveOccurred())
@@ -59,7 +90,6 @@ var _ = Describe("DockerSessionFactory", func() {
 
 		Context("when creating a new endpoint fails", func() {
 			It("returns an error", func() {
-				sessionFactory := docker_metadata_fetcher.NewDockerSessionFactory()
 				_, err := sessionFactory.MakeSession("nonexistantregistry.example.com/lattice-mappppppppppppappapapa")
 
 				Expect(err).To(HaveOccurred())
@@ -0,0 +1,290 @@
+package docker_metadata_fetcher
+
+//go:generate counterfeiter -o fake_docker_session/fake_docker_v2_session.go . DockerV2Session
+//go:generate counterfeiter -o fake_docker_session/fake_docker_v2_session_factory.go . DockerV2SessionFactory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/registry"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+)
+
+const (
+	ManifestV1MediaType       = "application/vnd.docker.distribution.manifest.v1+json"
+	SignedManifestV1MediaType = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	ManifestV2MediaType       = "application/vnd.docker.distribution.manifest.v2+json"
+
+	dockerHubV2Host  = "registry-1.docker.io"
+	v2RequestTimeout = 30 * time.Second
+
+	apiVersionHeader = "Docker-Distribution-API-Version"
+	v2APIVersion     = "registry/2.0"
+)
+
+var (
+	ErrV2Unsupported   = errors.New("registry does not support the v2 API")
+	ErrManifestUnknown = errors.New("manifest unknown")
+)
+
+type Manifest struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	Config        ManifestDescriptor   `json:"config"`
+	History       []ManifestV1History  `json:"history"`
+	Layers        []ManifestDescriptor `json:"layers"`
+	Digest        string               `json:"-"`
+}
+
+type ManifestDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Digest    string `json:"digest"`
+}
+
+type ManifestV1History struct {
+	V1Compatibility string `json:"v1Compatibility"`
+}
+
+type DockerV2Session interface {
+	GetManifest(reference string) (*Manifest, error)
+	GetBlob(digest string) ([]byte, error)
+}
+
+type DockerV2SessionFactory interface {
+	MakeV2Session(repoName string) (DockerV2Session, error)
+}
+
+type dockerV2SessionFactory struct {
+	registryCredentials registry_credentials.RegistryCredentials
+	httpClient          *http.Client
+}
+
+func NewDockerV2SessionFactory(registryCredentials registry_credentials.RegistryCredentials) *dockerV2SessionFactory {
+	return &dockerV2SessionFactory{
+		registryCredentials: registryCredentials,
+		httpClient:          &http.Client{Timeout: v2RequestTimeout},
+	}
+}
+
+// MakeV2Session pings the registry's /v2/ endpoint and authenticates against it.
+// It returns ErrV2Unsupported when the registry only speaks the v1 API, which it
+// takes from a 404 or an answer without the v2 API version header. Failing to reach
+// the registry at all is an error rather than a reason to try v1.
+func (factory *dockerV2SessionFactory) MakeV2Session(repoName string) (DockerV2Session, error) {
+	repositoryInfo, err := registry.ParseRepositoryInfo(repoName)
+	if err != nil {
+		return nil, fmt.Errorf("Error resolving Docker repository name:\n" + err.Error())
+	}
+
+	session := &dockerV2Session{
+		httpClient: factory.httpClient,
+		baseURL:    registryBaseURL(repositoryInfo),
+		remoteName: repositoryInfo.RemoteName,
+	}
+
+	response, err := factory.httpClient.Get(session.baseURL + "/v2/")
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to Docker registry:\n" + err.Error())
+	}
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound || response.Header.Get(apiVersionHeader) != v2APIVersion {
+		return nil, ErrV2Unsupported
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return session, nil
+	case http.StatusUnauthorized:
+	default:
+		return nil, fmt.Errorf("Error connecting to Docker registry: %s", response.Status)
+	}
+
+	credentials, _, err := factory.registryCredentials.Lookup(repositoryInfo.Index.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading Docker registry credentials:\n" + err.Error())
+	}
+
+	scheme, params := parseChallenge(response.Header.Get("Www-Authenticate"))
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		token, err := factory.fetchToken(params, session.remoteName, credentials)
+		if err != nil {
+			return nil, err
+		}
+		session.authorization = "Bearer " + token
+	case "basic":
+		request := &http.Request{Header: http.Header{}}
+		request.SetBasicAuth(credentials.Username, credentials.Password)
+		session.authorization = request.Header.Get("Authorization")
+	default:
+		return nil, fmt.Errorf("Unsupported Docker registry authentication: %s", scheme)
+	}
+
+	return session, nil
+}
+
+func (factory *dockerV2SessionFactory) fetchToken(params map[string]string, remoteName string, credentials registry_credentials.Credentials) (string, error) {
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("Invalid Docker registry token realm: %q", params["realm"])
+	}
+
+	query := tokenURL.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+remoteName+":pull")
+	tokenURL.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("GET", tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if credentials.Username != "" {
+		request.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+
+	response, err := factory.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Error authenticating with Docker registry:\n" + err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Error authenticating with Docker registry: %s", response.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("Error authenticating with Docker registry:\n" + err.Error())
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+type dockerV2Session struct {
+	httpClient    *http.Client
+	baseURL       string
+	remoteName    string
+	authorization string
+}
+
+// GetManifest fetches the manifest for a tag or digest. Digest is taken from the
+// Docker-Content-Digest header, or computed from the body when the registry omits it.
+func (session *dockerV2Session) GetManifest(reference string) (*Manifest, error) {
+	body, header, status, err := session.get("/manifests/"+reference, ManifestV2MediaType, SignedManifestV1MediaType, ManifestV1MediaType)
+	if status == http.StatusNotFound {
+		return nil, ErrManifestUnknown
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, fmt.Errorf("Error parsing Docker image manifest:\n" + err.Error())
+	}
+
+	manifest.Digest = header.Get("Docker-Content-Digest")
+	if manifest.Digest == "" {
+		manifest.Digest = sha256Digest(body)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = header.Get("Content-Type")
+	}
+
+	return manifest, nil
+}
+
+func (session *dockerV2Session) GetBlob(digest string) ([]byte, error) {
+	body, _, _, err := session.get("/blobs/"+digest, "*/*")
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(digest, "sha256:") && sha256Digest(body) != digest {
+		return nil, fmt.Errorf("Digest mismatch for Docker image blob %s", digest)
+	}
+
+	return body, nil
+}
+
+func (session *dockerV2Session) get(path string, accept ...string) ([]byte, http.Header, int, error) {
+	request, err := http.NewRequest("GET", session.baseURL+"/v2/"+session.remoteName+path, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	request.Header.Set("Accept", strings.Join(accept, ", "))
+	if session.authorization != "" {
+		request.Header.Set("Authorization", session.authorization)
+	}
+
+	response, err := session.httpClient.Do(request)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, response.StatusCode, fmt.Errorf("Error fetching %s%s from Docker registry: %s", session.remoteName, path, response.Status)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	return body, response.Header, response.StatusCode, err
+}
+
+// registryBaseURL follows docker in talking plain http to registries on the loopback
+// interface and https to everything else.
+func registryBaseURL(repositoryInfo *registry.RepositoryInfo) string {
+	host := repositoryInfo.Index.Name
+	if repositoryInfo.Index.Official {
+		host = dockerHubV2Host
+	}
+
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if ip := net.ParseIP(hostname); hostname == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return "http://" + host
+	}
+	return "https://" + host
+}
+
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) == 2 {
+		for _, match := range challengeParamRegexp.FindAllStringSubmatch(parts[1], -1) {
+			params[strings.ToLower(match[1])] = match[2]
+		}
+	}
+
+	return parts[0], params
+}
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,221 @@
+package docker_metadata_fetcher_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher/fake_docker_session"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials/fake_registry_credentials"
+)
+
+var _ = Describe("DockerV2SessionFactory", func() {
+	const imageConfig = `{"config":{"WorkingDir":"/app","ExposedPorts":{"8080/tcp":{}},"Cmd":["/lattice-app"]},"container_config":{}}`
+
+	var (
+		fakeRegistry            *ghttp.Server
+		registryHost            string
+		fakeRegistryCredentials *fake_registry_credentials.FakeRegistryCredentials
+		sessionFactory          docker_metadata_fetcher.DockerV2SessionFactory
+		configDigest            string
+		schema2Manifest         string
+	)
+
+	v2Header := http.Header{"Docker-Distribution-Api-Version": []string{"registry/2.0"}}
+
+	digestOf := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	BeforeEach(func() {
+		fakeRegistry = ghttp.NewServer()
+		registryURL, _ := url.Parse(fakeRegistry.URL())
+		registryHost = registryURL.Host
+
+		fakeRegistryCredentials = &fake_registry_credentials.FakeRegistryCredentials{}
+		sessionFactory = docker_metadata_fetcher.NewDockerV2SessionFactory(fakeRegistryCredentials)
+
+		configDigest = digestOf(imageConfig)
+		schema2Manifest = `{"schemaVersion":2,"mediaType":"` + docker_metadata_fetcher.ManifestV2MediaType + `","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":10,"digest":"` + configDigest + `"},"layers":[]}`
+	})
+
+	AfterEach(func() {
+		fakeRegistry.Close()
+	})
+
+	Context("when the registry does not speak v2", func() {
+		It("returns ErrV2Unsupported", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/", ghttp.RespondWith(http.StatusNotFound, ""))
+
+			_, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+
+			Expect(err).To(Equal(docker_metadata_fetcher.ErrV2Unsupported))
+		})
+
+		It("returns ErrV2Unsupported when the answer lacks the v2 API version header", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/", ghttp.RespondWith(http.StatusOK, "<html>a v1 registry</html>"))
+
+			_, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+
+			Expect(err).To(Equal(docker_metadata_fetcher.ErrV2Unsupported))
+		})
+	})
+
+	Context("when the registry cannot be reached", func() {
+		It("returns the error instead of falling back to v1", func() {
+			fakeRegistry.Close()
+
+			_, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(Equal(docker_metadata_fetcher.ErrV2Unsupported))
+			Expect(err.Error()).To(HavePrefix("Error connecting to Docker registry:\n"))
+		})
+
+		It("returns an error for a v2 registry that fails the ping", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/", ghttp.RespondWith(http.StatusServiceUnavailable, "", v2Header))
+
+			_, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+
+			Expect(err).To(MatchError("Error connecting to Docker registry: 503 Service Unavailable"))
+		})
+	})
+
+	Context("when the registry does not require authentication", func() {
+		BeforeEach(func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/", ghttp.RespondWith(http.StatusOK, "{}", v2Header))
+		})
+
+		It("fetches schema 2 manifests and resolves the tag to the registry's digest", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/latest", ghttp.CombineHandlers(
+				func(w http.ResponseWriter, req *http.Request) {
+					Expect(req.Header.Get("Accept")).To(ContainSubstring(docker_metadata_fetcher.ManifestV2MediaType))
+					Expect(req.Header.Get("Accept")).To(ContainSubstring(docker_metadata_fetcher.SignedManifestV1MediaType))
+				},
+				ghttp.RespondWith(http.StatusOK, schema2Manifest, http.Header{"Docker-Content-Digest": []string{"sha256:abc123"}}),
+			))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, err := session.GetManifest("latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.SchemaVersion).To(Equal(2))
+			Expect(manifest.Config.Digest).To(Equal(configDigest))
+			Expect(manifest.Digest).To(Equal("sha256:abc123"))
+			Expect(fakeRegistryCredentials.LookupCallCount()).To(Equal(0))
+		})
+
+		It("computes the digest when the registry does not send one", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/latest", ghttp.RespondWith(http.StatusOK, schema2Manifest))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, err := session.GetManifest("latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Digest).To(Equal(digestOf(schema2Manifest)))
+		})
+
+		It("returns ErrManifestUnknown for unknown tags", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/nope", ghttp.RespondWith(http.StatusNotFound, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = session.GetManifest("nope")
+			Expect(err).To(Equal(docker_metadata_fetcher.ErrManifestUnknown))
+		})
+
+		It("verifies blobs against their digest", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/blobs/"+configDigest, ghttp.RespondWith(http.StatusOK, imageConfig))
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/blobs/sha256:0000", ghttp.RespondWith(http.StatusOK, imageConfig))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+
+			blob, err := session.GetBlob(configDigest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(blob)).To(Equal(imageConfig))
+
+			_, err = session.GetBlob("sha256:0000")
+			Expect(err).To(MatchError("Digest mismatch for Docker image blob sha256:0000"))
+		})
+
+		It("backs the DockerMetadataFetcher without touching the v1 API", func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/latest", ghttp.RespondWith(http.StatusOK, schema2Manifest))
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/blobs/"+configDigest, ghttp.RespondWith(http.StatusOK, imageConfig))
+			v1SessionFactory := &fake_docker_session.FakeDockerSessionFactory{}
+
+			fetcher := docker_metadata_fetcher.New(v1SessionFactory, sessionFactory)
+			imageMetadata, err := fetcher.FetchMetadata(registryHost+"/lattice-app", "latest")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageMetadata.WorkingDir).To(Equal("/app"))
+			Expect(imageMetadata.StartCommand).To(Equal([]string{"/lattice-app"}))
+			Expect(imageMetadata.Ports.Exposed).To(Equal([]uint16{8080}))
+			Expect(imageMetadata.Digest).To(Equal(digestOf(schema2Manifest)))
+			Expect(v1SessionFactory.MakeSessionCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the registry requires token authentication", func() {
+		BeforeEach(func() {
+			fakeRegistry.RouteToHandler("GET", "/v2/", ghttp.RespondWith(http.StatusUnauthorized, "", http.Header{
+				"Docker-Distribution-Api-Version": []string{"registry/2.0"},
+				"Www-Authenticate":                []string{`Bearer realm="` + fakeRegistry.URL() + `/token",service="fake-registry"`},
+			}))
+			fakeRegistryCredentials.LookupReturns(registry_credentials.Credentials{Username: "jim", Password: "secret"}, true, nil)
+		})
+
+		It("fetches a pull token with the registry credentials and uses it for later requests", func() {
+			fakeRegistry.RouteToHandler("GET", "/token", ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/token", "scope=repository%3Alattice-app%3Apull&service=fake-registry"),
+				ghttp.VerifyHeader(http.Header{"Authorization": []string{"Basic " + base64.StdEncoding.EncodeToString([]byte("jim:secret"))}}),
+				ghttp.RespondWith(http.StatusOK, `{"token":"pull-token"}`),
+			))
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/latest", ghttp.CombineHandlers(
+				ghttp.VerifyHeader(http.Header{"Authorization": []string{"Bearer pull-token"}}),
+				ghttp.RespondWith(http.StatusOK, schema2Manifest),
+			))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeRegistryCredentials.LookupArgsForCall(0)).To(Equal(registryHost))
+
+			_, err = session.GetManifest("latest")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("accepts tokens returned as access_token", func() {
+			fakeRegistry.RouteToHandler("GET", "/token", ghttp.RespondWith(http.StatusOK, `{"access_token":"oauth-token"}`))
+			fakeRegistry.RouteToHandler("GET", "/v2/lattice-app/manifests/latest", ghttp.CombineHandlers(
+				ghttp.VerifyHeader(http.Header{"Authorization": []string{"Bearer oauth-token"}}),
+				ghttp.RespondWith(http.StatusOK, schema2Manifest),
+			))
+
+			session, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = session.GetManifest("latest")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error when the token is refused", func() {
+			fakeRegistry.RouteToHandler("GET", "/token", ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+			_, err := sessionFactory.MakeV2Session(registryHost + "/lattice-app")
+
+			Expect(err).To(MatchError("Error authenticating with Docker registry: 401 Unauthorized"))
+		})
+	})
+})
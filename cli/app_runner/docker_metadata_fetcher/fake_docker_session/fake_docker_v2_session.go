@@ -0,0 +1,97 @@
+// This file was generated by counterfeiter
+package fake_docker_session
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+)
+
+type FakeDockerV2Session struct {
+	GetManifestStub        func(reference string) (*docker_metadata_fetcher.Manifest, error)
+	getManifestMutex       sync.RWMutex
+	getManifestArgsForCall []struct {
+		reference string
+	}
+	getManifestReturns struct {
+		result1 *docker_metadata_fetcher.Manifest
+		result2 error
+	}
+	GetBlobStub        func(digest string) ([]byte, error)
+	getBlobMutex       sync.RWMutex
+	getBlobArgsForCall []struct {
+		digest string
+	}
+	getBlobReturns struct {
+		result1 []byte
+		result2 error
+	}
+}
+
+func (fake *FakeDockerV2Session) GetManifest(reference string) (*docker_metadata_fetcher.Manifest, error) {
+	fake.getManifestMutex.Lock()
+	fake.getManifestArgsForCall = append(fake.getManifestArgsForCall, struct {
+		reference string
+	}{reference})
+	fake.getManifestMutex.Unlock()
+	if fake.GetManifestStub != nil {
+		return fake.GetManifestStub(reference)
+	} else {
+		return fake.getManifestReturns.result1, fake.getManifestReturns.result2
+	}
+}
+
+func (fake *FakeDockerV2Session) GetManifestCallCount() int {
+	fake.getManifestMutex.RLock()
+	defer fake.getManifestMutex.RUnlock()
+	return len(fake.getManifestArgsForCall)
+}
+
+func (fake *FakeDockerV2Session) GetManifestArgsForCall(i int) string {
+	fake.getManifestMutex.RLock()
+	defer fake.getManifestMutex.RUnlock()
+	return fake.getManifestArgsForCall[i].reference
+}
+
+func (fake *FakeDockerV2Session) GetManifestReturns(result1 *docker_metadata_fetcher.Manifest, result2 error) {
+	fake.GetManifestStub = nil
+	fake.getManifestReturns = struct {
+		result1 *docker_metadata_fetcher.Manifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDockerV2Session) GetBlob(digest string) ([]byte, error) {
+	fake.getBlobMutex.Lock()
+	fake.getBlobArgsForCall = append(fake.getBlobArgsForCall, struct {
+		digest string
+	}{digest})
+	fake.getBlobMutex.Unlock()
+	if fake.GetBlobStub != nil {
+		return fake.GetBlobStub(digest)
+	} else {
+		return fake.getBlobReturns.result1, fake.getBlobReturns.result2
+	}
+}
+
+func (fake *FakeDockerV2Session) GetBlobCallCount() int {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	return len(fake.getBlobArgsForCall)
+}
+
+func (fake *FakeDockerV2Session) GetBlobArgsForCall(i int) string {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	return fake.getBlobArgsForCall[i].digest
+}
+
+func (fake *FakeDockerV2Session) GetBlobReturns(result1 []byte, result2 error) {
+	fake.GetBlobStub = nil
+	fake.getBlobReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+var _ docker_metadata_fetcher.DockerV2Session = new(FakeDockerV2Session)
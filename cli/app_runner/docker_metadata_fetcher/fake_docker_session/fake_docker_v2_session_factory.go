@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fake_docker_session
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+)
+
+type FakeDockerV2SessionFactory struct {
+	MakeV2SessionStub        func(repoName string) (docker_metadata_fetcher.DockerV2Session, error)
+	makeV2SessionMutex       sync.RWMutex
+	makeV2SessionArgsForCall []struct {
+		repoName string
+	}
+	makeV2SessionReturns struct {
+		result1 docker_metadata_fetcher.DockerV2Session
+		result2 error
+	}
+}
+
+func (fake *FakeDockerV2SessionFactory) MakeV2Session(repoName string) (docker_metadata_fetcher.DockerV2Session, error) {
+	fake.makeV2SessionMutex.Lock()
+	fake.makeV2SessionArgsForCall = append(fake.makeV2SessionArgsForCall, struct {
+		repoName string
+	}{repoName})
+	fake.makeV2SessionMutex.Unlock()
+	if fake.MakeV2SessionStub != nil {
+		return fake.MakeV2SessionStub(repoName)
+	} else {
+		return fake.makeV2SessionReturns.result1, fake.makeV2SessionReturns.result2
+	}
+}
+
+func (fake *FakeDockerV2SessionFactory) MakeV2SessionCallCount() int {
+	fake.makeV2SessionMutex.RLock()
+	defer fake.makeV2SessionMutex.RUnlock()
+	return len(fake.makeV2SessionArgsForCall)
+}
+
+func (fake *FakeDockerV2SessionFactory) MakeV2SessionArgsForCall(i int) string {
+	fake.makeV2SessionMutex.RLock()
+	defer fake.makeV2SessionMutex.RUnlock()
+	return fake.makeV2SessionArgsForCall[i].repoName
+}
+
+func (fake *FakeDockerV2SessionFactory) MakeV2SessionReturns(result1 docker_metadata_fetcher.DockerV2Session, result2 error) {
+	fake.MakeV2SessionStub = nil
+	fake.makeV2SessionReturns = struct {
+		result1 docker_metadata_fetcher.DockerV2Session
+		result2 error
+	}{result1, result2}
+}
+
+var _ docker_metadata_fetcher.DockerV2SessionFactory = new(FakeDockerV2SessionFactory)
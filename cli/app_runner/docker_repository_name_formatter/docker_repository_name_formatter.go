@@ -1,32 +1,98 @@
 package docker_repository_name_formatter
 
 import (
-	"github.com/docker/docker/registry"
+	"fmt"
+	"net/url"
 	"strings"
 )
 
+const dockerHubHost = "index.docker.io"
+
 func FormatForReceptor(dockerImageReference string) (string, error) {
-	dockerRepositoryName, tag := ParseRepoNameAndTagFromImageReference(dockerImageReference)
+	return FormatForReceptorWithCredentials(dockerImageReference, "", "")
+}
 
-	_, err := registry.ParseRepositoryInfo(dockerRepositoryName)
+// FormatForReceptorWithCredentials embeds the registry credentials in the rootfs url so
+// that the cells can pull private images. Without a username it matches FormatForReceptor.
+func FormatForReceptorWithCredentials(dockerImageReference, username, password string) (string, error) {
+	reference, err := ParseImageReference(dockerImageReference)
 	if err != nil {
 		return "", err
 	}
 
-	if strings.Contains(dockerRepositoryName, "/") {
-		return "docker:///" + dockerRepositoryName + "#" + tag, nil
-	} else {
-		return "docker:///library/" + dockerRepositoryName + "#" + tag, nil
+	if username == "" && reference.Official() {
+		return "docker:///" + reference.RemoteName() + "#" + reference.Reference(), nil
+	}
+
+	rootFS := url.URL{
+		Scheme:   "docker",
+		Host:     reference.Registry,
+		Path:     "/" + reference.RemoteName(),
+		Fragment: reference.Reference(),
+	}
+	if reference.Official() {
+		rootFS.Host = dockerHubHost
+	}
+	if username != "" {
+		rootFS.User = url.UserPassword(username, password)
 	}
+
+	return rootFS.String(), nil
 }
 
+// ParseReceptorRootFS turns a rootfs url built by FormatForReceptor back into the image
+// reference it came from. Credentials embedded in the url are dropped.
+func ParseReceptorRootFS(rootFSPath string) (*ImageReference, error) {
+	rootFS, err := url.Parse(rootFSPath)
+	if err != nil || rootFS.Scheme != "docker" {
+		return nil, fmt.Errorf("Not a docker image rootfs: %s", rootFSPath)
+	}
+
+	reference := &ImageReference{Registry: rootFS.Host}
+	if reference.Official() {
+		reference.Registry = ""
+	}
+
+	reference.Repository = strings.TrimPrefix(rootFS.Path, "/")
+	if reference.Official() && strings.Count(reference.Repository, "/") == 1 {
+		reference.Repository = strings.TrimPrefix(reference.Repository, officialRepositoryNamespace+"/")
+	}
+	if reference.Repository == "" {
+		return nil, fmt.Errorf("Not a docker image rootfs: %s", rootFSPath)
+	}
+
+	if digestRegexp.MatchString(rootFS.Fragment) {
+		reference.Digest = rootFS.Fragment
+	} else if rootFS.Fragment != "" {
+		reference.Tag = rootFS.Fragment
+	}
+
+	return reference, nil
+}
+
+// ParseRepoNameAndTagFromImageReference splits an image into the repository name and the
+// tag or digest to fetch. Unparseable references are split on the last ':' so that the
+// registry can report what is wrong with them.
 func ParseRepoNameAndTagFromImageReference(dockerImageReference string) (string, string) {
-	imageWithTag := strings.Split(dockerImageReference, ":")
-	dockerRepositoryName := imageWithTag[0]
+	if reference, err := ParseImageReference(dockerImageReference); err == nil {
+		return reference.RepoName(), reference.Reference()
+	}
+
+	dockerRepositoryName := dockerImageReference
+	tag := DefaultTag
 
-	tag := "latest"
-	if len(imageWithTag) > 1 {
-		tag = imageWithTag[1]
+	if colon := strings.LastIndex(dockerImageReference, ":"); colon > strings.LastIndex(dockerImageReference, "/") {
+		dockerRepositoryName = dockerImageReference[:colon]
+		tag = dockerImageReference[colon+1:]
 	}
 	return dockerRepositoryName, tag
 }
+
+// RegistryFromRepoName returns the registry host a repository name points at, or an
+// empty string for the docker hub.
+func RegistryFromRepoName(dockerRepositoryName string) string {
+	if parts := strings.SplitN(dockerRepositoryName, "/", 2); len(parts) == 2 && isRegistryHost(parts[0]) {
+		return parts[0]
+	}
+	return ""
+}
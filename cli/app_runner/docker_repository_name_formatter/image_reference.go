@@ -0,0 +1,133 @@
+package docker_repository_name_formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultTag = "latest"
+
+	officialRepositoryNamespace = "library"
+)
+
+// The grammar follows docker's distribution/reference package: an optional registry
+// host with port, one or more lowercase path components, an optional tag and an
+// optional digest.
+var (
+	pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	registryHostRegexp  = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	tagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func ParseImageReference(dockerImageReference string) (*ImageReference, error) {
+	reference := &ImageReference{}
+	name := dockerImageReference
+
+	if at := strings.Index(name, "@"); at != -1 {
+		reference.Digest = name[at+1:]
+		name = name[:at]
+		if !digestRegexp.MatchString(reference.Digest) {
+			return nil, fmt.Errorf("Invalid digest in docker image %s", dockerImageReference)
+		}
+	}
+
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		reference.Tag = name[colon+1:]
+		name = name[:colon]
+		if !tagRegexp.MatchString(reference.Tag) {
+			return nil, fmt.Errorf("Invalid tag in docker image %s", dockerImageReference)
+		}
+	}
+
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && isRegistryHost(parts[0]) {
+		reference.Registry = parts[0]
+		name = parts[1]
+		if !registryHostRegexp.MatchString(reference.Registry) {
+			return nil, fmt.Errorf("Invalid registry in docker image %s", dockerImageReference)
+		}
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("Invalid docker image %s: repository name is required", dockerImageReference)
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return nil, fmt.Errorf("Invalid repository name in docker image %s: %q must be lowercase letters, digits and separators", dockerImageReference, component)
+		}
+	}
+	reference.Repository = name
+
+	return reference, nil
+}
+
+// Official reports whether the image lives on the docker hub.
+func (reference *ImageReference) Official() bool {
+	switch reference.Registry {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return true
+	default:
+		return false
+	}
+}
+
+// RemoteName is the repository path on the registry, which for official single
+// component images lives in the library namespace.
+func (reference *ImageReference) RemoteName() string {
+	if reference.Official() && !strings.Contains(reference.Repository, "/") {
+		return officialRepositoryNamespace + "/" + reference.Repository
+	}
+	return reference.Repository
+}
+
+// RepoName is the image without its tag or digest, as given.
+func (reference *ImageReference) RepoName() string {
+	if reference.Registry == "" {
+		return reference.Repository
+	}
+	return reference.Registry + "/" + reference.Repository
+}
+
+// Reference is what to ask the registry for: the digest if there is one, then the tag.
+func (reference *ImageReference) Reference() string {
+	if reference.Digest != "" {
+		return reference.Digest
+	} else if reference.Tag != "" {
+		return reference.Tag
+	}
+	return DefaultTag
+}
+
+func (reference *ImageReference) String() string {
+	name := reference.RepoName()
+	if reference.Tag != "" {
+		name += ":" + reference.Tag
+	}
+	if reference.Digest != "" {
+		name += "@" + reference.Digest
+	}
+	return name
+}
+
+// Pinned returns a copy of the reference that points at the given digest, keeping
+// the tag for readability.
+func (reference *ImageReference) Pinned(digest string) *ImageReference {
+	pinned := *reference
+	pinned.Digest = digest
+	return &pinned
+}
+
+// isRegistryHost applies docker's rule that the first path component names a registry
+// only if it looks like a host: it contains a dot or a port, or is localhost.
+func isRegistryHost(component string) bool {
+	return component == "localhost" || strings.ContainsAny(component, ".:")
+}
@@ -0,0 +1,75 @@
+package docker_repository_name_formatter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_repository_name_formatter"
+)
+
+var _ = Describe("ParseImageReference", func() {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	parse := func(imageReference string) *docker_repository_name_formatter.ImageReference {
+		reference, err := docker_repository_name_formatter.ParseImageReference(imageReference)
+		Expect(err).ToNot(HaveOccurred())
+		return reference
+	}
+
+	It("parses official images", func() {
+		reference := parse("ubuntu")
+		Expect(*reference).To(Equal(docker_repository_name_formatter.ImageReference{Repository: "ubuntu"}))
+		Expect(reference.Official()).To(BeTrue())
+		Expect(reference.RemoteName()).To(Equal("library/ubuntu"))
+		Expect(reference.Reference()).To(Equal("latest"))
+	})
+
+	It("parses multi-level namespaces", func() {
+		reference := parse("jimbo/team/my-docker-app:v1.2")
+		Expect(*reference).To(Equal(docker_repository_name_formatter.ImageReference{Repository: "jimbo/team/my-docker-app", Tag: "v1.2"}))
+		Expect(reference.RemoteName()).To(Equal("jimbo/team/my-docker-app"))
+	})
+
+	It("parses registry hosts with ports", func() {
+		reference := parse("registry.example.com:5000/jimbo/my-docker-app:test")
+		Expect(*reference).To(Equal(docker_repository_name_formatter.ImageReference{Registry: "registry.example.com:5000", Repository: "jimbo/my-docker-app", Tag: "test"}))
+		Expect(reference.Official()).To(BeFalse())
+		Expect(reference.RepoName()).To(Equal("registry.example.com:5000/jimbo/my-docker-app"))
+	})
+
+	It("treats localhost as a registry host", func() {
+		Expect(parse("localhost/my-docker-app").Registry).To(Equal("localhost"))
+		Expect(parse("jimbo/my-docker-app").Registry).To(BeEmpty())
+	})
+
+	It("parses digests, which take precedence over tags", func() {
+		reference := parse("registry.example.com:5000/my-docker-app:v2@" + digest)
+		Expect(reference.Tag).To(Equal("v2"))
+		Expect(reference.Digest).To(Equal(digest))
+		Expect(reference.Reference()).To(Equal(digest))
+		Expect(reference.String()).To(Equal("registry.example.com:5000/my-docker-app:v2@" + digest))
+
+		Expect(parse("ubuntu@" + digest).Reference()).To(Equal(digest))
+	})
+
+	It("pins a reference to a digest", func() {
+		reference := parse("jimbo/my-docker-app:v2")
+
+		Expect(reference.Pinned(digest).String()).To(Equal("jimbo/my-docker-app:v2@" + digest))
+		Expect(reference.Digest).To(BeEmpty())
+	})
+
+	It("rejects malformed references", func() {
+		for _, imageReference := range []string{
+			"",
+			"UPPER/case",
+			"jimbo/my-docker-app:bad/tag",
+			"jimbo/my-docker-app@sha256:tooshort",
+			"registry.example.com:5000/",
+			"¥¥¥¥¥suchabadname¥¥¥¥¥",
+		} {
+			_, err := docker_repository_name_formatter.ParseImageReference(imageReference)
+			Expect(err).To(HaveOccurred(), imageReference)
+		}
+	})
+})
@@ -0,0 +1,66 @@
+package environment
+
+import "strings"
+
+// Build turns NAME[=VALUE] pairs into a map, taking the value of a pair without one
+// from env, a list of NAME=VALUE pairs such as os.Environ().
+func Build(envVars []string, env []string) map[string]string {
+	environment := make(map[string]string)
+
+	for _, envVarPair := range envVars {
+		name, value := ParsePair(envVarPair)
+
+		if value == "" {
+			value = Lookup(env, name)
+		}
+
+		environment[name] = value
+	}
+	return environment
+}
+
+// BuildFromMap copies envVars, taking the value of a variable without one from env.
+func BuildFromMap(envVars map[string]string, env []string) map[string]string {
+	environment := make(map[string]string)
+
+	for name, value := range envVars {
+		if value == "" {
+			value = Lookup(env, name)
+		}
+
+		environment[name] = value
+	}
+	return environment
+}
+
+// Merge layers env over imageEnv, the environment an image declares for itself.
+func Merge(imageEnv, env map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for name, value := range imageEnv {
+		merged[name] = value
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Lookup returns the value of the variable called name in env, or "" if it is not set.
+func Lookup(env []string, name string) string {
+	for _, envVarPair := range env {
+		if strings.HasPrefix(envVarPair, name+"=") {
+			_, value := ParsePair(envVarPair)
+			return value
+		}
+	}
+	return ""
+}
+
+// ParsePair splits NAME=VALUE at the first "=", so the value may contain "=" itself.
+func ParsePair(envVarPair string) (name, value string) {
+	s := strings.SplitN(envVarPair, "=", 2)
+	if len(s) > 1 {
+		return s[0], s[1]
+	}
+	return s[0], ""
+}
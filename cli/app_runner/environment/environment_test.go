@@ -0,0 +1,74 @@
+package environment_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/environment"
+)
+
+var _ = Describe("Environment", func() {
+	env := []string{"TIMEZONE_OFFSET=2", "TIMEZONE=CET", "QUERY=a=1&b=2"}
+
+	Describe("Build", func() {
+		It("takes the values given in the pairs", func() {
+			Expect(environment.Build([]string{"COLOR=Blue", "EMPTY="}, env)).To(Equal(map[string]string{
+				"COLOR": "Blue",
+				"EMPTY": "",
+			}))
+		})
+
+		It("keeps any = in the values", func() {
+			Expect(environment.Build([]string{"DATABASE_URL=mysql://db?user=admin&ssl=true"}, env)).To(Equal(map[string]string{
+				"DATABASE_URL": "mysql://db?user=admin&ssl=true",
+			}))
+		})
+
+		It("takes the values of names without one from env", func() {
+			Expect(environment.Build([]string{"TIMEZONE", "QUERY", "UNSET"}, env)).To(Equal(map[string]string{
+				"TIMEZONE": "CET",
+				"QUERY":    "a=1&b=2",
+				"UNSET":    "",
+			}))
+		})
+	})
+
+	Describe("BuildFromMap", func() {
+		It("takes the values of variables without one from env", func() {
+			Expect(environment.BuildFromMap(map[string]string{"COLOR": "Blue", "TIMEZONE": ""}, env)).To(Equal(map[string]string{
+				"COLOR":    "Blue",
+				"TIMEZONE": "CET",
+			}))
+		})
+	})
+
+	Describe("Merge", func() {
+		It("layers the given environment over the image's own", func() {
+			Expect(environment.Merge(map[string]string{"PATH": "/usr/local/bin", "COLOR": "Red"}, map[string]string{"COLOR": "Blue"})).To(Equal(map[string]string{
+				"PATH":  "/usr/local/bin",
+				"COLOR": "Blue",
+			}))
+		})
+	})
+
+	Describe("Lookup", func() {
+		It("matches the whole name rather than a prefix of it", func() {
+			Expect(environment.Lookup(env, "TIMEZONE")).To(Equal("CET"))
+			Expect(environment.Lookup(env, "TIME")).To(BeEmpty())
+		})
+	})
+
+	Describe("ParsePair", func() {
+		It("splits at the first =", func() {
+			name, value := environment.ParsePair("QUERY=a=1&b=2")
+			Expect(name).To(Equal("QUERY"))
+			Expect(value).To(Equal("a=1&b=2"))
+		})
+
+		It("returns an empty value for a bare name", func() {
+			name, value := environment.ParsePair("TIMEZONE")
+			Expect(name).To(Equal("TIMEZONE"))
+			Expect(value).To(BeEmpty())
+		})
+	})
+})
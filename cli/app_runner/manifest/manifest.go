@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultManifestFile = "lattice.yml"
+
+type Manifest struct {
+	Applications []AppManifest `yaml:"applications"`
+}
+
+type AppManifest struct {
+	Name          string            `yaml:"name"`
+	DockerImage   string            `yaml:"docker_image"`
+	StartCommand  string            `yaml:"start_command,omitempty"`
+	AppArgs       []string          `yaml:"args,omitempty"`
+	WorkingDir    string            `yaml:"working_dir,omitempty"`
+	RunAsRoot     bool              `yaml:"run_as_root,omitempty"`
+	Env           map[string]string `yaml:"env,omitempty"`
+	Ports         []uint16          `yaml:"ports,omitempty"`
+	MonitoredPort uint16            `yaml:"monitored_port,omitempty"`
+	NoMonitor     bool              `yaml:"no_monitor,omitempty"`
+	Routes        []RouteManifest   `yaml:"routes,omitempty"`
+	MemoryMB      *int              `yaml:"memory_mb,omitempty"`
+	DiskMB        *int              `yaml:"disk_mb,omitempty"`
+	Instances     *int              `yaml:"instances,omitempty"`
+	CPUWeight     uint              `yaml:"cpu_weight,omitempty"`
+	LogSource     string            `yaml:"log_source,omitempty"`
+	Domain        string            `yaml:"domain,omitempty"`
+	Annotation    string            `yaml:"annotation,omitempty"`
+	Unprivileged  bool              `yaml:"unprivileged,omitempty"`
+	HealthCheck   *HealthCheck      `yaml:"health_check,omitempty"`
+}
+
+// HealthCheck mirrors the health check flags of ltc start. The timeouts are
+// durations such as 5s or 2m.
+type HealthCheck struct {
+	Type         string `yaml:"type,omitempty"`
+	Path         string `yaml:"path,omitempty"`
+	Status       int    `yaml:"status,omitempty"`
+	Command      string `yaml:"command,omitempty"`
+	Timeout      string `yaml:"timeout,omitempty"`
+	StartTimeout string `yaml:"start_timeout,omitempty"`
+}
+
+type RouteManifest struct {
+	Hostname string `yaml:"hostname"`
+	Port     uint16 `yaml:"port"`
+}
+
+func Parse(reader io.Reader) (*Manifest, error) {
+	manifestBytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(manifestBytes, manifest); err != nil {
+		return nil, fmt.Errorf("Error parsing manifest: %s", err.Error())
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Write validates the manifest and writes it out in the format Parse reads.
+func Write(writer io.Writer, manifest *Manifest) error {
+	if err := manifest.validate(); err != nil {
+		return err
+	}
+
+	manifestBytes, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(manifestBytes)
+	return err
+}
+
+func (manifest *Manifest) validate() error {
+	if len(manifest.Applications) == 0 {
+		return errors.New("Manifest must contain at least one application")
+	}
+
+	seenNames := make(map[string]struct{})
+	for index, app := range manifest.Applications {
+		if app.Name == "" {
+			return fmt.Errorf("Application %d is missing a name", index+1)
+		}
+
+		if _, seen := seenNames[app.Name]; seen {
+			return fmt.Errorf("Application %s is declared more than once", app.Name)
+		}
+		seenNames[app.Name] = struct{}{}
+
+		if err := app.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app AppManifest) validate() error {
+	if app.DockerImage == "" {
+		return fmt.Errorf("Application %s is missing a docker_image", app.Name)
+	}
+
+	if len(app.Ports) > 1 && app.MonitoredPort == 0 && !app.NoMonitor {
+		return fmt.Errorf("Application %s must set monitored_port when specifying multiple ports unless no_monitor is set", app.Name)
+	}
+
+	if app.MonitoredPort != 0 && len(app.Ports) > 0 && !containsPort(app.Ports, app.MonitoredPort) {
+		return fmt.Errorf("Application %s has a monitored_port that is not one of its ports", app.Name)
+	}
+
+	for _, route := range app.Routes {
+		if route.Hostname == "" || route.Port == 0 {
+			return fmt.Errorf("Application %s has a malformed route. Routes must specify a hostname and a port", app.Name)
+		}
+	}
+
+	for _, count := range []*int{app.MemoryMB, app.DiskMB, app.Instances} {
+		if count != nil && *count < 0 {
+			return fmt.Errorf("Application %s has a negative memory_mb, disk_mb or instances", app.Name)
+		}
+	}
+
+	if app.HealthCheck != nil {
+		for _, duration := range []string{app.HealthCheck.Timeout, app.HealthCheck.StartTimeout} {
+			if _, err := time.ParseDuration(duration); duration != "" && err != nil {
+				return fmt.Errorf("Application %s has a malformed health check timeout: %s", app.Name, duration)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsPort(ports []uint16, port uint16) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
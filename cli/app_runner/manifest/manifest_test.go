@@ -0,0 +1,228 @@
+package manifest_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/manifest"
+)
+
+var _ = Describe("Manifest", func() {
+	Describe("Parse", func() {
+		It("parses a manifest describing multiple applications", func() {
+			manifestYaml := `
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  start_command: /lattice-app
+  args: ["--message", "hello"]
+  working_dir: /app
+  run_as_root: true
+  env:
+    TIMEZONE: CST
+  ports: [8080, 9090]
+  monitored_port: 9090
+  routes:
+  - hostname: web-admin
+    port: 9090
+  memory_mb: 256
+  disk_mb: 512
+  instances: 3
+  cpu_weight: 50
+  log_source: WEB
+  domain: staging
+  annotation: the web tier
+  unprivileged: true
+  health_check:
+    type: http
+    path: /ping
+    status: 204
+    timeout: 5s
+    start_timeout: 2m
+- name: worker
+  docker_image: cloudfoundry/worker
+`
+			appManifest, err := manifest.Parse(strings.NewReader(manifestYaml))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(appManifest.Applications).To(HaveLen(2))
+
+			web := appManifest.Applications[0]
+			Expect(web.Name).To(Equal("web"))
+			Expect(web.DockerImage).To(Equal("cloudfoundry/lattice-app"))
+			Expect(web.StartCommand).To(Equal("/lattice-app"))
+			Expect(web.AppArgs).To(Equal([]string{"--message", "hello"}))
+			Expect(web.WorkingDir).To(Equal("/app"))
+			Expect(web.RunAsRoot).To(BeTrue())
+			Expect(web.Env).To(Equal(map[string]string{"TIMEZONE": "CST"}))
+			Expect(web.Ports).To(Equal([]uint16{8080, 9090}))
+			Expect(web.MonitoredPort).To(Equal(uint16(9090)))
+			Expect(web.Routes).To(Equal([]manifest.RouteManifest{{Hostname: "web-admin", Port: 9090}}))
+			Expect(*web.MemoryMB).To(Equal(256))
+			Expect(*web.DiskMB).To(Equal(512))
+			Expect(*web.Instances).To(Equal(3))
+			Expect(web.CPUWeight).To(Equal(uint(50)))
+			Expect(web.LogSource).To(Equal("WEB"))
+			Expect(web.Domain).To(Equal("staging"))
+			Expect(web.Annotation).To(Equal("the web tier"))
+			Expect(web.Unprivileged).To(BeTrue())
+			Expect(web.HealthCheck).To(Equal(&manifest.HealthCheck{Type: "http", Path: "/ping", Status: 204, Timeout: "5s", StartTimeout: "2m"}))
+
+			worker := appManifest.Applications[1]
+			Expect(worker.Name).To(Equal("worker"))
+			Expect(worker.MemoryMB).To(BeNil())
+			Expect(worker.DiskMB).To(BeNil())
+			Expect(worker.Instances).To(BeNil())
+			Expect(worker.HealthCheck).To(BeNil())
+		})
+
+		It("parses JSON manifests", func() {
+			appManifest, err := manifest.Parse(strings.NewReader(`{"applications": [{"name": "web", "docker_image": "cloudfoundry/lattice-app", "instances": 0}]}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(appManifest.Applications).To(HaveLen(1))
+			Expect(*appManifest.Applications[0].Instances).To(Equal(0))
+		})
+
+		It("returns an error for malformed yaml", func() {
+			_, err := manifest.Parse(strings.NewReader("applications: [name: web"))
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("Error parsing manifest:"))
+		})
+
+		Context("validation", func() {
+			itFailsWith := func(manifestYaml, message string) {
+				_, err := manifest.Parse(strings.NewReader(manifestYaml))
+				ExpectWithOffset(1, err).To(MatchError(message))
+			}
+
+			It("requires at least one application", func() {
+				itFailsWith("applications: []", "Manifest must contain at least one application")
+			})
+
+			It("requires every application to have a name", func() {
+				itFailsWith(`
+applications:
+- docker_image: cloudfoundry/lattice-app
+`, "Application 1 is missing a name")
+			})
+
+			It("requires application names to be unique", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+- name: web
+  docker_image: cloudfoundry/other-app
+`, "Application web is declared more than once")
+			})
+
+			It("requires a docker image", func() {
+				itFailsWith(`
+applications:
+- name: web
+`, "Application web is missing a docker_image")
+			})
+
+			It("requires a monitored port when multiple ports are specified", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  ports: [8080, 9090]
+`, "Application web must set monitored_port when specifying multiple ports unless no_monitor is set")
+			})
+
+			It("allows multiple ports without a monitored port when no_monitor is set", func() {
+				_, err := manifest.Parse(strings.NewReader(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  ports: [8080, 9090]
+  no_monitor: true
+`))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("requires the monitored port to be one of the ports", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  ports: [8080]
+  monitored_port: 9090
+`, "Application web has a monitored_port that is not one of its ports")
+			})
+
+			It("requires routes to have a hostname and a port", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  routes:
+  - hostname: web
+`, "Application web has a malformed route. Routes must specify a hostname and a port")
+			})
+
+			It("rejects negative resource values", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  instances: -1
+`, "Application web has a negative memory_mb, disk_mb or instances")
+			})
+
+			It("rejects malformed health check timeouts", func() {
+				itFailsWith(`
+applications:
+- name: web
+  docker_image: cloudfoundry/lattice-app
+  health_check:
+    start_timeout: soon
+`, "Application web has a malformed health check timeout: soon")
+			})
+		})
+	})
+
+	Describe("Write", func() {
+		It("writes a manifest that parses back to the same apps", func() {
+			instances := 2
+			original := &manifest.Manifest{
+				Applications: []manifest.AppManifest{
+					{
+						Name:         "web",
+						DockerImage:  "cloudfoundry/lattice-app",
+						StartCommand: "/lattice-app",
+						Env:          map[string]string{"GREETING": "hello"},
+						Ports:        []uint16{8080},
+						Routes:       []manifest.RouteManifest{{Hostname: "web", Port: 8080}},
+						Instances:    &instances,
+						CPUWeight:    50,
+						Domain:       "staging",
+						Unprivileged: true,
+						HealthCheck:  &manifest.HealthCheck{Type: "command", Command: "pgrep lattice-app", Timeout: "2s"},
+					},
+				},
+			}
+
+			buffer := &bytes.Buffer{}
+			Expect(manifest.Write(buffer, original)).To(Succeed())
+			Expect(buffer.String()).ToNot(ContainSubstring("run_as_root"))
+
+			parsed, err := manifest.Parse(buffer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed).To(Equal(original))
+		})
+
+		It("refuses to write an invalid manifest", func() {
+			err := manifest.Write(&bytes.Buffer{}, &manifest.Manifest{})
+
+			Expect(err).To(MatchError("Manifest must contain at least one application"))
+		})
+	})
+})
@@ -0,0 +1,334 @@
+package command_factory
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_repository_name_formatter"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/environment"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+)
+
+const TimestampDisplayLayout = "2006-01-02 15:04:05 (MST)"
+
+type TaskRunnerCommandFactory struct {
+	taskRunnerCommand *taskRunnerCommand
+}
+
+func NewTaskRunnerCommandFactory(taskRunner task_runner.TaskRunner, dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher, registryCredentials registry_credentials.RegistryCredentials, output *output.Output, env []string) *TaskRunnerCommandFactory {
+	return &TaskRunnerCommandFactory{&taskRunnerCommand{taskRunner, dockerMetadataFetcher, registryCredentials, output, env}}
+}
+
+func (commandFactory *TaskRunnerCommandFactory) MakeSubmitTaskCommand() cli.Command {
+	var submitTaskFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "working-dir, w",
+			Usage: "working directory to assign to the task's process",
+		},
+		cli.BoolFlag{
+			Name:  "run-as-root, r",
+			Usage: "run the task's process as a privileged user (root)",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "environment variables to set, NAME[=VALUE]",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "container memory limit in MB",
+			Value: 128,
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "container disk limit in MB",
+			Value: 1024,
+		},
+		cli.StringFlag{
+			Name:  "result-file",
+			Usage: "file in the container whose contents are reported as the task's result",
+		},
+		cli.StringFlag{
+			Name:  "annotation",
+			Usage: "arbitrary annotation to store with the task",
+		},
+		cli.BoolFlag{
+			Name:  "registry-credentials",
+			Usage: "pass your credentials for the image's registry to lattice so it can pull a private image. They are visible to anyone who can read the task from the receptor.",
+		},
+	}
+
+	return cli.Command{
+		Name:  "submit-task",
+		Usage: "ltc submit-task TASK_NAME DOCKER_IMAGE",
+		Description: `Run a one-off task on lattice
+
+   TASK_NAME is required and must be unique across the Lattice cluster
+   DOCKER_IMAGE is required and must match the standard docker image format
+
+   ltc will fetch the command and working directory associated with your Docker image.
+   To provide a custom command:
+   ltc submit-task TASK_NAME DOCKER_IMAGE <optional flags> -- COMMAND ARG1 ARG2 ...
+
+   The task gets the environment of the image, with any -e variables layered over it.
+
+   ltc reads image metadata with the credentials saved by ltc registry-login or docker login.
+   To let lattice pull a private image with those credentials as well:
+   ltc submit-task TASK_NAME DOCKER_IMAGE --registry-credentials
+
+   Use ltc task TASK_NAME to check on the task once it has been submitted.`,
+		Action: commandFactory.taskRunnerCommand.submitTask,
+		Flags:  submitTaskFlags,
+	}
+}
+
+func (commandFactory *TaskRunnerCommandFactory) MakeListTasksCommand() cli.Command {
+	return cli.Command{
+		Name:        "tasks",
+		Description: "List all tasks on Lattice",
+		Usage:       "ltc tasks",
+		Action:      commandFactory.taskRunnerCommand.listTasks,
+		Flags:       []cli.Flag{},
+	}
+}
+
+func (commandFactory *TaskRunnerCommandFactory) MakeTaskCommand() cli.Command {
+	return cli.Command{
+		Name:        "task",
+		Description: "Displays the state and result of the given task",
+		Usage:       "ltc task TASK_NAME",
+		Action:      commandFactory.taskRunnerCommand.taskStatus,
+		Flags:       []cli.Flag{},
+	}
+}
+
+func (commandFactory *TaskRunnerCommandFactory) MakeCancelTaskCommand() cli.Command {
+	return cli.Command{
+		Name:        "cancel-task",
+		Description: "Cancel a pending or running task",
+		Usage:       "ltc cancel-task TASK_NAME",
+		Action:      commandFactory.taskRunnerCommand.cancelTask,
+		Flags:       []cli.Flag{},
+	}
+}
+
+func (commandFactory *TaskRunnerCommandFactory) MakeDeleteTaskCommand() cli.Command {
+	return cli.Command{
+		Name:        "delete-task",
+		Description: "Delete a completed task",
+		Usage:       "ltc delete-task TASK_NAME",
+		Action:      commandFactory.taskRunnerCommand.deleteTask,
+		Flags:       []cli.Flag{},
+	}
+}
+
+type taskRunnerCommand struct {
+	taskRunner            task_runner.TaskRunner
+	dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
+	registryCredentials   registry_credentials.RegistryCredentials
+	output                *output.Output
+	env                   []string
+}
+
+func (cmd *taskRunnerCommand) submitTask(context *cli.Context) {
+	taskName := context.Args().Get(0)
+	dockerImage := context.Args().Get(1)
+	terminator := context.Args().Get(2)
+	command := context.Args().Get(3)
+	workingDir := context.String("working-dir")
+
+	var args []string
+
+	switch {
+	case len(context.Args()) < 2:
+		cmd.output.IncorrectUsage("TASK_NAME and DOCKER_IMAGE are required")
+		return
+	case command != "" && terminator != "--":
+		cmd.output.IncorrectUsage("'--' Required before command")
+		return
+	case len(context.Args()) > 4:
+		args = context.Args()[4:]
+	}
+
+	repoName, tag := docker_repository_name_formatter.ParseRepoNameAndTagFromImageReference(dockerImage)
+
+	var registryCredentials registry_credentials.Credentials
+	if context.Bool("registry-credentials") {
+		registry := docker_repository_name_formatter.RegistryFromRepoName(repoName)
+		credentials, found, err := cmd.registryCredentials.Lookup(registry)
+		if err != nil {
+			cmd.output.Say(fmt.Sprintf("Error reading registry credentials: %s", err))
+			return
+		} else if !found {
+			cmd.output.Say(fmt.Sprintf("No credentials found for %s. Run ltc registry-login first.", registry_credentials.ServerFor(registry)))
+			return
+		}
+		registryCredentials = credentials
+	}
+
+	imageMetadata, err := cmd.dockerMetadataFetcher.FetchMetadata(repoName, tag)
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error fetching image metadata: %s", err))
+		return
+	}
+
+	if command == "" {
+		if len(imageMetadata.StartCommand) == 0 {
+			cmd.output.Say("No command specified and the image metadata did not contain one")
+			return
+		}
+		command = imageMetadata.StartCommand[0]
+		args = imageMetadata.StartCommand[1:]
+	}
+
+	if workingDir == "" {
+		workingDir = imageMetadata.WorkingDir
+	}
+	if workingDir == "" {
+		workingDir = "/"
+	}
+
+	err = cmd.taskRunner.SubmitTask(task_runner.SubmitTaskParams{
+		TaskGuid:             taskName,
+		DockerImagePath:      dockerImage,
+		Command:              command,
+		Args:                 args,
+		WorkingDir:           workingDir,
+		EnvironmentVariables: environment.Merge(imageMetadata.Env, environment.Build(context.StringSlice("env"), cmd.env)),
+		Privileged:           context.Bool("run-as-root"),
+		MemoryMB:             context.Int("memory-mb"),
+		DiskMB:               context.Int("disk-mb"),
+		ResultFile:           context.String("result-file"),
+		Annotation:           context.String("annotation"),
+		RegistryUsername:     registryCredentials.Username,
+		RegistryPassword:     registryCredentials.Password,
+	})
+	if err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Submitting Task: %s", err))
+		return
+	}
+
+	cmd.output.Say(colors.Green(fmt.Sprintf("Submitted task %s", taskName)))
+	cmd.output.NewLine()
+}
+
+func (cmd *taskRunnerCommand) listTasks(context *cli.Context) {
+	tasks, err := cmd.taskRunner.ListTasks()
+	if err != nil {
+		cmd.output.Say("Error listing tasks: " + err.Error())
+		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(tasks)
+		return
+	} else if len(tasks) == 0 {
+		cmd.output.Say("No tasks to display.")
+		return
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(cmd.output, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", colors.Bold("Task Name"), colors.Bold("State"), colors.Bold("Cell"))
+	for _, task := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", colors.Bold(task.TaskGuid), colorTaskState(task), colors.NoColor(task.CellID))
+	}
+
+	w.Flush()
+}
+
+func (cmd *taskRunnerCommand) taskStatus(context *cli.Context) {
+	taskName := context.Args().First()
+	if taskName == "" {
+		cmd.output.IncorrectUsage("TASK_NAME required")
+		return
+	}
+
+	task, err := cmd.taskRunner.TaskStatus(taskName)
+	if err != nil {
+		cmd.output.Say(err.Error())
+		return
+	} else if cmd.output.IsStructured() {
+		cmd.sayFormatted(task)
+		return
+	}
+
+	w := tabwriter.NewWriter(cmd.output, 13, 8, 1, '\t', 0)
+
+	fmt.Fprintf(w, "%s\t%s\n", "Task Name", colors.Bold(task.TaskGuid))
+	fmt.Fprintf(w, "%s\t%s\n", "State", colorTaskState(task))
+	fmt.Fprintf(w, "%s\t%s\n", "Image", task.RootFSPath)
+	if task.CellID != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Cell", task.CellID)
+	}
+	if task.CreatedAt != 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Created", time.Unix(0, task.CreatedAt).Format(TimestampDisplayLayout))
+	}
+	if task.Annotation != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Annotation", task.Annotation)
+	}
+	if task.Failed {
+		fmt.Fprintf(w, "%s\t%s\n", "Failure Reason", colors.Red(task.FailureReason))
+	}
+	if task.Result != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Result", task.Result)
+	}
+
+	w.Flush()
+}
+
+func (cmd *taskRunnerCommand) cancelTask(context *cli.Context) {
+	taskName := context.Args().First()
+	if taskName == "" {
+		cmd.output.IncorrectUsage("TASK_NAME required")
+		return
+	}
+
+	if err := cmd.taskRunner.CancelTask(taskName); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Cancelling Task: %s", err))
+		return
+	}
+
+	cmd.output.Say(colors.Green(fmt.Sprintf("Cancelled task %s", taskName)))
+	cmd.output.NewLine()
+}
+
+func (cmd *taskRunnerCommand) deleteTask(context *cli.Context) {
+	taskName := context.Args().First()
+	if taskName == "" {
+		cmd.output.IncorrectUsage("TASK_NAME required")
+		return
+	}
+
+	if err := cmd.taskRunner.DeleteTask(taskName); err != nil {
+		cmd.output.Say(fmt.Sprintf("Error Deleting Task: %s", err))
+		return
+	}
+
+	cmd.output.Say(colors.Green(fmt.Sprintf("Deleted task %s", taskName)))
+	cmd.output.NewLine()
+}
+
+func (cmd *taskRunnerCommand) sayFormatted(value interface{}) {
+	if err := cmd.output.SayFormatted(value); err != nil {
+		cmd.output.Say("Error formatting output: " + err.Error())
+	}
+}
+
+func colorTaskState(task task_runner.TaskInfo) string {
+	switch {
+	case task.Failed:
+		return colors.Red("FAILED")
+	case task.State == receptor.TaskStateCompleted:
+		return colors.Green(task.State)
+	case task.State == receptor.TaskStateRunning:
+		return colors.Cyan(task.State)
+	}
+	return colors.Yellow(task.State)
+}
@@ -0,0 +1,425 @@
+package command_factory_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner/command_factory"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner/fake_task_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials/fake_registry_credentials"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
+)
+
+var _ = Describe("TaskRunnerCommandFactory", func() {
+	var (
+		taskRunner            *fake_task_runner.FakeTaskRunner
+		dockerMetadataFetcher *fake_docker_metadata_fetcher.FakeDockerMetadataFetcher
+		registryCredentials   *fake_registry_credentials.FakeRegistryCredentials
+		outputBuffer          *gbytes.Buffer
+		commandFactory        *command_factory.TaskRunnerCommandFactory
+	)
+
+	BeforeEach(func() {
+		taskRunner = &fake_task_runner.FakeTaskRunner{}
+		dockerMetadataFetcher = &fake_docker_metadata_fetcher.FakeDockerMetadataFetcher{}
+		registryCredentials = &fake_registry_credentials.FakeRegistryCredentials{}
+		outputBuffer = gbytes.NewBuffer()
+		commandFactory = command_factory.NewTaskRunnerCommandFactory(taskRunner, dockerMetadataFetcher, registryCredentials, output.New(outputBuffer), []string{"SHELL=/bin/bash", "SHELLOPTS=braceexpand"})
+	})
+
+	Describe("SubmitTaskCommand", func() {
+		var submitTaskCommand cli.Command
+
+		BeforeEach(func() {
+			submitTaskCommand = commandFactory.MakeSubmitTaskCommand()
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+				WorkingDir:   "/migrator",
+				StartCommand: []string{"/migrate", "--all"},
+			}, nil)
+		})
+
+		It("submits a task with the specified command and flags", func() {
+			args := []string{
+				"--working-dir=/app",
+				"--run-as-root=true",
+				"--env=DATABASE_URL=mysql://db:3306/app",
+				"--env=SHELL",
+				"--memory-mb=256",
+				"--disk-mb=512",
+				"--result-file=/tmp/result",
+				"--annotation=nightly migration",
+				"migrate-db",
+				"cool/migrator:v3",
+				"--",
+				"/migrate",
+				"--up",
+				"3",
+			}
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, args)
+
+			repoName, tag := dockerMetadataFetcher.FetchMetadataArgsForCall(0)
+			Expect(repoName).To(Equal("cool/migrator"))
+			Expect(tag).To(Equal("v3"))
+
+			Expect(taskRunner.SubmitTaskCallCount()).To(Equal(1))
+			Expect(taskRunner.SubmitTaskArgsForCall(0)).To(Equal(task_runner.SubmitTaskParams{
+				TaskGuid:             "migrate-db",
+				DockerImagePath:      "cool/migrator:v3",
+				Command:              "/migrate",
+				Args:                 []string{"--up", "3"},
+				WorkingDir:           "/app",
+				EnvironmentVariables: map[string]string{"DATABASE_URL": "mysql://db:3306/app", "SHELL": "/bin/bash"},
+				Privileged:           true,
+				MemoryMB:             256,
+				DiskMB:               512,
+				ResultFile:           "/tmp/result",
+				Annotation:           "nightly migration",
+			}))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Submitted task migrate-db")))
+		})
+
+		It("uses the command and working directory from the image metadata by default", func() {
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			params := taskRunner.SubmitTaskArgsForCall(0)
+			Expect(params.Command).To(Equal("/migrate"))
+			Expect(params.Args).To(Equal([]string{"--all"}))
+			Expect(params.WorkingDir).To(Equal("/migrator"))
+			Expect(params.MemoryMB).To(Equal(128))
+			Expect(params.DiskMB).To(Equal(1024))
+		})
+
+		It("layers the environment given with -e over the image's own", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+				StartCommand: []string{"/migrate"},
+				Env:          map[string]string{"PATH": "/usr/local/bin", "COLOR": "Red"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--env=COLOR=Blue", "migrate-db", "cool/migrator"})
+
+			Expect(taskRunner.SubmitTaskArgsForCall(0).EnvironmentVariables).To(Equal(map[string]string{
+				"PATH":  "/usr/local/bin",
+				"COLOR": "Blue",
+			}))
+		})
+
+		It("defaults the working directory to /", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/migrate"}}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			Expect(taskRunner.SubmitTaskArgsForCall(0).WorkingDir).To(Equal("/"))
+		})
+
+		It("requires a command when the image metadata does not contain one", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			Expect(outputBuffer).To(test_helpers.Say("No command specified and the image metadata did not contain one"))
+			Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+		})
+
+		It("outputs errors fetching the image metadata", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(nil, errors.New("Docker Says No."))
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error fetching image metadata: Docker Says No."))
+			Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+		})
+
+		Context("when --registry-credentials is set", func() {
+			It("passes the credentials for the image's registry to the TaskRunner", func() {
+				registryCredentials.LookupReturns(registry_credentials.Credentials{Username: "jim", Password: "secret"}, true, nil)
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--registry-credentials", "migrate-db", "registry.example.com:5000/cool/migrator:v3"})
+
+				Expect(registryCredentials.LookupCallCount()).To(Equal(1))
+				Expect(registryCredentials.LookupArgsForCall(0)).To(Equal("registry.example.com:5000"))
+
+				Expect(taskRunner.SubmitTaskCallCount()).To(Equal(1))
+				params := taskRunner.SubmitTaskArgsForCall(0)
+				Expect(params.RegistryUsername).To(Equal("jim"))
+				Expect(params.RegistryPassword).To(Equal("secret"))
+			})
+
+			It("does not submit the task when there are no credentials for the registry", func() {
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--registry-credentials", "migrate-db", "cool/migrator"})
+
+				Expect(outputBuffer).To(test_helpers.Say("No credentials found for https://index.docker.io/v1/. Run ltc registry-login first."))
+				Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+			})
+
+			It("outputs errors reading the credentials", func() {
+				registryCredentials.LookupReturns(registry_credentials.Credentials{}, false, errors.New("bad docker config"))
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--registry-credentials", "migrate-db", "cool/migrator"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error reading registry credentials: bad docker config"))
+				Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+			})
+		})
+
+		It("does not look up registry credentials by default", func() {
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			Expect(registryCredentials.LookupCallCount()).To(Equal(0))
+			Expect(taskRunner.SubmitTaskArgsForCall(0).RegistryUsername).To(BeEmpty())
+		})
+
+		It("outputs errors submitting the task", func() {
+			taskRunner.SubmitTaskReturns(errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Submitting Task: Major Fault"))
+		})
+
+		It("validates that the name and image are passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME and DOCKER_IMAGE are required"))
+			Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+		})
+
+		It("validates that the terminator is passed in before the command", func() {
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"migrate-db", "cool/migrator", "not-the-terminator", "/migrate"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: '--' Required before command"))
+			Expect(taskRunner.SubmitTaskCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("ListTasksCommand", func() {
+		var listTasksCommand cli.Command
+
+		BeforeEach(func() {
+			listTasksCommand = commandFactory.MakeListTasksCommand()
+		})
+
+		It("lists the tasks and their states", func() {
+			taskRunner.ListTasksReturns([]task_runner.TaskInfo{
+				task_runner.TaskInfo{TaskGuid: "migrate-db", State: receptor.TaskStateCompleted},
+				task_runner.TaskInfo{TaskGuid: "nightly-batch", State: receptor.TaskStateRunning, CellID: "cell-1"},
+				task_runner.TaskInfo{TaskGuid: "broken-batch", State: receptor.TaskStateCompleted, Failed: true},
+				task_runner.TaskInfo{TaskGuid: "queued-batch", State: receptor.TaskStatePending},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Task Name")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("State")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Cell")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("migrate-db")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("COMPLETED")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("nightly-batch")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("RUNNING")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("cell-1")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("broken-batch")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("FAILED")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("queued-batch")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("PENDING")))
+		})
+
+		It("alerts the user if there are no tasks", func() {
+			taskRunner.ListTasksReturns([]task_runner.TaskInfo{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("No tasks to display."))
+		})
+
+		It("outputs errors listing the tasks", func() {
+			taskRunner.ListTasksReturns(nil, errors.New("The list was lost"))
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error listing tasks: The list was lost"))
+		})
+	})
+
+	Describe("TaskCommand", func() {
+		var taskCommand cli.Command
+
+		BeforeEach(func() {
+			taskCommand = commandFactory.MakeTaskCommand()
+		})
+
+		It("displays the state and result of the task", func() {
+			createdAt := time.Date(2015, 3, 1, 12, 30, 0, 0, time.Local)
+			taskRunner.TaskStatusReturns(task_runner.TaskInfo{
+				TaskGuid:   "migrate-db",
+				RootFSPath: "docker:///cool/migrator#v3",
+				State:      receptor.TaskStateCompleted,
+				CellID:     "cell-1",
+				CreatedAt:  createdAt.UnixNano(),
+				Annotation: "nightly migration",
+				Result:     "migrated 3 tables",
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(taskCommand, []string{"migrate-db"})
+
+			Expect(taskRunner.TaskStatusArgsForCall(0)).To(Equal("migrate-db"))
+
+			Expect(outputBuffer).To(test_helpers.Say("Task Name"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("migrate-db")))
+			Expect(outputBuffer).To(test_helpers.Say("State"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("COMPLETED")))
+			Expect(outputBuffer).To(test_helpers.Say("Image"))
+			Expect(outputBuffer).To(test_helpers.Say("docker:///cool/migrator#v3"))
+			Expect(outputBuffer).To(test_helpers.Say("Cell"))
+			Expect(outputBuffer).To(test_helpers.Say("cell-1"))
+			Expect(outputBuffer).To(test_helpers.Say("Created"))
+			Expect(outputBuffer).To(test_helpers.Say(createdAt.Format(command_factory.TimestampDisplayLayout)))
+			Expect(outputBuffer).To(test_helpers.Say("Annotation"))
+			Expect(outputBuffer).To(test_helpers.Say("nightly migration"))
+			Expect(outputBuffer).To(test_helpers.Say("Result"))
+			Expect(outputBuffer).To(test_helpers.Say("migrated 3 tables"))
+		})
+
+		It("displays the failure reason of failed tasks", func() {
+			taskRunner.TaskStatusReturns(task_runner.TaskInfo{
+				TaskGuid:      "migrate-db",
+				State:         receptor.TaskStateCompleted,
+				Failed:        true,
+				FailureReason: "exit status 1",
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(taskCommand, []string{"migrate-db"})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("FAILED")))
+			Expect(outputBuffer).To(test_helpers.Say("Failure Reason"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("exit status 1")))
+			Expect(outputBuffer).ToNot(test_helpers.Say("Result"))
+		})
+
+		It("outputs errors fetching the task", func() {
+			taskRunner.TaskStatusReturns(task_runner.TaskInfo{}, errors.New("Task migrate-db not found"))
+
+			test_helpers.ExecuteCommandWithArgs(taskCommand, []string{"migrate-db"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Task migrate-db not found"))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(taskCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME required"))
+			Expect(taskRunner.TaskStatusCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("CancelTaskCommand", func() {
+		var cancelTaskCommand cli.Command
+
+		BeforeEach(func() {
+			cancelTaskCommand = commandFactory.MakeCancelTaskCommand()
+		})
+
+		It("cancels the task", func() {
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"migrate-db"})
+
+			Expect(taskRunner.CancelTaskCallCount()).To(Equal(1))
+			Expect(taskRunner.CancelTaskArgsForCall(0)).To(Equal("migrate-db"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Cancelled task migrate-db")))
+		})
+
+		It("outputs errors cancelling the task", func() {
+			taskRunner.CancelTaskReturns(errors.New("Major Fault"))
+
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"migrate-db"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Cancelling Task: Major Fault"))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME required"))
+			Expect(taskRunner.CancelTaskCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("DeleteTaskCommand", func() {
+		var deleteTaskCommand cli.Command
+
+		BeforeEach(func() {
+			deleteTaskCommand = commandFactory.MakeDeleteTaskCommand()
+		})
+
+		It("deletes the task", func() {
+			test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{"migrate-db"})
+
+			Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+			Expect(taskRunner.DeleteTaskArgsForCall(0)).To(Equal("migrate-db"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Deleted task migrate-db")))
+		})
+
+		It("outputs errors deleting the task", func() {
+			taskRunner.DeleteTaskReturns(errors.New("Task migrate-db has not completed. Cancel it before deleting it"))
+
+			test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{"migrate-db"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error Deleting Task: Task migrate-db has not completed. Cancel it before deleting it"))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME required"))
+			Expect(taskRunner.DeleteTaskCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the output format is structured", func() {
+		BeforeEach(func() {
+			cliOutput := output.New(outputBuffer)
+			Expect(cliOutput.SetFormat(output.JSONFormat)).To(Succeed())
+			commandFactory = command_factory.NewTaskRunnerCommandFactory(taskRunner, dockerMetadataFetcher, registryCredentials, cliOutput, []string{})
+		})
+
+		It("lists the tasks as json", func() {
+			taskRunner.ListTasksReturns([]task_runner.TaskInfo{
+				task_runner.TaskInfo{TaskGuid: "migrate-db", State: receptor.TaskStateRunning, CellID: "cell-1"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(commandFactory.MakeListTasksCommand(), []string{})
+
+			var tasks []map[string]interface{}
+			Expect(json.Unmarshal(outputBuffer.Contents(), &tasks)).To(Succeed())
+			Expect(tasks).To(HaveLen(1))
+			Expect(tasks[0]).To(HaveKeyWithValue("task_guid", "migrate-db"))
+			Expect(tasks[0]).To(HaveKeyWithValue("state", "RUNNING"))
+			Expect(tasks[0]).To(HaveKeyWithValue("cell_id", "cell-1"))
+			Expect(tasks[0]).To(HaveKeyWithValue("failed", false))
+		})
+
+		It("shows the task as json", func() {
+			taskRunner.TaskStatusReturns(task_runner.TaskInfo{TaskGuid: "migrate-db", State: receptor.TaskStateCompleted, Result: "done"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(commandFactory.MakeTaskCommand(), []string{"migrate-db"})
+
+			var task map[string]interface{}
+			Expect(json.Unmarshal(outputBuffer.Contents(), &task)).To(Succeed())
+			Expect(task).To(HaveKeyWithValue("task_guid", "migrate-db"))
+			Expect(task).To(HaveKeyWithValue("result", "done"))
+		})
+	})
+})
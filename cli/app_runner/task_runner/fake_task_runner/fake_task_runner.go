@@ -0,0 +1,207 @@
+// This file was generated by counterfeiter
+package fake_task_runner
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner"
+)
+
+type FakeTaskRunner struct {
+	SubmitTaskStub        func(params task_runner.SubmitTaskParams) error
+	submitTaskMutex       sync.RWMutex
+	submitTaskArgsForCall []struct {
+		params task_runner.SubmitTaskParams
+	}
+	submitTaskReturns struct {
+		result1 error
+	}
+	ListTasksStub        func() ([]task_runner.TaskInfo, error)
+	listTasksMutex       sync.RWMutex
+	listTasksArgsForCall []struct{}
+	listTasksReturns     struct {
+		result1 []task_runner.TaskInfo
+		result2 error
+	}
+	TaskStatusStub        func(taskGuid string) (task_runner.TaskInfo, error)
+	taskStatusMutex       sync.RWMutex
+	taskStatusArgsForCall []struct {
+		taskGuid string
+	}
+	taskStatusReturns struct {
+		result1 task_runner.TaskInfo
+		result2 error
+	}
+	CancelTaskStub        func(taskGuid string) error
+	cancelTaskMutex       sync.RWMutex
+	cancelTaskArgsForCall []struct {
+		taskGuid string
+	}
+	cancelTaskReturns struct {
+		result1 error
+	}
+	DeleteTaskStub        func(taskGuid string) error
+	deleteTaskMutex       sync.RWMutex
+	deleteTaskArgsForCall []struct {
+		taskGuid string
+	}
+	deleteTaskReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeTaskRunner) SubmitTask(params task_runner.SubmitTaskParams) error {
+	fake.submitTaskMutex.Lock()
+	fake.submitTaskArgsForCall = append(fake.submitTaskArgsForCall, struct {
+		params task_runner.SubmitTaskParams
+	}{params})
+	fake.submitTaskMutex.Unlock()
+	if fake.SubmitTaskStub != nil {
+		return fake.SubmitTaskStub(params)
+	} else {
+		return fake.submitTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) SubmitTaskCallCount() int {
+	fake.submitTaskMutex.RLock()
+	defer fake.submitTaskMutex.RUnlock()
+	return len(fake.submitTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) SubmitTaskArgsForCall(i int) task_runner.SubmitTaskParams {
+	fake.submitTaskMutex.RLock()
+	defer fake.submitTaskMutex.RUnlock()
+	return fake.submitTaskArgsForCall[i].params
+}
+
+func (fake *FakeTaskRunner) SubmitTaskReturns(result1 error) {
+	fake.SubmitTaskStub = nil
+	fake.submitTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTaskRunner) ListTasks() ([]task_runner.TaskInfo, error) {
+	fake.listTasksMutex.Lock()
+	fake.listTasksArgsForCall = append(fake.listTasksArgsForCall, struct{}{})
+	fake.listTasksMutex.Unlock()
+	if fake.ListTasksStub != nil {
+		return fake.ListTasksStub()
+	} else {
+		return fake.listTasksReturns.result1, fake.listTasksReturns.result2
+	}
+}
+
+func (fake *FakeTaskRunner) ListTasksCallCount() int {
+	fake.listTasksMutex.RLock()
+	defer fake.listTasksMutex.RUnlock()
+	return len(fake.listTasksArgsForCall)
+}
+
+func (fake *FakeTaskRunner) ListTasksReturns(result1 []task_runner.TaskInfo, result2 error) {
+	fake.ListTasksStub = nil
+	fake.listTasksReturns = struct {
+		result1 []task_runner.TaskInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTaskRunner) TaskStatus(taskGuid string) (task_runner.TaskInfo, error) {
+	fake.taskStatusMutex.Lock()
+	fake.taskStatusArgsForCall = append(fake.taskStatusArgsForCall, struct {
+		taskGuid string
+	}{taskGuid})
+	fake.taskStatusMutex.Unlock()
+	if fake.TaskStatusStub != nil {
+		return fake.TaskStatusStub(taskGuid)
+	} else {
+		return fake.taskStatusReturns.result1, fake.taskStatusReturns.result2
+	}
+}
+
+func (fake *FakeTaskRunner) TaskStatusCallCount() int {
+	fake.taskStatusMutex.RLock()
+	defer fake.taskStatusMutex.RUnlock()
+	return len(fake.taskStatusArgsForCall)
+}
+
+func (fake *FakeTaskRunner) TaskStatusArgsForCall(i int) string {
+	fake.taskStatusMutex.RLock()
+	defer fake.taskStatusMutex.RUnlock()
+	return fake.taskStatusArgsForCall[i].taskGuid
+}
+
+func (fake *FakeTaskRunner) TaskStatusReturns(result1 task_runner.TaskInfo, result2 error) {
+	fake.TaskStatusStub = nil
+	fake.taskStatusReturns = struct {
+		result1 task_runner.TaskInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTaskRunner) CancelTask(taskGuid string) error {
+	fake.cancelTaskMutex.Lock()
+	fake.cancelTaskArgsForCall = append(fake.cancelTaskArgsForCall, struct {
+		taskGuid string
+	}{taskGuid})
+	fake.cancelTaskMutex.Unlock()
+	if fake.CancelTaskStub != nil {
+		return fake.CancelTaskStub(taskGuid)
+	} else {
+		return fake.cancelTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) CancelTaskCallCount() int {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	return len(fake.cancelTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) CancelTaskArgsForCall(i int) string {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	return fake.cancelTaskArgsForCall[i].taskGuid
+}
+
+func (fake *FakeTaskRunner) CancelTaskReturns(result1 error) {
+	fake.CancelTaskStub = nil
+	fake.cancelTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTaskRunner) DeleteTask(taskGuid string) error {
+	fake.deleteTaskMutex.Lock()
+	fake.deleteTaskArgsForCall = append(fake.deleteTaskArgsForCall, struct {
+		taskGuid string
+	}{taskGuid})
+	fake.deleteTaskMutex.Unlock()
+	if fake.DeleteTaskStub != nil {
+		return fake.DeleteTaskStub(taskGuid)
+	} else {
+		return fake.deleteTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) DeleteTaskCallCount() int {
+	fake.deleteTaskMutex.RLock()
+	defer fake.deleteTaskMutex.RUnlock()
+	return len(fake.deleteTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) DeleteTaskArgsForCall(i int) string {
+	fake.deleteTaskMutex.RLock()
+	defer fake.deleteTaskMutex.RUnlock()
+	return fake.deleteTaskArgsForCall[i].taskGuid
+}
+
+func (fake *FakeTaskRunner) DeleteTaskReturns(result1 error) {
+	fake.DeleteTaskStub = nil
+	fake.deleteTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ task_runner.TaskRunner = new(FakeTaskRunner)
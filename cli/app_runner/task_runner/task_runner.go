@@ -0,0 +1,175 @@
+package task_runner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_repository_name_formatter"
+)
+
+//go:generate counterfeiter -o fake_task_runner/fake_task_runner.go . TaskRunner
+type TaskRunner interface {
+	SubmitTask(params SubmitTaskParams) error
+	ListTasks() ([]TaskInfo, error)
+	TaskStatus(taskGuid string) (TaskInfo, error)
+	CancelTask(taskGuid string) error
+	DeleteTask(taskGuid string) error
+}
+
+type SubmitTaskParams struct {
+	TaskGuid             string
+	DockerImagePath      string
+	Command              string
+	Args                 []string
+	WorkingDir           string
+	EnvironmentVariables map[string]string
+	Privileged           bool
+	MemoryMB             int
+	DiskMB               int
+	ResultFile           string
+	Annotation           string
+	RegistryUsername     string
+	RegistryPassword     string
+}
+
+type TaskInfo struct {
+	TaskGuid      string `json:"task_guid" yaml:"task_guid"`
+	RootFSPath    string `json:"root_fs" yaml:"root_fs"`
+	State         string `json:"state" yaml:"state"`
+	CellID        string `json:"cell_id" yaml:"cell_id"`
+	CreatedAt     int64  `json:"created_at" yaml:"created_at"`
+	Failed        bool   `json:"failed" yaml:"failed"`
+	FailureReason string `json:"failure_reason" yaml:"failure_reason"`
+	Result        string `json:"result" yaml:"result"`
+	Annotation    string `json:"annotation" yaml:"annotation"`
+}
+
+type taskRunner struct {
+	receptorClient receptor.Client
+}
+
+func New(receptorClient receptor.Client) TaskRunner {
+	return &taskRunner{receptorClient}
+}
+
+func (taskRunner *taskRunner) SubmitTask(params SubmitTaskParams) error {
+	dockerImageUrl, err := docker_repository_name_formatter.FormatForReceptorWithCredentials(params.DockerImagePath, params.RegistryUsername, params.RegistryPassword)
+	if err != nil {
+		return err
+	}
+
+	req := receptor.TaskCreateRequest{
+		TaskGuid:             params.TaskGuid,
+		Domain:               docker_app_runner.DefaultDomain,
+		RootFSPath:           dockerImageUrl,
+		Stack:                docker_app_runner.DefaultStack,
+		MemoryMB:             params.MemoryMB,
+		DiskMB:               params.DiskMB,
+		Privileged:           true,
+		LogGuid:              params.TaskGuid,
+		LogSource:            "TASK",
+		ResultFile:           params.ResultFile,
+		Annotation:           params.Annotation,
+		EnvironmentVariables: buildEnvironmentVariables(params.EnvironmentVariables),
+		Action: &models.RunAction{
+			Path:       params.Command,
+			Args:       params.Args,
+			Privileged: params.Privileged,
+			Dir:        params.WorkingDir,
+		},
+	}
+
+	err = taskRunner.receptorClient.CreateTask(req)
+	if receptorError, ok := err.(receptor.Error); ok && receptorError.Type == receptor.TaskGuidAlreadyExists {
+		return fmt.Errorf("Task %s already exists", params.TaskGuid)
+	}
+
+	return err
+}
+
+func (taskRunner *taskRunner) ListTasks() ([]TaskInfo, error) {
+	tasks, err := taskRunner.receptorClient.TasksByDomain(docker_app_runner.DefaultDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	taskInfos := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		taskInfos = append(taskInfos, taskInfoFromResponse(task))
+	}
+	sort.Sort(taskInfoSortableByGuid(taskInfos))
+
+	return taskInfos, nil
+}
+
+func (taskRunner *taskRunner) TaskStatus(taskGuid string) (TaskInfo, error) {
+	task, err := taskRunner.receptorClient.GetTask(taskGuid)
+	if err != nil {
+		return TaskInfo{}, translateTaskError(taskGuid, err)
+	}
+
+	return taskInfoFromResponse(task), nil
+}
+
+func (taskRunner *taskRunner) CancelTask(taskGuid string) error {
+	return translateTaskError(taskGuid, taskRunner.receptorClient.CancelTask(taskGuid))
+}
+
+func (taskRunner *taskRunner) DeleteTask(taskGuid string) error {
+	return translateTaskError(taskGuid, taskRunner.receptorClient.DeleteTask(taskGuid))
+}
+
+func translateTaskError(taskGuid string, err error) error {
+	receptorError, ok := err.(receptor.Error)
+	if !ok {
+		return err
+	}
+
+	switch receptorError.Type {
+	case receptor.TaskNotFound:
+		return fmt.Errorf("Task %s not found", taskGuid)
+	case receptor.TaskNotDeletable:
+		return fmt.Errorf("Task %s has not completed. Cancel it before deleting it", taskGuid)
+	}
+
+	return err
+}
+
+func taskInfoFromResponse(task receptor.TaskResponse) TaskInfo {
+	return TaskInfo{
+		TaskGuid:      task.TaskGuid,
+		RootFSPath:    task.RootFSPath,
+		State:         task.State,
+		CellID:        task.CellID,
+		CreatedAt:     task.CreatedAt,
+		Failed:        task.Failed,
+		FailureReason: task.FailureReason,
+		Result:        task.Result,
+		Annotation:    task.Annotation,
+	}
+}
+
+func buildEnvironmentVariables(environmentVariables map[string]string) []receptor.EnvironmentVariable {
+	envVars := make([]receptor.EnvironmentVariable, 0, len(environmentVariables))
+	for name, value := range environmentVariables {
+		envVars = append(envVars, receptor.EnvironmentVariable{Name: name, Value: value})
+	}
+	return envVars
+}
+
+type taskInfoSortableByGuid []TaskInfo
+
+func (x taskInfoSortableByGuid) Len() int {
+	return len(x)
+}
+
+func (x taskInfoSortableByGuid) Less(i, j int) bool {
+	return x[i].TaskGuid < x[j].TaskGuid
+}
+
+func (x taskInfoSortableByGuid) Swap(i, j int) {
+	x[i], x[j] = x[j], x[i]
+}
@@ -0,0 +1,114 @@
+package app_watcher
+
+//go:generate counterfeiter -o fake_app_watcher/fake_app_watcher.go . AppWatcher
+
+import (
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+type AppWatcher interface {
+	WatchApps(appName string, stop <-chan struct{}) (<-chan Transition, error)
+}
+
+type appWatcher struct {
+	receptorClient receptor.Client
+}
+
+func New(receptorClient receptor.Client) AppWatcher {
+	return &appWatcher{receptorClient}
+}
+
+func (watcher *appWatcher) WatchApps(appName string, stop <-chan struct{}) (<-chan Transition, error) {
+	eventSource, err := watcher.receptorClient.SubscribeToEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := watcher.fetchModel(appName)
+	if err != nil {
+		eventSource.Close()
+		return nil, err
+	}
+
+	transitions := make(chan Transition)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-stop:
+		case <-done:
+		}
+		eventSource.Close()
+	}()
+
+	go func() {
+		defer close(transitions)
+		defer close(done)
+
+		snapshot := model.ProcessGuids()
+		if appName != "" {
+			snapshot = []string{appName}
+		}
+
+		for _, processGuid := range snapshot {
+			select {
+			case transitions <- model.Snapshot(processGuid):
+			case <-stop:
+				return
+			}
+		}
+
+		for {
+			event, err := eventSource.Next()
+			if err != nil {
+				return
+			}
+
+			if appName != "" && processGuidForEvent(event) != appName {
+				continue
+			}
+
+			transition, ok := model.Apply(event)
+			if !ok {
+				continue
+			}
+
+			select {
+			case transitions <- transition:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return transitions, nil
+}
+
+func (watcher *appWatcher) fetchModel(appName string) (*Model, error) {
+	desiredLRPs, err := watcher.receptorClient.DesiredLRPs()
+	if err != nil {
+		return nil, err
+	}
+
+	if appName == "" {
+		actualLRPs, err := watcher.receptorClient.ActualLRPs()
+		if err != nil {
+			return nil, err
+		}
+		return NewModel(desiredLRPs, actualLRPs), nil
+	}
+
+	actualLRPs, err := watcher.receptorClient.ActualLRPsByProcessGuid(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	filteredDesiredLRPs := []receptor.DesiredLRPResponse{}
+	for _, desiredLRP := range desiredLRPs {
+		if desiredLRP.ProcessGuid == appName {
+			filteredDesiredLRPs = append(filteredDesiredLRPs, desiredLRP)
+		}
+	}
+
+	return NewModel(filteredDesiredLRPs, actualLRPs), nil
+}
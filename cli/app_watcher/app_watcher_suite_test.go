@@ -0,0 +1,13 @@
+package app_watcher_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAppWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppWatcher Suite")
+}
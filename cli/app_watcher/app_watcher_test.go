@@ -0,0 +1,155 @@
+package app_watcher_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+)
+
+var _ = Describe("AppWatcher", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeEventSource    *fake_receptor.FakeEventSource
+		events             chan receptor.Event
+		stop               chan struct{}
+		appWatcher         app_watcher.AppWatcher
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		fakeEventSource = &fake_receptor.FakeEventSource{}
+		events = make(chan receptor.Event, 10)
+		stop = make(chan struct{})
+
+		eventChan := events
+		closeOnce := &sync.Once{}
+		fakeEventSource.NextStub = func() (receptor.Event, error) {
+			event, ok := <-eventChan
+			if !ok {
+				return nil, receptor.ErrReadFromClosedSource
+			}
+			return event, nil
+		}
+		fakeEventSource.CloseStub = func() error {
+			closeOnce.Do(func() { close(eventChan) })
+			return nil
+		}
+
+		fakeReceptorClient.SubscribeToEventsReturns(fakeEventSource, nil)
+		appWatcher = app_watcher.New(fakeReceptorClient)
+	})
+
+	Describe("WatchApps", func() {
+		Context("when watching a single app", func() {
+			BeforeEach(func() {
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 1},
+					receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 3},
+				}, nil)
+				fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+					receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 0, State: receptor.ActualLRPStateClaimed},
+				}, nil)
+			})
+
+			It("emits a snapshot of the app followed by its transitions", func() {
+				transitions, err := appWatcher.WatchApps("americano-app", stop)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(0)).To(Equal("americano-app"))
+
+				var transition app_watcher.Transition
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition).To(Equal(app_watcher.Transition{
+					Type:        app_watcher.AppSnapshot,
+					ProcessGuid: "americano-app",
+					App:         app_watcher.AppState{Desired: true, DesiredInstances: 1, ActualInstances: 1},
+				}))
+
+				events <- receptor.NewDesiredLRPChangedEvent(receptor.DesiredLRPResponse{ProcessGuid: "latte-app"}, receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 4})
+				events <- receptor.NewActualLRPChangedEvent(
+					receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 0, State: receptor.ActualLRPStateClaimed},
+					receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 0, State: receptor.ActualLRPStateRunning},
+				)
+
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition.ProcessGuid).To(Equal("americano-app"))
+				Expect(transition.Type).To(Equal(app_watcher.InstanceChanged))
+				Expect(transition.App.RunningInstances).To(Equal(1))
+
+				close(stop)
+				Eventually(transitions).Should(BeClosed())
+				Expect(fakeEventSource.CloseCallCount()).To(BeNumerically(">=", 1))
+			})
+
+			It("emits a snapshot even if the app does not exist yet", func() {
+				transitions, err := appWatcher.WatchApps("mocha-app", stop)
+				Expect(err).ToNot(HaveOccurred())
+
+				var transition app_watcher.Transition
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition).To(Equal(app_watcher.Transition{Type: app_watcher.AppSnapshot, ProcessGuid: "mocha-app"}))
+
+				close(stop)
+			})
+		})
+
+		Context("when watching all apps", func() {
+			It("emits a snapshot for every app", func() {
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 3},
+				}, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+					receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 0, State: receptor.ActualLRPStateRunning},
+				}, nil)
+
+				transitions, err := appWatcher.WatchApps("", stop)
+				Expect(err).ToNot(HaveOccurred())
+
+				var transition app_watcher.Transition
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition.ProcessGuid).To(Equal("americano-app"))
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition.ProcessGuid).To(Equal("latte-app"))
+
+				events <- receptor.NewDesiredLRPRemovedEvent(receptor.DesiredLRPResponse{ProcessGuid: "latte-app"})
+
+				Eventually(transitions).Should(Receive(&transition))
+				Expect(transition.Type).To(Equal(app_watcher.AppRemoved))
+
+				close(stop)
+			})
+		})
+
+		It("closes the transitions when the event stream ends", func() {
+			transitions, err := appWatcher.WatchApps("americano-app", stop)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(transitions).Should(Receive())
+			fakeEventSource.Close()
+
+			Eventually(transitions).Should(BeClosed())
+		})
+
+		It("returns an error when subscribing to events fails", func() {
+			fakeReceptorClient.SubscribeToEventsReturns(nil, errors.New("no events for you"))
+
+			_, err := appWatcher.WatchApps("americano-app", stop)
+
+			Expect(err).To(MatchError("no events for you"))
+		})
+
+		It("returns an error and closes the event source when fetching the current state fails", func() {
+			fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("receptor is down"))
+
+			_, err := appWatcher.WatchApps("americano-app", stop)
+
+			Expect(err).To(MatchError("receptor is down"))
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,116 @@
+package command_factory
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner/command_factory/presentation"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-golang/clock"
+)
+
+const TimeDisplayLayout = "15:04:05"
+
+type AppWatcherCommandFactory struct {
+	appWatcherCommand *appWatcherCommand
+}
+
+func NewAppWatcherCommandFactory(appWatcher app_watcher.AppWatcher, output *output.Output, clock clock.Clock, exitHandler exit_handler.ExitHandler) *AppWatcherCommandFactory {
+	return &AppWatcherCommandFactory{&appWatcherCommand{appWatcher, output, clock, exitHandler}}
+}
+
+func (commandFactory *AppWatcherCommandFactory) MakeWatchCommand() cli.Command {
+
+	var watchCommand = cli.Command{
+		Name:        "watch",
+		ShortName:   "wa",
+		Description: "Print app and instance state transitions as they happen",
+		Usage:       "ltc watch [APP_NAME]",
+		Action:      commandFactory.appWatcherCommand.watchApps,
+		Flags:       []cli.Flag{},
+	}
+
+	return watchCommand
+}
+
+type appWatcherCommand struct {
+	appWatcher  app_watcher.AppWatcher
+	output      *output.Output
+	clock       clock.Clock
+	exitHandler exit_handler.ExitHandler
+}
+
+func (cmd *appWatcherCommand) watchApps(context *cli.Context) {
+	appName := context.Args().First()
+
+	stop := make(chan struct{})
+	cmd.exitHandler.OnExit(func() { close(stop) })
+
+	transitions, err := cmd.appWatcher.WatchApps(appName, stop)
+	if err != nil {
+		cmd.output.Say("Error watching apps: " + err.Error())
+		return
+	}
+
+	for transition := range transitions {
+		if cmd.output.IsStructured() {
+			if err := cmd.output.SayFormatted(transition); err != nil {
+				cmd.output.Say("Error formatting output: " + err.Error())
+			}
+			continue
+		}
+
+		cmd.output.SayLine(fmt.Sprintf("%s %s", cmd.clock.Now().Format(TimeDisplayLayout), describeTransition(transition)))
+	}
+
+	select {
+	case <-stop:
+	default:
+		cmd.output.SayLine(colors.Red("Lost connection to the event stream."))
+	}
+}
+
+func describeTransition(transition app_watcher.Transition) string {
+	appName := colors.Bold(transition.ProcessGuid)
+	instanceName := fmt.Sprintf("%s[%d]", appName, transition.Index)
+
+	switch transition.Type {
+	case app_watcher.AppSnapshot:
+		if !transition.App.Desired && transition.App.ActualInstances == 0 {
+			return fmt.Sprintf("%s: not running", appName)
+		}
+		return fmt.Sprintf("%s: %s instances running", appName, colorInstances(transition.App))
+	case app_watcher.AppCreated:
+		return fmt.Sprintf("%s: created with %d instances", appName, transition.App.DesiredInstances)
+	case app_watcher.AppChanged:
+		return fmt.Sprintf("%s: updated, %s instances running", appName, colorInstances(transition.App))
+	case app_watcher.AppRemoved:
+		return fmt.Sprintf("%s: removed", appName)
+	case app_watcher.InstanceCreated:
+		return fmt.Sprintf("%s: %s", instanceName, colorState(transition.ToState))
+	case app_watcher.InstanceChanged:
+		return fmt.Sprintf("%s: %s -> %s", instanceName, colorState(transition.FromState), colorState(transition.ToState))
+	case app_watcher.InstanceRemoved:
+		return fmt.Sprintf("%s: removed", instanceName)
+	}
+
+	return appName
+}
+
+func colorState(state string) string {
+	return presentation.ColorInstanceState(app_examiner.InstanceInfo{State: state})
+}
+
+func colorInstances(appState app_watcher.AppState) string {
+	instances := fmt.Sprintf("%d/%d", appState.RunningInstances, appState.DesiredInstances)
+	if appState.RunningInstances == appState.DesiredInstances {
+		return colors.Green(instances)
+	} else if appState.RunningInstances == 0 {
+		return colors.Red(instances)
+	}
+	return colors.Yellow(instances)
+}
@@ -0,0 +1,133 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codegangsta/cli"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher/command_factory"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher/fake_app_watcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/fake_exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("CommandFactory", func() {
+	var (
+		appWatcher   *fake_app_watcher.FakeAppWatcher
+		outputBuffer *gbytes.Buffer
+		clock        *fakeclock.FakeClock
+		exitHandler  *fake_exit_handler.FakeExitHandler
+		transitions  chan app_watcher.Transition
+		watchCommand cli.Command
+	)
+
+	BeforeEach(func() {
+		appWatcher = &fake_app_watcher.FakeAppWatcher{}
+		outputBuffer = gbytes.NewBuffer()
+		clock = fakeclock.NewFakeClock(time.Date(2015, 3, 4, 13, 14, 15, 0, time.UTC))
+		exitHandler = &fake_exit_handler.FakeExitHandler{}
+		transitions = make(chan app_watcher.Transition)
+
+		appWatcher.WatchAppsStub = func(appName string, stop <-chan struct{}) (<-chan app_watcher.Transition, error) {
+			go func() {
+				<-stop
+				close(transitions)
+			}()
+			return transitions, nil
+		}
+
+		commandFactory := command_factory.NewAppWatcherCommandFactory(appWatcher, output.New(outputBuffer), clock, exitHandler)
+		watchCommand = commandFactory.MakeWatchCommand()
+	})
+
+	Describe("WatchCommand", func() {
+		It("prints app and instance transitions until the user exits", func() {
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(watchCommand, []string{"americano-app"})
+
+			Eventually(appWatcher.WatchAppsCallCount).Should(Equal(1))
+			appName, _ := appWatcher.WatchAppsArgsForCall(0)
+			Expect(appName).To(Equal("americano-app"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppSnapshot, ProcessGuid: "americano-app", App: app_watcher.AppState{Desired: true, DesiredInstances: 2, ActualInstances: 2, RunningInstances: 1}}
+			Eventually(outputBuffer).Should(test_helpers.Say("13:14:15 " + colors.Bold("americano-app") + ": " + colors.Yellow("1/2") + " instances running\n"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, ProcessGuid: "americano-app", Index: 1, FromState: "CLAIMED", ToState: "RUNNING"}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("americano-app") + "[1]: " + colors.Yellow("CLAIMED") + " -> " + colors.Green("RUNNING") + "\n"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceCreated, ProcessGuid: "americano-app", Index: 2, ToState: "UNCLAIMED"}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("americano-app") + "[2]: " + colors.Cyan("UNCLAIMED") + "\n"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppChanged, ProcessGuid: "americano-app", App: app_watcher.AppState{Desired: true, DesiredInstances: 3, RunningInstances: 3}}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("americano-app") + ": updated, " + colors.Green("3/3") + " instances running\n"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceRemoved, ProcessGuid: "americano-app", Index: 2}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("americano-app") + "[2]: removed\n"))
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppRemoved, ProcessGuid: "americano-app"}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("americano-app") + ": removed\n"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).ToNot(test_helpers.Say("Lost connection"))
+		})
+
+		It("watches every app when no app name is given", func() {
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(watchCommand, []string{})
+
+			transitions <- app_watcher.Transition{Type: app_watcher.AppCreated, ProcessGuid: "latte-app", App: app_watcher.AppState{Desired: true, DesiredInstances: 4}}
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("latte-app") + ": created with 4 instances\n"))
+
+			appName, _ := appWatcher.WatchAppsArgsForCall(0)
+			Expect(appName).To(BeEmpty())
+
+			exitHandler.Exit(exit_codes.SigInt)
+			Eventually(commandFinishChan).Should(BeClosed())
+		})
+
+		It("tells the user when the event stream goes away", func() {
+			appWatcher.WatchAppsStub = nil
+			appWatcher.WatchAppsReturns(transitions, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(watchCommand, []string{})
+			Eventually(appWatcher.WatchAppsCallCount).Should(Equal(1))
+			close(transitions)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("Lost connection to the event stream.")))
+		})
+
+		It("prints structured transitions when an output format is set", func() {
+			structuredOutput := output.New(outputBuffer)
+			Expect(structuredOutput.SetFormat(output.JSONFormat)).To(Succeed())
+			watchCommand = command_factory.NewAppWatcherCommandFactory(appWatcher, structuredOutput, clock, exitHandler).MakeWatchCommand()
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(watchCommand, []string{"americano-app"})
+
+			transitions <- app_watcher.Transition{Type: app_watcher.InstanceChanged, ProcessGuid: "americano-app", Index: 0, FromState: "CLAIMED", ToState: "RUNNING"}
+			Eventually(outputBuffer).Should(test_helpers.Say(`"type": "instance_changed"`))
+			Eventually(outputBuffer).Should(test_helpers.Say(`"to_state": "RUNNING"`))
+
+			exitHandler.Exit(exit_codes.SigInt)
+			Eventually(commandFinishChan).Should(BeClosed())
+		})
+
+		It("alerts the user if the app watcher cannot subscribe to events", func() {
+			appWatcher.WatchAppsStub = nil
+			appWatcher.WatchAppsReturns(nil, errors.New("no events for you"))
+
+			test_helpers.ExecuteCommandWithArgs(watchCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error watching apps: no events for you"))
+		})
+	})
+})
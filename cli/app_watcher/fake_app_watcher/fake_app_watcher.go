@@ -0,0 +1,57 @@
+// This file was generated by counterfeiter
+package fake_app_watcher
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+)
+
+type FakeAppWatcher struct {
+	WatchAppsStub        func(appName string, stop <-chan struct{}) (<-chan app_watcher.Transition, error)
+	watchAppsMutex       sync.RWMutex
+	watchAppsArgsForCall []struct {
+		appName string
+		stop    <-chan struct{}
+	}
+	watchAppsReturns struct {
+		result1 <-chan app_watcher.Transition
+		result2 error
+	}
+}
+
+func (fake *FakeAppWatcher) WatchApps(appName string, stop <-chan struct{}) (<-chan app_watcher.Transition, error) {
+	fake.watchAppsMutex.Lock()
+	fake.watchAppsArgsForCall = append(fake.watchAppsArgsForCall, struct {
+		appName string
+		stop    <-chan struct{}
+	}{appName, stop})
+	fake.watchAppsMutex.Unlock()
+	if fake.WatchAppsStub != nil {
+		return fake.WatchAppsStub(appName, stop)
+	} else {
+		return fake.watchAppsReturns.result1, fake.watchAppsReturns.result2
+	}
+}
+
+func (fake *FakeAppWatcher) WatchAppsCallCount() int {
+	fake.watchAppsMutex.RLock()
+	defer fake.watchAppsMutex.RUnlock()
+	return len(fake.watchAppsArgsForCall)
+}
+
+func (fake *FakeAppWatcher) WatchAppsArgsForCall(i int) (string, <-chan struct{}) {
+	fake.watchAppsMutex.RLock()
+	defer fake.watchAppsMutex.RUnlock()
+	return fake.watchAppsArgsForCall[i].appName, fake.watchAppsArgsForCall[i].stop
+}
+
+func (fake *FakeAppWatcher) WatchAppsReturns(result1 <-chan app_watcher.Transition, result2 error) {
+	fake.WatchAppsStub = nil
+	fake.watchAppsReturns = struct {
+		result1 <-chan app_watcher.Transition
+		result2 error
+	}{result1, result2}
+}
+
+var _ app_watcher.AppWatcher = new(FakeAppWatcher)
@@ -0,0 +1,167 @@
+package app_watcher
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+type TransitionType string
+
+const (
+	AppSnapshot     TransitionType = "snapshot"
+	AppCreated      TransitionType = "app_created"
+	AppChanged      TransitionType = "app_changed"
+	AppRemoved      TransitionType = "app_removed"
+	InstanceCreated TransitionType = "instance_created"
+	InstanceChanged TransitionType = "instance_changed"
+	InstanceRemoved TransitionType = "instance_removed"
+)
+
+type AppState struct {
+	Desired          bool `json:"desired" yaml:"desired"`
+	DesiredInstances int  `json:"desired_instances" yaml:"desired_instances"`
+	ActualInstances  int  `json:"actual_instances" yaml:"actual_instances"`
+	RunningInstances int  `json:"running_instances" yaml:"running_instances"`
+}
+
+type Transition struct {
+	Type        TransitionType `json:"type" yaml:"type"`
+	ProcessGuid string         `json:"process_guid" yaml:"process_guid"`
+	Index       int            `json:"index" yaml:"index"`
+	FromState   string         `json:"from_state,omitempty" yaml:"from_state,omitempty"`
+	ToState     string         `json:"to_state,omitempty" yaml:"to_state,omitempty"`
+	App         AppState       `json:"app" yaml:"app"`
+}
+
+type Model struct {
+	desiredLRPs map[string]receptor.DesiredLRPResponse
+	actualLRPs  map[string]map[int]receptor.ActualLRPResponse
+}
+
+func NewModel(desiredLRPs []receptor.DesiredLRPResponse, actualLRPs []receptor.ActualLRPResponse) *Model {
+	model := &Model{
+		desiredLRPs: make(map[string]receptor.DesiredLRPResponse),
+		actualLRPs:  make(map[string]map[int]receptor.ActualLRPResponse),
+	}
+
+	for _, desiredLRP := range desiredLRPs {
+		model.desiredLRPs[desiredLRP.ProcessGuid] = desiredLRP
+	}
+	for _, actualLRP := range actualLRPs {
+		model.setActualLRP(actualLRP)
+	}
+
+	return model
+}
+
+func (m *Model) AppState(processGuid string) AppState {
+	state := AppState{}
+
+	if desiredLRP, ok := m.desiredLRPs[processGuid]; ok {
+		state.Desired = true
+		state.DesiredInstances = desiredLRP.Instances
+	}
+
+	for _, actualLRP := range m.actualLRPs[processGuid] {
+		state.ActualInstances++
+		if actualLRP.State == receptor.ActualLRPStateRunning {
+			state.RunningInstances++
+		}
+	}
+
+	return state
+}
+
+func (m *Model) ProcessGuids() []string {
+	seen := make(map[string]bool)
+	processGuids := []string{}
+
+	for processGuid := range m.desiredLRPs {
+		seen[processGuid] = true
+		processGuids = append(processGuids, processGuid)
+	}
+	for processGuid := range m.actualLRPs {
+		if !seen[processGuid] {
+			processGuids = append(processGuids, processGuid)
+		}
+	}
+
+	sort.Strings(processGuids)
+	return processGuids
+}
+
+func (m *Model) Snapshot(processGuid string) Transition {
+	return Transition{
+		Type:        AppSnapshot,
+		ProcessGuid: processGuid,
+		App:         m.AppState(processGuid),
+	}
+}
+
+func (m *Model) Apply(event receptor.Event) (Transition, bool) {
+	var transition Transition
+
+	switch event := event.(type) {
+	case receptor.DesiredLRPCreatedEvent:
+		m.desiredLRPs[event.DesiredLRPResponse.ProcessGuid] = event.DesiredLRPResponse
+		transition = Transition{Type: AppCreated, ProcessGuid: event.DesiredLRPResponse.ProcessGuid}
+	case receptor.DesiredLRPChangedEvent:
+		m.desiredLRPs[event.After.ProcessGuid] = event.After
+		transition = Transition{Type: AppChanged, ProcessGuid: event.After.ProcessGuid}
+	case receptor.DesiredLRPRemovedEvent:
+		delete(m.desiredLRPs, event.DesiredLRPResponse.ProcessGuid)
+		transition = Transition{Type: AppRemoved, ProcessGuid: event.DesiredLRPResponse.ProcessGuid}
+	case receptor.ActualLRPCreatedEvent:
+		actualLRP := event.ActualLRPResponse
+		m.setActualLRP(actualLRP)
+		transition = Transition{Type: InstanceCreated, ProcessGuid: actualLRP.ProcessGuid, Index: actualLRP.Index, ToState: string(actualLRP.State)}
+	case receptor.ActualLRPChangedEvent:
+		m.setActualLRP(event.After)
+		transition = Transition{Type: InstanceChanged, ProcessGuid: event.After.ProcessGuid, Index: event.After.Index, FromState: string(event.Before.State), ToState: string(event.After.State)}
+	case receptor.ActualLRPRemovedEvent:
+		actualLRP := event.ActualLRPResponse
+		m.removeActualLRP(actualLRP)
+		transition = Transition{Type: InstanceRemoved, ProcessGuid: actualLRP.ProcessGuid, Index: actualLRP.Index, FromState: string(actualLRP.State)}
+	default:
+		return Transition{}, false
+	}
+
+	transition.App = m.AppState(transition.ProcessGuid)
+	return transition, true
+}
+
+func (m *Model) setActualLRP(actualLRP receptor.ActualLRPResponse) {
+	instances, ok := m.actualLRPs[actualLRP.ProcessGuid]
+	if !ok {
+		instances = make(map[int]receptor.ActualLRPResponse)
+		m.actualLRPs[actualLRP.ProcessGuid] = instances
+	}
+	instances[actualLRP.Index] = actualLRP
+}
+
+func (m *Model) removeActualLRP(actualLRP receptor.ActualLRPResponse) {
+	instances := m.actualLRPs[actualLRP.ProcessGuid]
+	delete(instances, actualLRP.Index)
+	if len(instances) == 0 {
+		delete(m.actualLRPs, actualLRP.ProcessGuid)
+	}
+}
+
+func processGuidForEvent(event receptor.Event) string {
+	switch event := event.(type) {
+	case receptor.DesiredLRPCreatedEvent:
+		return event.DesiredLRPResponse.ProcessGuid
+	case receptor.DesiredLRPChangedEvent:
+		return event.After.ProcessGuid
+	case receptor.DesiredLRPRemovedEvent:
+		return event.DesiredLRPResponse.ProcessGuid
+	case receptor.ActualLRPCreatedEvent:
+		return event.ActualLRPResponse.ProcessGuid
+	case receptor.ActualLRPChangedEvent:
+		return event.After.ProcessGuid
+	case receptor.ActualLRPRemovedEvent:
+		return event.ActualLRPResponse.ProcessGuid
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package app_watcher_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
+)
+
+var _ = Describe("Model", func() {
+	var model *app_watcher.Model
+
+	BeforeEach(func() {
+		model = app_watcher.NewModel(
+			[]receptor.DesiredLRPResponse{
+				receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 2},
+			},
+			[]receptor.ActualLRPResponse{
+				receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 0, State: receptor.ActualLRPStateRunning},
+				receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 1, State: receptor.ActualLRPStateClaimed},
+				receptor.ActualLRPResponse{ProcessGuid: "orphaned-app", Index: 0, State: receptor.ActualLRPStateRunning},
+			},
+		)
+	})
+
+	Describe("AppState", func() {
+		It("summarizes the desired and actual state of an app", func() {
+			Expect(model.AppState("americano-app")).To(Equal(app_watcher.AppState{
+				Desired:          true,
+				DesiredInstances: 2,
+				ActualInstances:  2,
+				RunningInstances: 1,
+			}))
+			Expect(model.AppState("orphaned-app")).To(Equal(app_watcher.AppState{ActualInstances: 1, RunningInstances: 1}))
+			Expect(model.AppState("unknown-app")).To(Equal(app_watcher.AppState{}))
+		})
+	})
+
+	Describe("ProcessGuids", func() {
+		It("returns the sorted process guids of desired and actual lrps", func() {
+			Expect(model.ProcessGuids()).To(Equal([]string{"americano-app", "orphaned-app"}))
+		})
+	})
+
+	Describe("Snapshot", func() {
+		It("returns a snapshot transition with the current app state", func() {
+			Expect(model.Snapshot("americano-app")).To(Equal(app_watcher.Transition{
+				Type:        app_watcher.AppSnapshot,
+				ProcessGuid: "americano-app",
+				App:         model.AppState("americano-app"),
+			}))
+		})
+	})
+
+	Describe("Apply", func() {
+		It("tracks desired lrps being created, changed and removed", func() {
+			transition, ok := model.Apply(receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 1}))
+			Expect(ok).To(BeTrue())
+			Expect(transition.Type).To(Equal(app_watcher.AppCreated))
+			Expect(transition.ProcessGuid).To(Equal("latte-app"))
+			Expect(transition.App).To(Equal(app_watcher.AppState{Desired: true, DesiredInstances: 1}))
+
+			transition, ok = model.Apply(receptor.NewDesiredLRPChangedEvent(
+				receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 1},
+				receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 4},
+			))
+			Expect(ok).To(BeTrue())
+			Expect(transition.Type).To(Equal(app_watcher.AppChanged))
+			Expect(transition.App.DesiredInstances).To(Equal(4))
+
+			transition, ok = model.Apply(receptor.NewDesiredLRPRemovedEvent(receptor.DesiredLRPResponse{ProcessGuid: "latte-app", Instances: 4}))
+			Expect(ok).To(BeTrue())
+			Expect(transition.Type).To(Equal(app_watcher.AppRemoved))
+			Expect(transition.App).To(Equal(app_watcher.AppState{}))
+		})
+
+		It("tracks actual lrps moving between states", func() {
+			transition, ok := model.Apply(receptor.NewActualLRPChangedEvent(
+				receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 1, State: receptor.ActualLRPStateClaimed},
+				receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 1, State: receptor.ActualLRPStateRunning},
+			))
+			Expect(ok).To(BeTrue())
+			Expect(transition).To(Equal(app_watcher.Transition{
+				Type:        app_watcher.InstanceChanged,
+				ProcessGuid: "americano-app",
+				Index:       1,
+				FromState:   "CLAIMED",
+				ToState:     "RUNNING",
+				App:         app_watcher.AppState{Desired: true, DesiredInstances: 2, ActualInstances: 2, RunningInstances: 2},
+			}))
+
+			transition, ok = model.Apply(receptor.NewActualLRPCreatedEvent(receptor.ActualLRPResponse{ProcessGuid: "americano-app", Index: 2, State: receptor.ActualLRPStateUnclaimed}))
+			Expect(ok).To(BeTrue())
+			Expect(transition.Type).To(Equal(app_watcher.InstanceCreated))
+			Expect(transition.Index).To(Equal(2))
+			Expect(transition.ToState).To(Equal("UNCLAIMED"))
+			Expect(transition.App.ActualInstances).To(Equal(3))
+
+			transition, ok = model.Apply(receptor.NewActualLRPRemovedEvent(receptor.ActualLRPResponse{ProcessGuid: "orphaned-app", Index: 0, State: receptor.ActualLRPStateRunning}))
+			Expect(ok).To(BeTrue())
+			Expect(transition.Type).To(Equal(app_watcher.InstanceRemoved))
+			Expect(transition.FromState).To(Equal("RUNNING"))
+			Expect(transition.App).To(Equal(app_watcher.AppState{}))
+			Expect(model.ProcessGuids()).To(Equal([]string{"americano-app"}))
+		})
+	})
+})
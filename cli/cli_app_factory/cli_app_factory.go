@@ -2,7 +2,9 @@ package cli_app_factory
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"time"
@@ -13,8 +15,13 @@ import (
 	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_app_runner"
 	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/docker_metadata_fetcher"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_watcher"
 	"github.com/pivotal-cf-experimental/lattice-cli/config"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/config_helpers"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/target_verifier"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
 	"github.com/pivotal-cf-experimental/lattice-cli/integration_test"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs"
@@ -25,14 +32,45 @@ import (
 
 	app_examiner_command_factory "github.com/pivotal-cf-experimental/lattice-cli/app_examiner/command_factory"
 	app_runner_command_factory "github.com/pivotal-cf-experimental/lattice-cli/app_runner/command_factory"
+	task_runner_command_factory "github.com/pivotal-cf-experimental/lattice-cli/app_runner/task_runner/command_factory"
+	app_watcher_command_factory "github.com/pivotal-cf-experimental/lattice-cli/app_watcher/command_factory"
 	config_command_factory "github.com/pivotal-cf-experimental/lattice-cli/config/command_factory"
+	doctor_command_factory "github.com/pivotal-cf-experimental/lattice-cli/doctor/command_factory"
 	integration_test_command_factory "github.com/pivotal-cf-experimental/lattice-cli/integration_test/command_factory"
 	logs_command_factory "github.com/pivotal-cf-experimental/lattice-cli/logs/command_factory"
 )
 
 var nonTargetVerifiedCommandNames = map[string]struct{}{
-	config_command_factory.TargetCommandName: {},
+	config_command_factory.TargetCommandName:        {},
+	config_command_factory.TargetsCommandName:       {},
+	config_command_factory.RegistryLoginCommandName: {},
+	doctor_command_factory.DoctorCommandName:        {},
+	"help":                                          {},
+}
+
+// credentialFreeCommandNames never talk to the receptor, so ltc does not load the
+// password of the target for them, which may mean prompting for a passphrase.
+var credentialFreeCommandNames = map[string]struct{}{
+	config_command_factory.TargetCommandName:        {},
+	config_command_factory.TargetShortName:          {},
+	config_command_factory.TargetsCommandName:       {},
+	config_command_factory.RegistryLoginCommandName: {},
 	"help": {},
+	"h":    {},
+}
+
+// structuredOutputCommandNames are the commands that print json or yaml with --output.
+var structuredOutputCommandNames = map[string]struct{}{
+	"list":                                   {},
+	"status":                                 {},
+	"visualize":                              {},
+	"cells":                                  {},
+	"cell":                                   {},
+	"tasks":                                  {},
+	"task":                                   {},
+	"diff":                                   {},
+	"watch":                                  {},
+	doctor_command_factory.DoctorCommandName: {},
 }
 
 const (
@@ -42,15 +80,52 @@ const (
 	latticeCliHomeVar = "LATTICE_CLI_HOME"
 )
 
-func MakeCliApp(timeoutStr, ltcConfigRoot string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, targetVerifier target_verifier.TargetVerifier, output *output.Output) *cli.App {
-	config.Load()
+func MakeCliApp(timeoutStr, ltcConfigRoot string, args []string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, targetVerifier target_verifier.TargetVerifier, output *output.Output) *cli.App {
+	loadErr := config.Load()
 	app := cli.NewApp()
 	app.Name = AppName
 	app.Author = "Pivotal"
 	app.Usage = LtcUsage
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "output format for list, status, visualize, cells, cell, tasks, task, diff, watch and doctor: text, json or yaml",
+			Value: "text",
+		},
+		cli.StringFlag{
+			Name:  "target, t",
+			Usage: "named target to use for this command instead of the active one",
+		},
+	}
+
+	globalFlags := parseGlobalFlags(app.Flags, args)
+	targetErr := overrideTarget(config, globalFlags)
+
+	var credentialsErr error
+	if commandName := globalFlags.Arg(0); loadErr == nil && targetErr == nil && commandName != "" {
+		if _, ok := credentialFreeCommandNames[commandName]; !ok {
+			credentialsErr = config.LoadCredentials()
+		}
+	}
+
 	app.Commands = cliCommands(timeoutStr, ltcConfigRoot, exitHandler, config, logger, targetVerifier, output)
 
 	app.Before = func(context *cli.Context) error {
+		if err := output.SetFormat(context.String("output")); err != nil {
+			output.Say(err.Error())
+			return err
+		}
+
+		if targetErr != nil {
+			output.Say(targetErr.Error())
+			return targetErr
+		}
+
+		if credentialsErr != nil {
+			output.Say("Error loading the credentials of the target: " + credentialsErr.Error())
+			return credentialsErr
+		}
+
 		args := context.Args()
 		command := app.Command(args.First())
 
@@ -58,10 +133,21 @@ func MakeCliApp(timeoutStr, ltcConfigRoot string, exitHandler exit_handler.ExitH
 			return nil
 		}
 
+		if _, ok := structuredOutputCommandNames[command.Name]; !ok && output.IsStructured() {
+			err := fmt.Errorf("ltc %s does not support --output %s", command.Name, context.String("output"))
+			output.Say(err.Error())
+			return err
+		}
+
 		if _, ok := nonTargetVerifiedCommandNames[command.Name]; ok || len(args) == 0 {
 			return nil
 		}
 
+		if loadErr != nil {
+			output.Say("Error loading the config: " + loadErr.Error())
+			return loadErr
+		}
+
 		if receptorUp, authorized, err := targetVerifier.VerifyTarget(config.Receptor()); !receptorUp {
 			output.Say(fmt.Sprintf("Error connecting to the receptor. Make sure your lattice target is set, and that lattice is up and running.\n\tUnderlying error: %s", err.Error()))
 			return err
@@ -78,17 +164,25 @@ func MakeCliApp(timeoutStr, ltcConfigRoot string, exitHandler exit_handler.ExitH
 func cliCommands(timeoutStr, ltcConfigRoot string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, targetVerifier target_verifier.TargetVerifier, output *output.Output) []cli.Command {
 	input := os.Stdin
 
+	clock := clock.NewClock()
+
 	receptorClient := receptor.NewClient(config.Receptor())
-	appRunner := docker_app_runner.New(receptorClient, config.Target())
+	appRunner := docker_app_runner.New(receptorClient, config.Target(), config.FileServer(), clock)
+	appWatcher := app_watcher.New(receptorClient)
 
-	clock := clock.NewClock()
+	registryCredentials := registry_credentials.New(config.CredentialStore(), config_helpers.DockerConfigFileLocations(os.Getenv("HOME")))
+
+	logReaderFactory := func() logs.LogReader {
+		return logs.NewLogReader(noaa.NewConsumer(LoggregatorUrl(config.Loggregator()), nil, nil))
+	}
+	tailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output, logReaderFactory)
 
-	logReader := logs.NewLogReader(noaa.NewConsumer(LoggregatorUrl(config.Loggregator()), nil, nil))
-	tailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output, logReader)
+	appExaminer := app_examiner.New(receptorClient)
 
 	appRunnerCommandFactoryConfig := app_runner_command_factory.AppRunnerCommandFactoryConfig{
 		AppRunner:             appRunner,
-		DockerMetadataFetcher: docker_metadata_fetcher.New(docker_metadata_fetcher.NewDockerSessionFactory()),
+		AppWatcher:            appWatcher,
+		DockerMetadataFetcher: docker_metadata_fetcher.New(docker_metadata_fetcher.NewDockerSessionFactory(registryCredentials), docker_metadata_fetcher.NewDockerV2SessionFactory(registryCredentials)),
 		Output:                output,
 		Timeout:               Timeout(timeoutStr),
 		Domain:                config.Target(),
@@ -96,34 +190,92 @@ func cliCommands(timeoutStr, ltcConfigRoot string, exitHandler exit_handler.Exit
 		Clock:                 clock,
 		Logger:                logger,
 		TailedLogsOutputter:   tailedLogsOutputter,
+		LogReaderFactory:      logReaderFactory,
+		RegistryCredentials:   registryCredentials,
+		AppExaminer:           appExaminer,
+		Input:                 input,
+		ExitHandler:           exitHandler,
 	}
 
 	appRunnerCommandFactory := app_runner_command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
 
-	logsCommandFactory := logs_command_factory.NewLogsCommandFactory(output, tailedLogsOutputter, exitHandler)
+	taskRunner := task_runner.New(receptorClient)
+	taskRunnerCommandFactory := task_runner_command_factory.NewTaskRunnerCommandFactory(taskRunner, appRunnerCommandFactoryConfig.DockerMetadataFetcher, registryCredentials, output, os.Environ())
 
-	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, targetVerifier, input, output, exitHandler)
+	logsCommandFactory := logs_command_factory.NewLogsCommandFactory(appExaminer, output, tailedLogsOutputter, logReaderFactory, clock, exitHandler)
+
+	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, targetVerifier, registryCredentials, input, output, exitHandler)
 
-	appExaminer := app_examiner.New(receptorClient)
 	appExaminerCommandFactory := app_examiner_command_factory.NewAppExaminerCommandFactory(appExaminer, output, clock, exitHandler)
 
+	appWatcherCommandFactory := app_watcher_command_factory.NewAppWatcherCommandFactory(appWatcher, output, clock, exitHandler)
+
+	latticeDoctor := doctor.New(config, targetVerifier, appExaminer, doctor.NewNetwork(doctor.NetworkTimeout))
+	doctorCommandFactory := doctor_command_factory.NewDoctorCommandFactory(latticeDoctor, output, exitHandler)
+
 	testRunner := integration_test.NewIntegrationTestRunner(output, config, ltcConfigRoot)
 	integrationTestCommandFactory := integration_test_command_factory.NewIntegrationTestCommandFactory(testRunner, output)
 
 	return []cli.Command{
 		appRunnerCommandFactory.MakeStartAppCommand(),
+		appRunnerCommandFactory.MakeDeployCommand(),
+		appRunnerCommandFactory.MakeUpdateAppCommand(),
+		appRunnerCommandFactory.MakeRedeployCommand(),
 		appRunnerCommandFactory.MakeScaleAppCommand(),
+		appRunnerCommandFactory.MakeRestartAppCommand(),
+		appRunnerCommandFactory.MakeAutoscaleCommand(),
 		appRunnerCommandFactory.MakeStopAppCommand(),
 		appRunnerCommandFactory.MakeRemoveAppCommand(),
+		appRunnerCommandFactory.MakeExportCommand(),
+		appRunnerCommandFactory.MakeImportCommand(),
+		appRunnerCommandFactory.MakeDiffCommand(),
+		taskRunnerCommandFactory.MakeSubmitTaskCommand(),
+		taskRunnerCommandFactory.MakeListTasksCommand(),
+		taskRunnerCommandFactory.MakeTaskCommand(),
+		taskRunnerCommandFactory.MakeCancelTaskCommand(),
+		taskRunnerCommandFactory.MakeDeleteTaskCommand(),
 		logsCommandFactory.MakeLogsCommand(),
+		logsCommandFactory.MakeTopCommand(),
 		configCommandFactory.MakeTargetCommand(),
+		configCommandFactory.MakeTargetsCommand(),
+		configCommandFactory.MakeRegistryLoginCommand(),
 		appExaminerCommandFactory.MakeListAppCommand(),
 		appExaminerCommandFactory.MakeStatusCommand(),
 		appExaminerCommandFactory.MakeVisualizeCommand(),
+		appExaminerCommandFactory.MakeListCellsCommand(),
+		appExaminerCommandFactory.MakeCellCommand(),
+		appWatcherCommandFactory.MakeWatchCommand(),
+		doctorCommandFactory.MakeDoctorCommand(),
 		integrationTestCommandFactory.MakeIntegrationTestCommand(),
 	}
 }
 
+// overrideTarget applies --target before the commands are built against the config,
+// parsing the global flags in args the way the app will.
+// parseGlobalFlags reads the global flags ahead of the app, which needs the
+// target settled before it builds the commands. Its first argument is the command.
+func parseGlobalFlags(flags []cli.Flag, args []string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(AppName, flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+	for _, globalFlag := range flags {
+		globalFlag.Apply(flagSet)
+	}
+
+	if len(args) > 0 {
+		flagSet.Parse(args[1:])
+	}
+	return flagSet
+}
+
+func overrideTarget(config *config.Config, globalFlags *flag.FlagSet) error {
+	for _, name := range []string{"target", "t"} {
+		if targetName := globalFlags.Lookup(name).Value.String(); targetName != "" {
+			return config.OverrideTarget(targetName)
+		}
+	}
+	return nil
+}
+
 func Timeout(timeoutEnv string) time.Duration {
 	if timeout, err := strconv.Atoi(timeoutEnv); err == nil {
 		return time.Second * time.Duration(timeout)
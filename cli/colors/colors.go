@@ -2,6 +2,7 @@ package colors
 
 import (
 	"fmt"
+	"hash/fnv"
 	"strings"
 )
 
@@ -12,6 +13,8 @@ const (
 	cyan            string = "\x1b[36m"
 	green           string = "\x1b[32m"
 	yellow          string = "\x1b[33m"
+	blue            string = "\x1b[34m"
+	magenta         string = "\x1b[35m"
 	purpleUnderline string = "\x1b[35;4m"
 	defaultStyle    string = "\x1b[0m"
 	boldStyle       string = "\x1b[1m"
@@ -45,6 +48,15 @@ func PurpleUnderline(output string) string {
 	return colorize(output, purpleUnderline)
 }
 
+var keyedColors = []string{magenta, blue, green, cyan, yellow, red}
+
+// ColorForKey colors output with a color picked from key, so the same key always gets the same color.
+func ColorForKey(key string, output string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return colorize(output, keyedColors[hash.Sum32()%uint32(len(keyedColors))])
+}
+
 func colorize(output string, color string) string {
 	if strings.TrimSpace(output) == "" {
 		return output
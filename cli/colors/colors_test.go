@@ -17,6 +17,22 @@ var _ = Describe("colors", func() {
 		})
 	}
 
+	Describe("ColorForKey", func() {
+		It("always picks the same color for the same key", func() {
+			Expect(colors.ColorForKey("my-app", "[my-app]")).To(Equal(colors.ColorForKey("my-app", "[my-app]")))
+			Expect(colors.ColorForKey("my-app", "[my-app]")).To(HavePrefix("\x1b["))
+			Expect(colors.ColorForKey("my-app", "[my-app]")).To(HaveSuffix("[my-app]\x1b[0m"))
+		})
+
+		It("spreads different keys across colors", func() {
+			keyColors := map[string]bool{}
+			for _, key := range []string{"app-1", "app-2", "app-3", "app-4", "app-5", "app-6", "app-7", "app-8"} {
+				keyColors[colors.ColorForKey(key, "x")] = true
+			}
+			Expect(len(keyColors)).To(BeNumerically(">", 1))
+		})
+	})
+
 	Describe("Red", func() {
 		It("adds the red color code", func() {
 			Expect(colors.Red("ERROR NOT GOOD")).To(Equal("\x1b[91mERROR NOT GOOD\x1b[0m"))
@@ -5,50 +5,113 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-cf-experimental/lattice-cli/config"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/target_verifier"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
 )
 
-const TargetCommandName = "target"
+const (
+	TargetCommandName        = "target"
+	TargetShortName          = "t"
+	TargetsCommandName       = "targets"
+	RegistryLoginCommandName = "registry-login"
+)
 
 type commandFactory struct {
 	cmd *configCommand
 }
 
-func NewConfigCommandFactory(config *config.Config, targetVerifier target_verifier.TargetVerifier, input io.Reader, output *output.Output, exitHandler exit_handler.ExitHandler) *commandFactory {
-	return &commandFactory{&configCommand{config, input, output, targetVerifier, exitHandler}}
+func NewConfigCommandFactory(config *config.Config, targetVerifier target_verifier.TargetVerifier, registryCredentials registry_credentials.RegistryCredentials, input io.Reader, output *output.Output, exitHandler exit_handler.ExitHandler) *commandFactory {
+	return &commandFactory{&configCommand{config, input, output, targetVerifier, registryCredentials, exitHandler}}
 }
 
 func (c *commandFactory) MakeTargetCommand() cli.Command {
 	var startCommand = cli.Command{
 		Name:      TargetCommandName,
-		ShortName: "t",
+		ShortName: TargetShortName,
 		Description: `Set a target lattice location.
 
    For a Vagrant deployed Lattice:
-   ltc target 192.168.11.11.xip.io`,
-		Usage:  "ltc target LATTICE_TARGET",
+   ltc target 192.168.11.11.xip.io
+
+   To save the target under a name and switch back to it later:
+   ltc target 192.168.11.11.xip.io --name dev
+   ltc target --switch dev
+
+   If the cells of your lattice download files from somewhere other than
+   the cluster's own file server:
+   ltc target 192.168.11.11.xip.io --file-server http://files.example.com:8080`,
+		Usage:  "ltc target LATTICE_TARGET [--name NAME] [--file-server URL] | ltc target --switch NAME",
 		Action: c.cmd.target,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "name, n",
+				Usage: "save the target under this name",
+			},
+			cli.StringFlag{
+				Name:  "switch, s",
+				Usage: "make the named target the active one",
+			},
+			cli.StringFlag{
+				Name:  "file-server",
+				Usage: "URL of the file server lattice cells download the healthcheck from",
+			},
+		},
 	}
 
 	return startCommand
 }
 
+func (c *commandFactory) MakeTargetsCommand() cli.Command {
+	var targetsCommand = cli.Command{
+		Name:        TargetsCommandName,
+		Description: "List the named lattice targets",
+		Usage:       "ltc targets",
+		Action:      c.cmd.listTargets,
+	}
+
+	return targetsCommand
+}
+
+func (c *commandFactory) MakeRegistryLoginCommand() cli.Command {
+	var registryLoginCommand = cli.Command{
+		Name: RegistryLoginCommandName,
+		Description: `Save credentials for a docker registry
+
+   ltc uses them to read the metadata of private images, and ltc start --registry-credentials
+   passes them to lattice so it can pull the image. Without a REGISTRY, the credentials are for the docker hub.
+
+   Credentials saved by docker login are used too, but ltc registry-login takes precedence.`,
+		Usage:  "ltc registry-login [REGISTRY]",
+		Action: c.cmd.registryLogin,
+	}
+
+	return registryLoginCommand
+}
+
 type configCommand struct {
-	config         *config.Config
-	input          io.Reader
-	output         *output.Output
-	targetVerifier target_verifier.TargetVerifier
-	exitHandler    exit_handler.ExitHandler
+	config              *config.Config
+	input               io.Reader
+	output              *output.Output
+	targetVerifier      target_verifier.TargetVerifier
+	registryCredentials registry_credentials.RegistryCredentials
+	exitHandler         exit_handler.ExitHandler
 }
 
 func (cmd *configCommand) target(context *cli.Context) {
 	target := context.Args().First()
+	name := context.String("name")
+
+	if switchName := context.String("switch"); switchName != "" {
+		cmd.switchTarget(switchName)
+		return
+	}
 
 	if target == "" {
 		cmd.printTarget()
@@ -57,13 +120,14 @@ func (cmd *configCommand) target(context *cli.Context) {
 
 	cmd.config.SetTarget(target)
 	cmd.config.SetLogin("", "")
+	cmd.config.SetFileServer(strings.TrimSuffix(context.String("file-server"), "/"))
 
 	if _, authorized, err := cmd.targetVerifier.VerifyTarget(cmd.config.Receptor()); err != nil {
 		cmd.output.Say("Error verifying target: " + err.Error())
 		cmd.exitHandler.Exit(exit_codes.BadTarget)
 		return
 	} else if authorized {
-		cmd.save()
+		cmd.save(name)
 		return
 	}
 
@@ -81,10 +145,70 @@ func (cmd *configCommand) target(context *cli.Context) {
 		return
 	}
 
-	cmd.save()
+	cmd.save(name)
 }
 
-func (cmd *configCommand) save() {
+func (cmd *configCommand) switchTarget(name string) {
+	if err := cmd.config.UseTarget(name); err != nil {
+		cmd.output.Say(err.Error())
+		cmd.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if err := cmd.config.Save(); err != nil {
+		cmd.output.Say(err.Error())
+		return
+	}
+
+	cmd.output.Say(fmt.Sprintf("Switched to target %s (%s)", name, cmd.config.Target()))
+}
+
+func (cmd *configCommand) listTargets(context *cli.Context) {
+	names := cmd.config.TargetNames()
+	if len(names) == 0 {
+		cmd.output.Say("No named targets. Use ltc target LATTICE_TARGET --name NAME to save one.")
+		return
+	}
+
+	w := tabwriter.NewWriter(cmd.output, 9, 8, 1, '\t', 0)
+	fmt.Fprintln(w, "\tName\tTarget\tUsername")
+	for _, name := range names {
+		targetData, _ := cmd.config.NamedTarget(name)
+
+		active := ""
+		if name == cmd.config.ActiveTargetName() {
+			active = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", active, name, targetData.Target, targetData.Username)
+	}
+	w.Flush()
+}
+
+func (cmd *configCommand) registryLogin(context *cli.Context) {
+	registry := context.Args().First()
+
+	username := cmd.prompt("Username: ")
+	password := cmd.prompt("Password: ")
+
+	if username == "" {
+		cmd.incorrectUsage("Username is required")
+		return
+	}
+
+	credentials := registry_credentials.Credentials{Username: username, Password: password}
+	if err := cmd.registryCredentials.Login(registry, credentials); err != nil {
+		cmd.output.Say("Error saving registry credentials: " + err.Error())
+		return
+	}
+
+	cmd.output.Say("Saved credentials for " + registry_credentials.ServerFor(registry))
+}
+
+func (cmd *configCommand) save(name string) {
+	if name != "" {
+		cmd.config.SaveTargetAs(name)
+	}
+
 	err := cmd.config.Save()
 	if err != nil {
 		cmd.output.Say(err.Error())
@@ -116,9 +240,17 @@ func (cmd *configCommand) printTarget() {
 		cmd.output.Say("Target not set.")
 		return
 	}
+	if cmd.config.ActiveTargetName() != "" {
+		cmd.output.Say(fmt.Sprintf("Name:\t\t%s\n", cmd.config.ActiveTargetName()))
+	}
 	cmd.output.Say(fmt.Sprintf("Target:\t\t%s", cmd.config.Target()))
 
+	if cmd.config.FileServer() != config.DefaultFileServer {
+		cmd.output.Say(fmt.Sprintf("\nFile Server:\t%s", cmd.config.FileServer()))
+	}
+
 	if cmd.config.Username() != "" {
 		cmd.output.Say(fmt.Sprintf("\nUsername:\t%s", cmd.config.Username()))
+		cmd.output.Say(fmt.Sprintf("\nCredentials:\t%s", cmd.config.CredentialStoreName()))
 	}
 }
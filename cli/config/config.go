@@ -1,27 +1,57 @@
 package config
 
 import (
+	"errors"
+	"sort"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/persister"
 )
 
+const DefaultFileServer = "http://file_server.service.dc1.consul:8080"
+
+type TargetData struct {
+	Target     string
+	Username   string
+	Password   string `json:",omitempty"`
+	FileServer string `json:",omitempty"`
+}
+
 type Data struct {
-	Target   string
-	Username string
-	Password string
+	Target       string
+	Username     string
+	Password     string                `json:",omitempty"`
+	FileServer   string                `json:",omitempty"`
+	ActiveTarget string                `json:",omitempty"`
+	Targets      map[string]TargetData `json:",omitempty"`
 }
 
 type Config struct {
-	persister persister.Persister
-	data      *Data
+	persister       persister.Persister
+	credentialStore credential_store.CredentialStore
+	data            *Data
+	override        *TargetData
+	overrideName    string
 }
 
 func New(persister persister.Persister) *Config {
-	config := &Config{persister: persister, data: &Data{}}
+	return NewWithCredentialStore(persister, credential_store.NewMemStore())
+}
+
+func NewWithCredentialStore(persister persister.Persister, credentialStore credential_store.CredentialStore) *Config {
+	config := &Config{persister: persister, credentialStore: credentialStore, data: &Data{}}
 	return config
 }
 
 func (c *Config) SetTarget(target string) {
+	c.override = nil
 	c.data.Target = target
+	c.data.FileServer = ""
+	c.data.ActiveTarget = ""
+}
+
+func (c *Config) SetFileServer(fileServer string) {
+	c.data.FileServer = fileServer
 }
 
 func (c *Config) SetLogin(username string, password string) {
@@ -30,29 +60,209 @@ func (c *Config) SetLogin(username string, password string) {
 }
 
 func (c *Config) Target() string {
-	return c.data.Target
+	return c.current().Target
+}
+
+// FileServer is the URL of the file server lattice cells download the
+// healthcheck from, which defaults to the one inside a lattice cluster.
+func (c *Config) FileServer() string {
+	if fileServer := c.current().FileServer; fileServer != "" {
+		return fileServer
+	}
+	return DefaultFileServer
 }
 
 func (c *Config) Username() string {
-	return c.data.Username
+	return c.current().Username
+}
+
+func (c *Config) CredentialStoreName() string {
+	return c.credentialStore.Name()
+}
+
+func (c *Config) CredentialStore() credential_store.CredentialStore {
+	return c.credentialStore
+}
+
+func (c *Config) ActiveTargetName() string {
+	return c.data.ActiveTarget
+}
+
+func (c *Config) SaveTargetAs(name string) {
+	if c.data.Targets == nil {
+		c.data.Targets = make(map[string]TargetData)
+	}
+
+	c.data.Targets[name] = TargetData{
+		Target:     c.data.Target,
+		Username:   c.data.Username,
+		Password:   c.data.Password,
+		FileServer: c.data.FileServer,
+	}
+	c.data.ActiveTarget = name
+}
+
+func (c *Config) UseTarget(name string) error {
+	targetData, ok := c.data.Targets[name]
+	if !ok {
+		return errors.New("Unknown target: " + name)
+	}
+
+	c.override = nil
+	c.data.Target = targetData.Target
+	c.data.Username = targetData.Username
+	c.data.Password = targetData.Password
+	c.data.FileServer = targetData.FileServer
+	c.data.ActiveTarget = name
+
+	return nil
+}
+
+// OverrideTarget points the config at a named target for the rest of this run.
+// The active target is left alone, so Save never records the override.
+func (c *Config) OverrideTarget(name string) error {
+	targetData, ok := c.data.Targets[name]
+	if !ok {
+		return errors.New("Unknown target: " + name)
+	}
+
+	c.override = &targetData
+	c.overrideName = name
+	return nil
+}
+
+func (c *Config) NamedTarget(name string) (TargetData, bool) {
+	targetData, ok := c.data.Targets[name]
+	return targetData, ok
+}
+
+func (c *Config) TargetNames() []string {
+	names := make([]string, 0, len(c.data.Targets))
+	for name := range c.data.Targets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
 }
 
 func (c *Config) Loggregator() string {
-	return "doppler." + c.data.Target
+	return "doppler." + c.Target()
 }
 
 func (c *Config) Receptor() string {
-	if c.data.Username == "" {
-		return "http://receptor." + c.data.Target
+	current := c.current()
+	if current.Username == "" {
+		return "http://receptor." + current.Target
 	}
 
-	return "http://" + c.data.Username + ":" + c.data.Password + "@receptor." + c.data.Target
+	return "http://" + current.Username + ":" + current.Password + "@receptor." + current.Target
 }
 
+// current is the overriding target if there is one, and the active target otherwise.
+func (c *Config) current() TargetData {
+	if c.override != nil {
+		return *c.override
+	}
+
+	return TargetData{
+		Target:     c.data.Target,
+		Username:   c.data.Username,
+		Password:   c.data.Password,
+		FileServer: c.data.FileServer,
+	}
+}
+
+// Load reads the config without touching the credential store. Passwords still
+// present in the file from older versions are kept and moved to the credential
+// store on the next Save.
 func (c *Config) Load() error {
 	return c.persister.Load(c.data)
 }
 
+// LoadCredentials fills in the password of the target in use from the credential
+// store. It is separate from Load since reading the store may mean prompting for
+// a passphrase or running a helper, which only commands that talk to the receptor need.
+func (c *Config) LoadCredentials() error {
+	if c.override != nil {
+		password, err := c.loadPassword(c.overrideName, c.override.Target, c.override.Username, c.override.Password)
+		if err != nil {
+			return err
+		}
+		c.override.Password = password
+		return nil
+	}
+
+	password, err := c.loadPassword(c.data.ActiveTarget, c.data.Target, c.data.Username, c.data.Password)
+	if err != nil {
+		return err
+	}
+	c.data.Password = password
+	return nil
+}
+
+// Save writes the config without any passwords, which go to the credential store instead.
+// Passwords that were never loaded are empty and leave the store alone.
 func (c *Config) Save() error {
-	return c.persister.Save(c.data)
+	if err := c.storePassword(c.data.ActiveTarget, c.data.Target, c.data.Username, c.data.Password); err != nil {
+		return err
+	}
+
+	dataToSave := *c.data
+	dataToSave.Password = ""
+	dataToSave.Targets = nil
+
+	if len(c.data.Targets) > 0 {
+		dataToSave.Targets = make(map[string]TargetData)
+		for name, targetData := range c.data.Targets {
+			if err := c.storePassword(name, targetData.Target, targetData.Username, targetData.Password); err != nil {
+				return err
+			}
+			targetData.Password = ""
+			dataToSave.Targets[name] = targetData
+		}
+	}
+
+	return c.persister.Save(&dataToSave)
+}
+
+// credentialKey keys the password of a named target by its name as well as its URL, so
+// that two names for the same URL keep their own logins. The name goes in the path since
+// credential helpers keep the path of a server URL.
+func credentialKey(name, target string) string {
+	if name == "" {
+		return target
+	}
+	return target + "/" + name
+}
+
+// loadPassword falls back to the URL alone, which is how passwords were keyed before
+// targets had names.
+func (c *Config) loadPassword(name, target, username, password string) (string, error) {
+	if username == "" || password != "" {
+		return password, nil
+	}
+
+	credentials, err := c.credentialStore.Get(credentialKey(name, target))
+	if err == credential_store.ErrCredentialsNotFound && name != "" {
+		credentials, err = c.credentialStore.Get(target)
+	}
+	if err == credential_store.ErrCredentialsNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if credentials.Username != username {
+		return "", nil
+	}
+	return credentials.Secret, nil
+}
+
+func (c *Config) storePassword(name, target, username, password string) error {
+	if username == "" || password == "" {
+		return nil
+	}
+
+	return c.credentialStore.Store(credential_store.Credentials{ServerURL: credentialKey(name, target), Username: username, Secret: password})
 }
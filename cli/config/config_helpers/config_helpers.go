@@ -8,3 +8,14 @@ func ConfigFileLocation(homeDir string) string {
 	configDir := filepath.Join(homeDir, ".lattice")
 	return filepath.Join(configDir, "config.json")
 }
+
+func CredentialsFileLocation(homeDir string) string {
+	return filepath.Join(homeDir, ".lattice", "credentials")
+}
+
+func DockerConfigFileLocations(homeDir string) []string {
+	return []string{
+		filepath.Join(homeDir, ".docker", "config.json"),
+		filepath.Join(homeDir, ".dockercfg"),
+	}
+}
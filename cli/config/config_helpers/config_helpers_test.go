@@ -15,4 +15,20 @@ var _ = Describe("config_helpers", func() {
 			Expect(fileLocation).To(Equal("/home/chicago/.lattice/config.json"))
 		})
 	})
+
+	Describe("CredentialsFileLocation", func() {
+		It("returns the credentials location for the diego home path", func() {
+			fileLocation := config_helpers.CredentialsFileLocation("/home/chicago")
+			Expect(fileLocation).To(Equal("/home/chicago/.lattice/credentials"))
+		})
+	})
+
+	Describe("DockerConfigFileLocations", func() {
+		It("returns the docker config locations for the home path, newest format first", func() {
+			Expect(config_helpers.DockerConfigFileLocations("/home/chicago")).To(Equal([]string{
+				"/home/chicago/.docker/config.json",
+				"/home/chicago/.dockercfg",
+			}))
+		})
+	})
 })
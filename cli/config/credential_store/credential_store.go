@@ -0,0 +1,20 @@
+package credential_store
+
+import (
+	"errors"
+)
+
+var ErrCredentialsNotFound = errors.New("credentials not found")
+
+type Credentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+type CredentialStore interface {
+	Name() string
+	Get(serverURL string) (Credentials, error)
+	Store(credentials Credentials) error
+	Erase(serverURL string) error
+}
@@ -0,0 +1,13 @@
+package credential_store_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCredentialStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CredentialStore Suite")
+}
@@ -0,0 +1,172 @@
+package credential_store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	saltLength    = 16
+	keyIterations = 10000
+	keyLength     = 32
+)
+
+var ErrNoPassphrase = errors.New("No credential passphrase set. Set LATTICE_CREDENTIAL_PASSPHRASE or LATTICE_CREDENTIAL_HELPER to store credentials.")
+
+type encryptedFile struct {
+	Salt       []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+type encryptedFileStore struct {
+	filePath   string
+	passphrase string
+	prompt     func() (string, error)
+}
+
+func NewEncryptedFileStore(filePath, passphrase string) CredentialStore {
+	return &encryptedFileStore{filePath: filePath, passphrase: passphrase}
+}
+
+// NewPromptingEncryptedFileStore asks for the passphrase with prompt the first time
+// the credentials are needed, rather than taking it up front.
+func NewPromptingEncryptedFileStore(filePath string, prompt func() (string, error)) CredentialStore {
+	return &encryptedFileStore{filePath: filePath, prompt: prompt}
+}
+
+func (e *encryptedFileStore) Name() string {
+	return "encrypted file (" + e.filePath + ")"
+}
+
+func (e *encryptedFileStore) Get(serverURL string) (Credentials, error) {
+	allCredentials, _, err := e.load()
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	credentials, ok := allCredentials[serverURL]
+	if !ok {
+		return Credentials{}, ErrCredentialsNotFound
+	}
+	return credentials, nil
+}
+
+func (e *encryptedFileStore) Store(credentials Credentials) error {
+	allCredentials, salt, err := e.load()
+	if err != nil {
+		return err
+	}
+
+	allCredentials[credentials.ServerURL] = credentials
+	return e.save(allCredentials, salt)
+}
+
+func (e *encryptedFileStore) Erase(serverURL string) error {
+	allCredentials, salt, err := e.load()
+	if err != nil {
+		return err
+	}
+
+	delete(allCredentials, serverURL)
+	return e.save(allCredentials, salt)
+}
+
+func (e *encryptedFileStore) load() (map[string]Credentials, []byte, error) {
+	if e.passphrase == "" && e.prompt != nil {
+		passphrase, err := e.prompt()
+		if err != nil {
+			return nil, nil, err
+		}
+		e.passphrase, e.prompt = passphrase, nil
+	}
+
+	if e.passphrase == "" {
+		return nil, nil, ErrNoPassphrase
+	}
+
+	allCredentials := make(map[string]Credentials)
+
+	fileBytes, err := ioutil.ReadFile(e.filePath)
+	if os.IsNotExist(err) {
+		salt := make([]byte, saltLength)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, nil, err
+		}
+		return allCredentials, salt, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(fileBytes, &file); err != nil {
+		return nil, nil, err
+	}
+
+	gcm, err := newGCM(e.passphrase, file.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, errors.New("Could not decrypt credentials. Check your credential passphrase.")
+	}
+
+	if err := json.Unmarshal(plaintext, &allCredentials); err != nil {
+		return nil, nil, err
+	}
+
+	return allCredentials, file.Salt, nil
+}
+
+func (e *encryptedFileStore) save(allCredentials map[string]Credentials, salt []byte) error {
+	plaintext, err := json.Marshal(allCredentials)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(e.passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	fileBytes, err := json.Marshal(encryptedFile{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.filePath), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(e.filePath, fileBytes, 0600)
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, keyIterations, keyLength, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
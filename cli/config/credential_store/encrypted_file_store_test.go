@@ -0,0 +1,126 @@
+package credential_store_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
+)
+
+var _ = Describe("encryptedFileStore", func() {
+	var (
+		tmpDir          string
+		credentialsPath string
+		credentials     credential_store.Credentials
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "credential_store")
+		Expect(err).ToNot(HaveOccurred())
+
+		credentialsPath = filepath.Join(tmpDir, ".lattice", "credentials")
+		credentials = credential_store.Credentials{ServerURL: "lattice.example.com", Username: "user", Secret: "super-secret-password"}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("stores and retrieves credentials", func() {
+		store := credential_store.NewEncryptedFileStore(credentialsPath, "open sesame")
+
+		Expect(store.Store(credentials)).To(Succeed())
+
+		Expect(credential_store.NewEncryptedFileStore(credentialsPath, "open sesame").Get("lattice.example.com")).To(Equal(credentials))
+	})
+
+	It("never writes the secret to disk in plaintext", func() {
+		store := credential_store.NewEncryptedFileStore(credentialsPath, "open sesame")
+
+		Expect(store.Store(credentials)).To(Succeed())
+
+		fileBytes, err := ioutil.ReadFile(credentialsPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(fileBytes)).ToNot(ContainSubstring("super-secret-password"))
+
+		fileInfo, err := os.Stat(credentialsPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("returns ErrCredentialsNotFound for unknown servers", func() {
+		store := credential_store.NewEncryptedFileStore(credentialsPath, "open sesame")
+
+		_, err := store.Get("nowhere.example.com")
+
+		Expect(err).To(Equal(credential_store.ErrCredentialsNotFound))
+	})
+
+	It("erases credentials", func() {
+		store := credential_store.NewEncryptedFileStore(credentialsPath, "open sesame")
+		Expect(store.Store(credentials)).To(Succeed())
+
+		Expect(store.Erase("lattice.example.com")).To(Succeed())
+
+		_, err := store.Get("lattice.example.com")
+		Expect(err).To(Equal(credential_store.ErrCredentialsNotFound))
+	})
+
+	It("returns an error for the wrong passphrase", func() {
+		Expect(credential_store.NewEncryptedFileStore(credentialsPath, "open sesame").Store(credentials)).To(Succeed())
+
+		_, err := credential_store.NewEncryptedFileStore(credentialsPath, "open barley").Get("lattice.example.com")
+
+		Expect(err).To(MatchError("Could not decrypt credentials. Check your credential passphrase."))
+	})
+
+	It("refuses to store anything without a passphrase", func() {
+		store := credential_store.NewEncryptedFileStore(credentialsPath, "")
+
+		Expect(store.Store(credentials)).To(Equal(credential_store.ErrNoPassphrase))
+		_, err := os.Stat(credentialsPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+	Describe("prompting for the passphrase", func() {
+		It("asks once, when the credentials are first needed", func() {
+			Expect(credential_store.NewEncryptedFileStore(credentialsPath, "open sesame").Store(credentials)).To(Succeed())
+
+			prompts := 0
+			store := credential_store.NewPromptingEncryptedFileStore(credentialsPath, func() (string, error) {
+				prompts++
+				return "open sesame", nil
+			})
+			Expect(prompts).To(Equal(0))
+
+			Expect(store.Get("lattice.example.com")).To(Equal(credentials))
+			Expect(store.Get("lattice.example.com")).To(Equal(credentials))
+			Expect(prompts).To(Equal(1))
+		})
+
+		It("returns ErrNoPassphrase when nothing is entered", func() {
+			store := credential_store.NewPromptingEncryptedFileStore(credentialsPath, func() (string, error) {
+				return "", nil
+			})
+
+			_, err := store.Get("lattice.example.com")
+
+			Expect(err).To(Equal(credential_store.ErrNoPassphrase))
+		})
+
+		It("returns the errors prompting", func() {
+			store := credential_store.NewPromptingEncryptedFileStore(credentialsPath, func() (string, error) {
+				return "", errors.New("not a terminal")
+			})
+
+			_, err := store.Get("lattice.example.com")
+
+			Expect(err).To(MatchError("not a terminal"))
+		})
+	})
+})
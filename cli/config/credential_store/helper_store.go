@@ -0,0 +1,75 @@
+package credential_store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+const HelperPrefix = "ltc-credential-"
+
+// helperStore speaks the docker credential helper protocol: the helper program is
+// invoked with get, store or erase and exchanges JSON or the server URL over stdin/stdout.
+type helperStore struct {
+	program string
+}
+
+func NewHelperStore(program string) CredentialStore {
+	return &helperStore{program}
+}
+
+func (h *helperStore) Name() string {
+	return "helper (" + h.program + ")"
+}
+
+func (h *helperStore) Get(serverURL string) (Credentials, error) {
+	output, err := h.run("get", []byte(serverURL))
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(output, &credentials); err != nil {
+		return Credentials{}, err
+	}
+	credentials.ServerURL = serverURL
+
+	return credentials, nil
+}
+
+func (h *helperStore) Store(credentials Credentials) error {
+	payload, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.run("store", payload)
+	return err
+}
+
+func (h *helperStore) Erase(serverURL string) error {
+	_, err := h.run("erase", []byte(serverURL))
+	return err
+}
+
+func (h *helperStore) run(action string, input []byte) ([]byte, error) {
+	cmd := exec.Command(h.program, action)
+	cmd.Stdin = bytes.NewReader(input)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, err
+		}
+
+		message := strings.TrimSpace(string(output))
+		if strings.Contains(message, ErrCredentialsNotFound.Error()) {
+			return nil, ErrCredentialsNotFound
+		}
+		return nil, errors.New(h.program + " " + action + " failed: " + message)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,88 @@
+package credential_store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
+)
+
+const fakeHelperScript = `#!/bin/sh
+dir=$(dirname "$0")
+case "$1" in
+  store) cat > "$dir/stored" ;;
+  get)
+    read server
+    if [ "$server" = "lattice.example.com" ]; then
+      echo '{"ServerURL":"lattice.example.com","Username":"user","Secret":"from-the-keychain"}'
+    else
+      echo "credentials not found in native keychain"
+      exit 1
+    fi ;;
+  erase) cat > "$dir/erased" ;;
+  *) echo "unknown action"; exit 1 ;;
+esac
+`
+
+var _ = Describe("helperStore", func() {
+	var (
+		tmpDir     string
+		helperPath string
+		store      credential_store.CredentialStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "helper_store")
+		Expect(err).ToNot(HaveOccurred())
+
+		helperPath = filepath.Join(tmpDir, credential_store.HelperPrefix+"fake")
+		Expect(ioutil.WriteFile(helperPath, []byte(fakeHelperScript), 0700)).To(Succeed())
+
+		store = credential_store.NewHelperStore(helperPath)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("sends credentials to the helper as JSON", func() {
+		err := store.Store(credential_store.Credentials{ServerURL: "lattice.example.com", Username: "user", Secret: "pass"})
+		Expect(err).ToNot(HaveOccurred())
+
+		stored, err := ioutil.ReadFile(filepath.Join(tmpDir, "stored"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(MatchJSON(`{"ServerURL":"lattice.example.com","Username":"user","Secret":"pass"}`))
+	})
+
+	It("reads credentials back from the helper", func() {
+		credentials, err := store.Get("lattice.example.com")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials).To(Equal(credential_store.Credentials{ServerURL: "lattice.example.com", Username: "user", Secret: "from-the-keychain"}))
+	})
+
+	It("maps the helper's not found message to ErrCredentialsNotFound", func() {
+		_, err := store.Get("nowhere.example.com")
+
+		Expect(err).To(Equal(credential_store.ErrCredentialsNotFound))
+	})
+
+	It("asks the helper to erase credentials by server URL", func() {
+		Expect(store.Erase("lattice.example.com")).To(Succeed())
+
+		erased, err := ioutil.ReadFile(filepath.Join(tmpDir, "erased"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(erased)).To(Equal("lattice.example.com"))
+	})
+
+	It("returns an error when the helper cannot be run", func() {
+		err := credential_store.NewHelperStore(filepath.Join(tmpDir, "missing-helper")).Store(credential_store.Credentials{})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,31 @@
+package credential_store
+
+type memStore struct {
+	credentials map[string]Credentials
+}
+
+func NewMemStore() CredentialStore {
+	return &memStore{make(map[string]Credentials)}
+}
+
+func (m *memStore) Name() string {
+	return "memory"
+}
+
+func (m *memStore) Get(serverURL string) (Credentials, error) {
+	credentials, ok := m.credentials[serverURL]
+	if !ok {
+		return Credentials{}, ErrCredentialsNotFound
+	}
+	return credentials, nil
+}
+
+func (m *memStore) Store(credentials Credentials) error {
+	m.credentials[credentials.ServerURL] = credentials
+	return nil
+}
+
+func (m *memStore) Erase(serverURL string) error {
+	delete(m.credentials, serverURL)
+	return nil
+}
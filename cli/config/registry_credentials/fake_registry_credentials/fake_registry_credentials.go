@@ -0,0 +1,99 @@
+// This file was generated by counterfeiter
+package fake_registry_credentials
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+)
+
+type FakeRegistryCredentials struct {
+	LookupStub        func(registry string) (credentials registry_credentials.Credentials, found bool, err error)
+	lookupMutex       sync.RWMutex
+	lookupArgsForCall []struct {
+		registry string
+	}
+	lookupReturns struct {
+		result1 registry_credentials.Credentials
+		result2 bool
+		result3 error
+	}
+	LoginStub        func(registry string, credentials registry_credentials.Credentials) error
+	loginMutex       sync.RWMutex
+	loginArgsForCall []struct {
+		registry    string
+		credentials registry_credentials.Credentials
+	}
+	loginReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeRegistryCredentials) Lookup(registry string) (credentials registry_credentials.Credentials, found bool, err error) {
+	fake.lookupMutex.Lock()
+	fake.lookupArgsForCall = append(fake.lookupArgsForCall, struct {
+		registry string
+	}{registry})
+	fake.lookupMutex.Unlock()
+	if fake.LookupStub != nil {
+		return fake.LookupStub(registry)
+	} else {
+		return fake.lookupReturns.result1, fake.lookupReturns.result2, fake.lookupReturns.result3
+	}
+}
+
+func (fake *FakeRegistryCredentials) LookupCallCount() int {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return len(fake.lookupArgsForCall)
+}
+
+func (fake *FakeRegistryCredentials) LookupArgsForCall(i int) string {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return fake.lookupArgsForCall[i].registry
+}
+
+func (fake *FakeRegistryCredentials) LookupReturns(result1 registry_credentials.Credentials, result2 bool, result3 error) {
+	fake.LookupStub = nil
+	fake.lookupReturns = struct {
+		result1 registry_credentials.Credentials
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRegistryCredentials) Login(registry string, credentials registry_credentials.Credentials) error {
+	fake.loginMutex.Lock()
+	fake.loginArgsForCall = append(fake.loginArgsForCall, struct {
+		registry    string
+		credentials registry_credentials.Credentials
+	}{registry, credentials})
+	fake.loginMutex.Unlock()
+	if fake.LoginStub != nil {
+		return fake.LoginStub(registry, credentials)
+	} else {
+		return fake.loginReturns.result1
+	}
+}
+
+func (fake *FakeRegistryCredentials) LoginCallCount() int {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	return len(fake.loginArgsForCall)
+}
+
+func (fake *FakeRegistryCredentials) LoginArgsForCall(i int) (string, registry_credentials.Credentials) {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	return fake.loginArgsForCall[i].registry, fake.loginArgsForCall[i].credentials
+}
+
+func (fake *FakeRegistryCredentials) LoginReturns(result1 error) {
+	fake.LoginStub = nil
+	fake.loginReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ registry_credentials.RegistryCredentials = new(FakeRegistryCredentials)
@@ -0,0 +1,137 @@
+package registry_credentials
+
+//go:generate counterfeiter -o fake_registry_credentials/fake_registry_credentials.go . RegistryCredentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
+)
+
+const DockerHubServer = "https://index.docker.io/v1/"
+
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type RegistryCredentials interface {
+	Lookup(registry string) (credentials Credentials, found bool, err error)
+	Login(registry string, credentials Credentials) error
+}
+
+type registryCredentials struct {
+	credentialStore   credential_store.CredentialStore
+	dockerConfigPaths []string
+}
+
+// New looks credentials up in the ltc credential store first and then in each docker
+// config file, in order. Credentials saved with Login only ever go to the credential store.
+func New(credentialStore credential_store.CredentialStore, dockerConfigPaths []string) RegistryCredentials {
+	return &registryCredentials{credentialStore, dockerConfigPaths}
+}
+
+// ServerFor returns the key credentials for a registry are stored under, which is the
+// docker hub v1 url for the official index and the registry host otherwise.
+func ServerFor(registry string) string {
+	switch normalizeServer(registry) {
+	case "", "docker.io", "index.docker.io":
+		return DockerHubServer
+	default:
+		return normalizeServer(registry)
+	}
+}
+
+func (r *registryCredentials) Lookup(registry string) (Credentials, bool, error) {
+	server := ServerFor(registry)
+
+	storedCredentials, err := r.credentialStore.Get(server)
+	if err == nil {
+		return Credentials{storedCredentials.Username, storedCredentials.Secret}, true, nil
+	} else if err != credential_store.ErrCredentialsNotFound && err != credential_store.ErrNoPassphrase {
+		return Credentials{}, false, err
+	}
+
+	for _, path := range r.dockerConfigPaths {
+		auths, err := readDockerConfig(path)
+		if err != nil {
+			return Credentials{}, false, err
+		}
+
+		for authServer, auth := range auths {
+			if ServerFor(authServer) == server {
+				credentials, err := decodeAuth(auth.Auth)
+				if err != nil {
+					return Credentials{}, false, fmt.Errorf("Invalid credentials for %s in %s: %s", authServer, path, err)
+				}
+				return credentials, true, nil
+			}
+		}
+	}
+
+	return Credentials{}, false, nil
+}
+
+func (r *registryCredentials) Login(registry string, credentials Credentials) error {
+	return r.credentialStore.Store(credential_store.Credentials{
+		ServerURL: ServerFor(registry),
+		Username:  credentials.Username,
+		Secret:    credentials.Password,
+	})
+}
+
+type dockerAuth struct {
+	Auth  string `json:"auth"`
+	Email string `json:"email,omitempty"`
+}
+
+// readDockerConfig understands both ~/.docker/config.json, which nests the
+// registries under "auths", and the older flat ~/.dockercfg format.
+func readDockerConfig(path string) (map[string]dockerAuth, error) {
+	configBytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var dockerConfig struct {
+		Auths map[string]dockerAuth `json:"auths"`
+	}
+	if err := json.Unmarshal(configBytes, &dockerConfig); err == nil && dockerConfig.Auths != nil {
+		return dockerConfig.Auths, nil
+	}
+
+	var auths map[string]dockerAuth
+	if err := json.Unmarshal(configBytes, &auths); err != nil {
+		return nil, fmt.Errorf("Could not read docker config %s: %s", path, err)
+	}
+	return auths, nil
+}
+
+func decodeAuth(auth string) (Credentials, error) {
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	userAndPassword := strings.SplitN(string(decoded), ":", 2)
+	if len(userAndPassword) != 2 {
+		return Credentials{}, fmt.Errorf("expected USERNAME:PASSWORD")
+	}
+	return Credentials{userAndPassword[0], userAndPassword[1]}, nil
+}
+
+func normalizeServer(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	if slash := strings.Index(server, "/"); slash != -1 {
+		server = server[:slash]
+	}
+	return server
+}
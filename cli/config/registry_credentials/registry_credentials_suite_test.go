@@ -0,0 +1,13 @@
+package registry_credentials_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRegistryCredentials(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RegistryCredentials Suite")
+}
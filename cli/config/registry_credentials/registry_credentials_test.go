@@ -0,0 +1,111 @@
+package registry_credentials_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/registry_credentials"
+)
+
+var _ = Describe("RegistryCredentials", func() {
+	var (
+		tmpDir           string
+		dockerConfigPath string
+		dockercfgPath    string
+		credentialStore  credential_store.CredentialStore
+		credentials      registry_credentials.RegistryCredentials
+	)
+
+	auth := func(username, password string) string {
+		return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "registry_credentials")
+		Expect(err).ToNot(HaveOccurred())
+
+		dockerConfigPath = filepath.Join(tmpDir, ".docker", "config.json")
+		dockercfgPath = filepath.Join(tmpDir, ".dockercfg")
+		Expect(os.MkdirAll(filepath.Dir(dockerConfigPath), 0700)).To(Succeed())
+
+		credentialStore = credential_store.NewMemStore()
+		credentials = registry_credentials.New(credentialStore, []string{dockerConfigPath, dockercfgPath})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("ServerFor", func() {
+		It("uses the docker hub url for the official index", func() {
+			Expect(registry_credentials.ServerFor("")).To(Equal(registry_credentials.DockerHubServer))
+			Expect(registry_credentials.ServerFor("docker.io")).To(Equal(registry_credentials.DockerHubServer))
+			Expect(registry_credentials.ServerFor("https://index.docker.io/v1/")).To(Equal(registry_credentials.DockerHubServer))
+		})
+
+		It("uses the host for private registries", func() {
+			Expect(registry_credentials.ServerFor("registry.example.com:5000")).To(Equal("registry.example.com:5000"))
+			Expect(registry_credentials.ServerFor("https://registry.example.com:5000/v1/")).To(Equal("registry.example.com:5000"))
+		})
+	})
+
+	Describe("Lookup", func() {
+		It("reports when there are no credentials for the registry", func() {
+			_, found, err := credentials.Lookup("registry.example.com:5000")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("reads credentials from ~/.docker/config.json", func() {
+			Expect(ioutil.WriteFile(dockerConfigPath, []byte(`{"auths":{"https://registry.example.com:5000/v1/":{"auth":"`+auth("jim", "pass:word")+`","email":"jim@example.com"}}}`), 0600)).To(Succeed())
+
+			found, ok, err := credentials.Lookup("registry.example.com:5000")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(found).To(Equal(registry_credentials.Credentials{Username: "jim", Password: "pass:word"}))
+		})
+
+		It("reads docker hub credentials from ~/.dockercfg", func() {
+			Expect(ioutil.WriteFile(dockercfgPath, []byte(`{"https://index.docker.io/v1/":{"auth":"`+auth("hubuser", "hubpass")+`","email":""}}`), 0600)).To(Succeed())
+
+			found, ok, err := credentials.Lookup("docker.io")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(found).To(Equal(registry_credentials.Credentials{Username: "hubuser", Password: "hubpass"}))
+		})
+
+		It("prefers credentials saved with Login over the docker config", func() {
+			Expect(ioutil.WriteFile(dockerConfigPath, []byte(`{"auths":{"registry.example.com":{"auth":"`+auth("docker", "config")+`"}}}`), 0600)).To(Succeed())
+			Expect(credentials.Login("registry.example.com", registry_credentials.Credentials{Username: "ltc", Password: "login"})).To(Succeed())
+
+			found, _, err := credentials.Lookup("registry.example.com")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(Equal(registry_credentials.Credentials{Username: "ltc", Password: "login"}))
+		})
+
+		It("returns an error for malformed auth entries", func() {
+			Expect(ioutil.WriteFile(dockerConfigPath, []byte(`{"auths":{"registry.example.com":{"auth":"not base64!"}}}`), 0600)).To(Succeed())
+
+			_, _, err := credentials.Lookup("registry.example.com")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid credentials for registry.example.com"))
+		})
+	})
+
+	Describe("Login", func() {
+		It("stores the credentials under the registry's server key", func() {
+			Expect(credentials.Login("", registry_credentials.Credentials{Username: "hubuser", Password: "hubpass"})).To(Succeed())
+
+			stored, err := credentialStore.Get(registry_credentials.DockerHubServer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored).To(Equal(credential_store.Credentials{ServerURL: registry_credentials.DockerHubServer, Username: "hubuser", Secret: "hubpass"}))
+		})
+	})
+})
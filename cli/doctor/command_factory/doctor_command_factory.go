@@ -0,0 +1,87 @@
+package command_factory
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+)
+
+const DoctorCommandName = "doctor"
+
+type DoctorCommandFactory struct {
+	cmd *doctorCommand
+}
+
+func NewDoctorCommandFactory(doctor doctor.Doctor, output *output.Output, exitHandler exit_handler.ExitHandler) *DoctorCommandFactory {
+	return &DoctorCommandFactory{&doctorCommand{doctor, output, exitHandler}}
+}
+
+func (factory *DoctorCommandFactory) MakeDoctorCommand() cli.Command {
+	return cli.Command{
+		Name: DoctorCommandName,
+		Description: `Check the health of the targeted lattice cluster
+
+   Checks receptor reachability and credentials, DNS for the lattice domain,
+   the router, doppler websockets, missing cells, instances that could not be
+   placed and crash-looping apps, with a hint on how to fix each failure.`,
+		Usage:  "ltc doctor",
+		Action: factory.cmd.runChecks,
+		Flags:  []cli.Flag{},
+	}
+}
+
+type doctorCommand struct {
+	doctor      doctor.Doctor
+	output      *output.Output
+	exitHandler exit_handler.ExitHandler
+}
+
+func (cmd *doctorCommand) runChecks(context *cli.Context) {
+	results := cmd.doctor.RunChecks()
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	if cmd.output.IsStructured() {
+		if err := cmd.output.SayFormatted(results); err != nil {
+			cmd.output.Say("Error formatting output: " + err.Error())
+		}
+	} else {
+		for _, result := range results {
+			cmd.output.SayLine(statusLabel(result.Status) + " " + colors.Bold(result.Name) + ": " + result.Message)
+			if result.Hint != "" {
+				cmd.output.SayLine("       " + result.Hint)
+			}
+		}
+
+		cmd.output.NewLine()
+		summary := fmt.Sprintf("%d passed, %d failed, %d skipped.", counts[doctor.StatusPassed], counts[doctor.StatusFailed], counts[doctor.StatusSkipped])
+		if counts[doctor.StatusFailed] > 0 {
+			cmd.output.SayLine(colors.Red(summary))
+		} else {
+			cmd.output.SayLine(colors.Green(summary))
+		}
+	}
+
+	if counts[doctor.StatusFailed] > 0 {
+		cmd.exitHandler.Exit(exit_codes.ChecksFailed)
+	}
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case doctor.StatusPassed:
+		return colors.Green("[PASS]")
+	case doctor.StatusSkipped:
+		return colors.Yellow("[SKIP]")
+	default:
+		return colors.Red("[FAIL]")
+	}
+}
@@ -0,0 +1,82 @@
+package command_factory_test
+
+import (
+	"encoding/json"
+
+	"github.com/codegangsta/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor/command_factory"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor/fake_doctor"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/fake_exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
+)
+
+var _ = Describe("DoctorCommandFactory", func() {
+	var (
+		fakeDoctor    *fake_doctor.FakeDoctor
+		outputBuffer  *gbytes.Buffer
+		commandOutput *output.Output
+		exitHandler   *fake_exit_handler.FakeExitHandler
+		doctorCommand cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeDoctor = &fake_doctor.FakeDoctor{}
+		outputBuffer = gbytes.NewBuffer()
+		commandOutput = output.New(outputBuffer)
+		exitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		doctorCommand = command_factory.NewDoctorCommandFactory(fakeDoctor, commandOutput, exitHandler).MakeDoctorCommand()
+	})
+
+	It("reports the result of each check", func() {
+		fakeDoctor.RunChecksReturns([]doctor.CheckResult{
+			{Name: "Receptor", Status: doctor.StatusPassed, Message: "receptor.lattice.example.com is up and ltc is authorized."},
+			{Name: "Router", Status: doctor.StatusFailed, Message: "Could not reach the router.", Hint: "Make sure the router is running."},
+			{Name: "Cells", Status: doctor.StatusSkipped, Message: "Skipped because the receptor cannot be used."},
+		})
+
+		test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+		Expect(fakeDoctor.RunChecksCallCount()).To(Equal(1))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Green("[PASS]") + " " + colors.Bold("Receptor") + ": receptor.lattice.example.com is up and ltc is authorized.\n"))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Red("[FAIL]") + " " + colors.Bold("Router") + ": Could not reach the router.\n"))
+		Expect(outputBuffer).To(test_helpers.Say("       Make sure the router is running.\n"))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("[SKIP]") + " " + colors.Bold("Cells") + ": Skipped because the receptor cannot be used.\n"))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Red("1 passed, 1 failed, 1 skipped.")))
+		Expect(exitHandler.ExitCalledWith).To(Equal([]int{exit_codes.ChecksFailed}))
+	})
+
+	It("exits cleanly when every check passes", func() {
+		fakeDoctor.RunChecksReturns([]doctor.CheckResult{
+			{Name: "Receptor", Status: doctor.StatusPassed, Message: "receptor.lattice.example.com is up and ltc is authorized."},
+		})
+
+		test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say(colors.Green("1 passed, 0 failed, 0 skipped.")))
+		Expect(exitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("emits the results as structured output", func() {
+		Expect(commandOutput.SetFormat("json")).To(Succeed())
+		results := []doctor.CheckResult{
+			{Name: "Crashes", Status: doctor.StatusFailed, Message: "Crash-looping apps: broken-app (1 crashes)", Hint: "Run ltc logs APP_NAME --recent."},
+		}
+		fakeDoctor.RunChecksReturns(results)
+
+		test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+		var emitted []doctor.CheckResult
+		Expect(json.Unmarshal(outputBuffer.Contents(), &emitted)).To(Succeed())
+		Expect(emitted).To(Equal(results))
+		Expect(exitHandler.ExitCalledWith).To(Equal([]int{exit_codes.ChecksFailed}))
+	})
+})
@@ -0,0 +1,194 @@
+package doctor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/config"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/target_verifier"
+)
+
+const (
+	StatusPassed  = "pass"
+	StatusFailed  = "fail"
+	StatusSkipped = "skip"
+
+	// CrashLoopThreshold is how many times an instance may crash before its app
+	// counts as crash-looping.
+	CrashLoopThreshold = 3
+
+	// probeHostname is a route no app is expected to have, used to check the
+	// wildcard DNS entry and the router.
+	probeHostname = "ltc-doctor"
+)
+
+type CheckResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Hint    string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+//go:generate counterfeiter -o fake_doctor/fake_doctor.go . Doctor
+type Doctor interface {
+	RunChecks() []CheckResult
+}
+
+func New(config *config.Config, targetVerifier target_verifier.TargetVerifier, appExaminer app_examiner.AppExaminer, network Network) Doctor {
+	return &doctor{config, targetVerifier, appExaminer, network}
+}
+
+type doctor struct {
+	config         *config.Config
+	targetVerifier target_verifier.TargetVerifier
+	appExaminer    app_examiner.AppExaminer
+	network        Network
+}
+
+// RunChecks checks each part of the target cluster separately, so one broken part
+// does not hide the state of the others. The checks that need the receptor are
+// skipped when it cannot be used.
+func (d *doctor) RunChecks() []CheckResult {
+	target := d.config.Target()
+	if target == "" {
+		return []CheckResult{failed("Target", "No lattice target is set.", "Run ltc target LATTICE_DOMAIN.")}
+	}
+
+	receptorResult := d.checkReceptor(target)
+	results := []CheckResult{
+		receptorResult,
+		d.checkDNS(target),
+		d.checkRouter(target),
+		d.checkDoppler(),
+	}
+
+	if receptorResult.Status != StatusPassed {
+		for _, name := range []string{"Cells", "Placement", "Crashes"} {
+			results = append(results, CheckResult{Name: name, Status: StatusSkipped, Message: "Skipped because the receptor cannot be used."})
+		}
+		return results
+	}
+
+	results = append(results, d.checkCells())
+
+	apps, err := d.appExaminer.ListApps()
+	if err != nil {
+		message := "Could not list the apps: " + err.Error()
+		return append(results, failed("Placement", message, ""), failed("Crashes", message, ""))
+	}
+
+	return append(results, checkPlacement(apps), checkCrashes(apps))
+}
+
+func (d *doctor) checkReceptor(target string) CheckResult {
+	receptorUp, authorized, err := d.targetVerifier.VerifyTarget(d.config.Receptor())
+	switch {
+	case !receptorUp:
+		return failed("Receptor", fmt.Sprintf("Could not reach the receptor at receptor.%s: %s", target, errorMessage(err)), "Make sure lattice is up and running, and that ltc target points at it.")
+	case !authorized:
+		return failed("Receptor", "The receptor rejected ltc's credentials.", "Run ltc target with the correct username and password.")
+	}
+	return passed("Receptor", fmt.Sprintf("receptor.%s is up and ltc is authorized.", target))
+}
+
+func (d *doctor) checkDNS(target string) CheckResult {
+	hint := fmt.Sprintf("Lattice needs a wildcard DNS entry for *.%s pointing at the brain. For a quick setup, target a xip.io domain such as 192.168.11.11.xip.io.", target)
+
+	for _, host := range []string{target, probeHostname + "." + target} {
+		if _, err := d.network.LookupHost(host); err != nil {
+			return failed("DNS", fmt.Sprintf("Could not resolve %s: %s", host, err), hint)
+		}
+	}
+	return passed("DNS", fmt.Sprintf("%s and its subdomains resolve.", target))
+}
+
+func (d *doctor) checkRouter(target string) CheckResult {
+	url := "http://" + probeHostname + "." + target
+	if _, err := d.network.HTTPGet(url); err != nil {
+		return failed("Router", fmt.Sprintf("Could not reach the router at %s: %s", url, err), "Make sure the router is running and that port 80 of the brain is reachable from here.")
+	}
+	return passed("Router", "The router is answering requests.")
+}
+
+func (d *doctor) checkDoppler() CheckResult {
+	url := "ws://" + d.config.Loggregator() + "/apps/" + probeHostname + "/stream"
+	if err := d.network.DialWebsocket(url); err != nil {
+		return failed("Doppler", fmt.Sprintf("Could not open a websocket to %s: %s", d.config.Loggregator(), err), "ltc logs, top and autoscale need doppler to accept websockets on port 80. Make sure nothing between here and the brain drops the Upgrade header.")
+	}
+	return passed("Doppler", "Doppler is accepting websocket connections.")
+}
+
+func (d *doctor) checkCells() CheckResult {
+	cells, err := d.appExaminer.ListCells()
+	if err != nil {
+		return failed("Cells", "Could not list the cells: "+err.Error(), "")
+	}
+
+	missingCellIDs := []string{}
+	for _, cell := range cells {
+		if cell.Missing {
+			missingCellIDs = append(missingCellIDs, cell.CellID)
+		}
+	}
+
+	switch {
+	case len(cells) == 0:
+		return failed("Cells", "No cells have registered.", "Make sure the cells are running and can reach the brain.")
+	case len(missingCellIDs) > 0:
+		return failed("Cells", "Missing cells: "+strings.Join(missingCellIDs, ", "), "Missing cells still have instances placed on them but have stopped reporting. Check that they are running, or wait for their instances to be rescheduled.")
+	}
+	return passed("Cells", fmt.Sprintf("%d cells are reporting.", len(cells)))
+}
+
+func checkPlacement(apps []app_examiner.AppInfo) CheckResult {
+	unplaced := []string{}
+	for _, app := range apps {
+		for _, instance := range app.ActualInstances {
+			if instance.State == "UNCLAIMED" && instance.PlacementError != "" {
+				unplaced = append(unplaced, fmt.Sprintf("%s[%d] (%s)", app.ProcessGuid, instance.Index, instance.PlacementError))
+			}
+		}
+	}
+
+	if len(unplaced) > 0 {
+		return failed("Placement", "Instances that could not be placed: "+strings.Join(unplaced, ", "), "The cells are out of capacity for these instances. Scale down or remove apps, or add cells. See ltc cells.")
+	}
+	return passed("Placement", "Every instance has been placed on a cell.")
+}
+
+func checkCrashes(apps []app_examiner.AppInfo) CheckResult {
+	crashing := []string{}
+	for _, app := range apps {
+		crashLooping, crashCount := false, 0
+		for _, instance := range app.ActualInstances {
+			crashLooping = crashLooping || instance.State == "CRASHED" || instance.CrashCount >= CrashLoopThreshold
+			crashCount += instance.CrashCount
+		}
+		if crashLooping {
+			crashing = append(crashing, fmt.Sprintf("%s (%d crashes)", app.ProcessGuid, crashCount))
+		}
+	}
+
+	if len(crashing) > 0 {
+		sort.Strings(crashing)
+		return failed("Crashes", "Crash-looping apps: "+strings.Join(crashing, ", "), "Run ltc logs APP_NAME --recent to see why they are crashing.")
+	}
+	return passed("Crashes", "No apps are crash-looping.")
+}
+
+func passed(name, message string) CheckResult {
+	return CheckResult{Name: name, Status: StatusPassed, Message: message}
+}
+
+func failed(name, message, hint string) CheckResult {
+	return CheckResult{Name: name, Status: StatusFailed, Message: message, Hint: hint}
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return "unknown error"
+	}
+	return err.Error()
+}
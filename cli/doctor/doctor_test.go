@@ -0,0 +1,229 @@
+package doctor_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner/fake_app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/config"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/persister"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/target_verifier/fake_target_verifier"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor/fake_network"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		ltcConfig          *config.Config
+		fakeTargetVerifier *fake_target_verifier.FakeTargetVerifier
+		fakeAppExaminer    *fake_app_examiner.FakeAppExaminer
+		fakeNetwork        *fake_network.FakeNetwork
+		latticeDoctor      doctor.Doctor
+	)
+
+	resultNamed := func(results []doctor.CheckResult, name string) doctor.CheckResult {
+		for _, result := range results {
+			if result.Name == name {
+				return result
+			}
+		}
+		Fail("no check named " + name)
+		return doctor.CheckResult{}
+	}
+
+	BeforeEach(func() {
+		ltcConfig = config.New(persister.NewMemPersister())
+		ltcConfig.SetTarget("lattice.example.com")
+		fakeTargetVerifier = &fake_target_verifier.FakeTargetVerifier{}
+		fakeTargetVerifier.VerifyTargetReturns(true, true, nil)
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeAppExaminer.ListCellsReturns([]app_examiner.CellInfo{app_examiner.CellInfo{CellID: "cell-0"}}, nil)
+		fakeNetwork = &fake_network.FakeNetwork{}
+
+		latticeDoctor = doctor.New(ltcConfig, fakeTargetVerifier, fakeAppExaminer, fakeNetwork)
+	})
+
+	Describe("RunChecks", func() {
+		It("passes every check on a healthy cluster", func() {
+			results := latticeDoctor.RunChecks()
+
+			names := []string{}
+			for _, result := range results {
+				names = append(names, result.Name)
+				Expect(result.Status).To(Equal(doctor.StatusPassed), result.Name+": "+result.Message)
+			}
+			Expect(names).To(Equal([]string{"Receptor", "DNS", "Router", "Doppler", "Cells", "Placement", "Crashes"}))
+
+			Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.lattice.example.com"))
+			Expect(fakeNetwork.LookupHostArgsForCall(0)).To(Equal("lattice.example.com"))
+			Expect(fakeNetwork.LookupHostArgsForCall(1)).To(Equal("ltc-doctor.lattice.example.com"))
+			Expect(fakeNetwork.HTTPGetArgsForCall(0)).To(Equal("http://ltc-doctor.lattice.example.com"))
+			Expect(fakeNetwork.DialWebsocketArgsForCall(0)).To(Equal("ws://doppler.lattice.example.com/apps/ltc-doctor/stream"))
+		})
+
+		It("only fails the target check when there is no target", func() {
+			ltcConfig.SetTarget("")
+
+			results := latticeDoctor.RunChecks()
+
+			Expect(results).To(Equal([]doctor.CheckResult{{Name: "Target", Status: doctor.StatusFailed, Message: "No lattice target is set.", Hint: "Run ltc target LATTICE_DOMAIN."}}))
+			Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(Equal(0))
+		})
+
+		Context("when the receptor is down", func() {
+			BeforeEach(func() {
+				fakeTargetVerifier.VerifyTargetReturns(false, false, errors.New("connection refused"))
+			})
+
+			It("fails the receptor check and skips the checks that need it", func() {
+				results := latticeDoctor.RunChecks()
+
+				receptorResult := resultNamed(results, "Receptor")
+				Expect(receptorResult.Status).To(Equal(doctor.StatusFailed))
+				Expect(receptorResult.Message).To(Equal("Could not reach the receptor at receptor.lattice.example.com: connection refused"))
+				Expect(receptorResult.Hint).NotTo(BeEmpty())
+
+				Expect(resultNamed(results, "DNS").Status).To(Equal(doctor.StatusPassed))
+				Expect(resultNamed(results, "Cells").Status).To(Equal(doctor.StatusSkipped))
+				Expect(resultNamed(results, "Placement").Status).To(Equal(doctor.StatusSkipped))
+				Expect(resultNamed(results, "Crashes").Status).To(Equal(doctor.StatusSkipped))
+				Expect(fakeAppExaminer.ListCellsCallCount()).To(Equal(0))
+			})
+		})
+
+		It("fails the receptor check when ltc is not authorized", func() {
+			fakeTargetVerifier.VerifyTargetReturns(true, false, nil)
+
+			receptorResult := resultNamed(latticeDoctor.RunChecks(), "Receptor")
+
+			Expect(receptorResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(receptorResult.Message).To(Equal("The receptor rejected ltc's credentials."))
+		})
+
+		It("fails the DNS check when the wildcard entry does not resolve", func() {
+			fakeNetwork.LookupHostStub = func(host string) ([]string, error) {
+				if host == "ltc-doctor.lattice.example.com" {
+					return nil, errors.New("no such host")
+				}
+				return []string{"192.168.11.11"}, nil
+			}
+
+			dnsResult := resultNamed(latticeDoctor.RunChecks(), "DNS")
+
+			Expect(dnsResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(dnsResult.Message).To(Equal("Could not resolve ltc-doctor.lattice.example.com: no such host"))
+			Expect(dnsResult.Hint).To(ContainSubstring("*.lattice.example.com"))
+		})
+
+		It("fails the router check when the router cannot be reached", func() {
+			fakeNetwork.HTTPGetReturns(0, errors.New("i/o timeout"))
+
+			routerResult := resultNamed(latticeDoctor.RunChecks(), "Router")
+
+			Expect(routerResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(routerResult.Message).To(Equal("Could not reach the router at http://ltc-doctor.lattice.example.com: i/o timeout"))
+		})
+
+		It("fails the doppler check when the websocket cannot be opened", func() {
+			fakeNetwork.DialWebsocketReturns(errors.New("bad handshake"))
+
+			dopplerResult := resultNamed(latticeDoctor.RunChecks(), "Doppler")
+
+			Expect(dopplerResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(dopplerResult.Message).To(Equal("Could not open a websocket to doppler.lattice.example.com: bad handshake"))
+		})
+
+		Describe("the cells check", func() {
+			It("fails when cells are missing", func() {
+				fakeAppExaminer.ListCellsReturns([]app_examiner.CellInfo{
+					app_examiner.CellInfo{CellID: "cell-0"},
+					app_examiner.CellInfo{CellID: "cell-1", Missing: true},
+					app_examiner.CellInfo{CellID: "cell-2", Missing: true},
+				}, nil)
+
+				cellsResult := resultNamed(latticeDoctor.RunChecks(), "Cells")
+
+				Expect(cellsResult.Status).To(Equal(doctor.StatusFailed))
+				Expect(cellsResult.Message).To(Equal("Missing cells: cell-1, cell-2"))
+			})
+
+			It("fails when no cells have registered", func() {
+				fakeAppExaminer.ListCellsReturns([]app_examiner.CellInfo{}, nil)
+
+				cellsResult := resultNamed(latticeDoctor.RunChecks(), "Cells")
+
+				Expect(cellsResult.Status).To(Equal(doctor.StatusFailed))
+				Expect(cellsResult.Message).To(Equal("No cells have registered."))
+			})
+
+			It("fails when the cells cannot be listed", func() {
+				fakeAppExaminer.ListCellsReturns(nil, errors.New("bbs is down"))
+
+				cellsResult := resultNamed(latticeDoctor.RunChecks(), "Cells")
+
+				Expect(cellsResult.Status).To(Equal(doctor.StatusFailed))
+				Expect(cellsResult.Message).To(Equal("Could not list the cells: bbs is down"))
+			})
+		})
+
+		It("fails the placement check for unclaimed instances with placement errors", func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{
+					ProcessGuid: "big-app",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "RUNNING"},
+						app_examiner.InstanceInfo{Index: 1, State: "UNCLAIMED", PlacementError: "insufficient resources"},
+						app_examiner.InstanceInfo{Index: 2, State: "UNCLAIMED"},
+					},
+				},
+			}, nil)
+
+			placementResult := resultNamed(latticeDoctor.RunChecks(), "Placement")
+
+			Expect(placementResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(placementResult.Message).To(Equal("Instances that could not be placed: big-app[1] (insufficient resources)"))
+		})
+
+		It("fails the crashes check for crash-looping apps", func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{
+					ProcessGuid: "flaky-app",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "RUNNING", CrashCount: 1},
+						app_examiner.InstanceInfo{Index: 1, State: "RUNNING", CrashCount: doctor.CrashLoopThreshold},
+					},
+				},
+				app_examiner.AppInfo{
+					ProcessGuid: "broken-app",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "CRASHED", CrashCount: 1},
+					},
+				},
+				app_examiner.AppInfo{
+					ProcessGuid: "fine-app",
+					ActualInstances: []app_examiner.InstanceInfo{
+						app_examiner.InstanceInfo{Index: 0, State: "RUNNING", CrashCount: 1},
+					},
+				},
+			}, nil)
+
+			crashesResult := resultNamed(latticeDoctor.RunChecks(), "Crashes")
+
+			Expect(crashesResult.Status).To(Equal(doctor.StatusFailed))
+			Expect(crashesResult.Message).To(Equal("Crash-looping apps: broken-app (1 crashes), flaky-app (4 crashes)"))
+			Expect(crashesResult.Hint).To(ContainSubstring("ltc logs APP_NAME --recent"))
+		})
+
+		It("fails the app checks when the apps cannot be listed", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("receptor hiccup"))
+
+			results := latticeDoctor.RunChecks()
+
+			Expect(resultNamed(results, "Placement").Message).To(Equal("Could not list the apps: receptor hiccup"))
+			Expect(resultNamed(results, "Crashes").Status).To(Equal(doctor.StatusFailed))
+		})
+	})
+})
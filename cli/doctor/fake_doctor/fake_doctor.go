@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fake_doctor
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
+)
+
+type FakeDoctor struct {
+	RunChecksStub        func() []doctor.CheckResult
+	runChecksMutex       sync.RWMutex
+	runChecksArgsForCall []struct{}
+	runChecksReturns     struct {
+		result1 []doctor.CheckResult
+	}
+}
+
+func (fake *FakeDoctor) RunChecks() []doctor.CheckResult {
+	fake.runChecksMutex.Lock()
+	fake.runChecksArgsForCall = append(fake.runChecksArgsForCall, struct{}{})
+	fake.runChecksMutex.Unlock()
+	if fake.RunChecksStub != nil {
+		return fake.RunChecksStub()
+	} else {
+		return fake.runChecksReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) RunChecksCallCount() int {
+	fake.runChecksMutex.RLock()
+	defer fake.runChecksMutex.RUnlock()
+	return len(fake.runChecksArgsForCall)
+}
+
+func (fake *FakeDoctor) RunChecksReturns(result1 []doctor.CheckResult) {
+	fake.RunChecksStub = nil
+	fake.runChecksReturns = struct {
+		result1 []doctor.CheckResult
+	}{result1}
+}
+
+var _ doctor.Doctor = new(FakeDoctor)
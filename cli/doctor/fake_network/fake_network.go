@@ -0,0 +1,137 @@
+// This file was generated by counterfeiter
+package fake_network
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/doctor"
+)
+
+type FakeNetwork struct {
+	LookupHostStub        func(host string) ([]string, error)
+	lookupHostMutex       sync.RWMutex
+	lookupHostArgsForCall []struct {
+		host string
+	}
+	lookupHostReturns struct {
+		result1 []string
+		result2 error
+	}
+	HTTPGetStub        func(url string) (statusCode int, err error)
+	hTTPGetMutex       sync.RWMutex
+	hTTPGetArgsForCall []struct {
+		url string
+	}
+	hTTPGetReturns struct {
+		result1 int
+		result2 error
+	}
+	DialWebsocketStub        func(url string) error
+	dialWebsocketMutex       sync.RWMutex
+	dialWebsocketArgsForCall []struct {
+		url string
+	}
+	dialWebsocketReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeNetwork) LookupHost(host string) ([]string, error) {
+	fake.lookupHostMutex.Lock()
+	fake.lookupHostArgsForCall = append(fake.lookupHostArgsForCall, struct {
+		host string
+	}{host})
+	fake.lookupHostMutex.Unlock()
+	if fake.LookupHostStub != nil {
+		return fake.LookupHostStub(host)
+	} else {
+		return fake.lookupHostReturns.result1, fake.lookupHostReturns.result2
+	}
+}
+
+func (fake *FakeNetwork) LookupHostCallCount() int {
+	fake.lookupHostMutex.RLock()
+	defer fake.lookupHostMutex.RUnlock()
+	return len(fake.lookupHostArgsForCall)
+}
+
+func (fake *FakeNetwork) LookupHostArgsForCall(i int) string {
+	fake.lookupHostMutex.RLock()
+	defer fake.lookupHostMutex.RUnlock()
+	return fake.lookupHostArgsForCall[i].host
+}
+
+func (fake *FakeNetwork) LookupHostReturns(result1 []string, result2 error) {
+	fake.LookupHostStub = nil
+	fake.lookupHostReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNetwork) HTTPGet(url string) (statusCode int, err error) {
+	fake.hTTPGetMutex.Lock()
+	fake.hTTPGetArgsForCall = append(fake.hTTPGetArgsForCall, struct {
+		url string
+	}{url})
+	fake.hTTPGetMutex.Unlock()
+	if fake.HTTPGetStub != nil {
+		return fake.HTTPGetStub(url)
+	} else {
+		return fake.hTTPGetReturns.result1, fake.hTTPGetReturns.result2
+	}
+}
+
+func (fake *FakeNetwork) HTTPGetCallCount() int {
+	fake.hTTPGetMutex.RLock()
+	defer fake.hTTPGetMutex.RUnlock()
+	return len(fake.hTTPGetArgsForCall)
+}
+
+func (fake *FakeNetwork) HTTPGetArgsForCall(i int) string {
+	fake.hTTPGetMutex.RLock()
+	defer fake.hTTPGetMutex.RUnlock()
+	return fake.hTTPGetArgsForCall[i].url
+}
+
+func (fake *FakeNetwork) HTTPGetReturns(result1 int, result2 error) {
+	fake.HTTPGetStub = nil
+	fake.hTTPGetReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNetwork) DialWebsocket(url string) error {
+	fake.dialWebsocketMutex.Lock()
+	fake.dialWebsocketArgsForCall = append(fake.dialWebsocketArgsForCall, struct {
+		url string
+	}{url})
+	fake.dialWebsocketMutex.Unlock()
+	if fake.DialWebsocketStub != nil {
+		return fake.DialWebsocketStub(url)
+	} else {
+		return fake.dialWebsocketReturns.result1
+	}
+}
+
+func (fake *FakeNetwork) DialWebsocketCallCount() int {
+	fake.dialWebsocketMutex.RLock()
+	defer fake.dialWebsocketMutex.RUnlock()
+	return len(fake.dialWebsocketArgsForCall)
+}
+
+func (fake *FakeNetwork) DialWebsocketArgsForCall(i int) string {
+	fake.dialWebsocketMutex.RLock()
+	defer fake.dialWebsocketMutex.RUnlock()
+	return fake.dialWebsocketArgsForCall[i].url
+}
+
+func (fake *FakeNetwork) DialWebsocketReturns(result1 error) {
+	fake.DialWebsocketStub = nil
+	fake.dialWebsocketReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ doctor.Network = new(FakeNetwork)
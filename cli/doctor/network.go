@@ -0,0 +1,74 @@
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// NetworkTimeout keeps ltc doctor from hanging on a part of the cluster that does not answer.
+const NetworkTimeout = 5 * time.Second
+
+//go:generate counterfeiter -o fake_network/fake_network.go . Network
+type Network interface {
+	LookupHost(host string) ([]string, error)
+	HTTPGet(url string) (statusCode int, err error)
+	DialWebsocket(url string) error
+}
+
+// NewNetwork returns a Network whose requests give up after timeout.
+func NewNetwork(timeout time.Duration) Network {
+	return &network{timeout}
+}
+
+type network struct {
+	timeout time.Duration
+}
+
+type lookupResult struct {
+	addrs []string
+	err   error
+}
+
+// LookupHost gives up on the resolver after the timeout. The lookup itself cannot be
+// cancelled, so it finishes in the background.
+func (n *network) LookupHost(host string) ([]string, error) {
+	resultChan := make(chan lookupResult, 1)
+	go func() {
+		addrs, err := net.LookupHost(host)
+		resultChan <- lookupResult{addrs, err}
+	}()
+
+	timer := time.NewTimer(n.timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-resultChan:
+		return result.addrs, result.err
+	case <-timer.C:
+		return nil, fmt.Errorf("lookup %s: timed out after %s", host, n.timeout)
+	}
+}
+
+func (n *network) HTTPGet(url string) (int, error) {
+	client := &http.Client{Timeout: n.timeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode, nil
+}
+
+func (n *network) DialWebsocket(url string) error {
+	dialer := &websocket.Dialer{HandshakeTimeout: n.timeout}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -1,6 +1,9 @@
 package exit_codes
 
 const (
-	BadTarget = 12
-	SigInt    = 130
+	AppsDrifted  = 1
+	DiffFailed   = 2
+	ChecksFailed = 3
+	BadTarget    = 12
+	SigInt       = 130
 )
@@ -1,21 +1,37 @@
 package command_factory
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter"
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/output/cursor"
+	"github.com/pivotal-golang/clock"
 )
 
 type logsCommandFactory struct {
 	cmd *logsCommand
 }
 
-func NewLogsCommandFactory(output *output.Output, tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter, exitHandler exit_handler.ExitHandler) *logsCommandFactory {
+func NewLogsCommandFactory(appExaminer app_examiner.AppExaminer, output *output.Output, tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter, logReaderFactory console_tailed_logs_outputter.LogReaderFactory, clock clock.Clock, exitHandler exit_handler.ExitHandler) *logsCommandFactory {
 	return &logsCommandFactory{
 		&logsCommand{
+			appExaminer:         appExaminer,
 			output:              output,
 			tailedLogsOutputter: tailedLogsOutputter,
+			logReaderFactory:    logReaderFactory,
+			clock:               clock,
 			exitHandler:         exitHandler,
 		},
 	}
@@ -23,30 +39,445 @@ func NewLogsCommandFactory(output *output.Output, tailedLogsOutputter console_ta
 
 func (factory *logsCommandFactory) MakeLogsCommand() cli.Command {
 	var logsCommand = cli.Command{
-		Name:        "logs",
-		ShortName:   "l",
-		Description: "Stream logs from the specified application",
-		Usage:       "ltc logs APP_NAME",
-		Action:      factory.cmd.tailLogs,
-		Flags:       []cli.Flag{},
+		Name:      "logs",
+		ShortName: "l",
+		Description: `Stream logs from the specified applications
+
+   To follow several apps, or every app, in one terminal:
+   ltc logs APP_NAME OTHER_APP_NAME
+   ltc logs --all
+
+   To print the logs doppler has buffered instead, e.g. after a crash:
+   ltc logs APP_NAME --recent
+
+   To narrow the output down:
+   ltc logs APP_NAME --source APP --instance 0 --since 10m --grep panic
+
+   To run as a long-lived shipper that persists or forwards the logs:
+   ltc logs --all --sink file:/var/log/lattice --sink syslog+tcp://logs.example.com:514
+
+   Sinks: console, file:DIR, json:PATH (json:- for stdout), syslog://HOST:PORT (UDP), syslog+tcp://HOST:PORT`,
+		Usage:  "ltc logs (APP_NAME... | --all) [--recent] [--source SOURCE] [--instance INDEX] [--since DURATION] [--grep PATTERN] [--sink SINK...]",
+		Action: factory.cmd.tailLogs,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "all, a",
+				Usage: "stream the logs of every app",
+			},
+			cli.BoolFlag{
+				Name:  "recent, r",
+				Usage: "print the recent logs buffered by doppler, sorted by time, instead of streaming",
+			},
+			cli.StringSliceFlag{
+				Name:  "source, s",
+				Usage: "only show logs from this source type, e.g. APP or HEALTH",
+				Value: &cli.StringSlice{},
+			},
+			cli.IntFlag{
+				Name:  "instance, i",
+				Usage: "only show logs from the instance with this index",
+				Value: logs.AllInstances,
+			},
+			cli.StringFlag{
+				Name:  "since",
+				Usage: "only show logs newer than this duration, e.g. 30s or 10m",
+			},
+			cli.StringFlag{
+				Name:  "grep, g",
+				Usage: "only show logs whose message matches this regular expression",
+			},
+			cli.StringSliceFlag{
+				Name:  "sink",
+				Usage: "write logs to this sink instead of the console, can be repeated",
+				Value: &cli.StringSlice{},
+			},
+		},
 	}
 
 	return logsCommand
 }
 
+func (factory *logsCommandFactory) MakeTopCommand() cli.Command {
+	return cli.Command{
+		Name: "top",
+		Description: `Show the live CPU, memory and disk usage of the specified applications and their instances
+
+   Memory and disk usage are shown against the limits the apps were started with.
+
+   To follow several apps, or every app, at once:
+   ltc top APP_NAME OTHER_APP_NAME
+   ltc top --all`,
+		Usage:  "ltc top (APP_NAME... | --all) [--rate DURATION]",
+		Action: factory.cmd.top,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "all, a",
+				Usage: "show the usage of every app",
+			},
+			cli.DurationFlag{
+				Name:  "rate, r",
+				Usage: "The rate at which to refresh the usage.\n\te.g. -r=\".5s\"",
+				Value: time.Second,
+			},
+		},
+	}
+}
+
 type logsCommand struct {
+	appExaminer         app_examiner.AppExaminer
 	output              *output.Output
 	tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter
+	logReaderFactory    console_tailed_logs_outputter.LogReaderFactory
+	clock               clock.Clock
 	exitHandler         exit_handler.ExitHandler
 }
 
 func (cmd *logsCommand) tailLogs(context *cli.Context) {
-	appGuid := context.Args().First()
+	appGuids, ok := cmd.appGuids(context, "logs")
+	if !ok {
+		return
+	}
+
+	filter, err := cmd.buildFilter(context)
+	if err != nil {
+		cmd.output.IncorrectUsage(err.Error())
+		return
+	}
+
+	if sinkSpecs := context.StringSlice("sink"); len(sinkSpecs) > 0 {
+		if context.Bool("recent") {
+			cmd.output.IncorrectUsage("--sink cannot be combined with --recent")
+			return
+		}
+		cmd.shipLogs(appGuids, filter, sinkSpecs)
+		return
+	}
+
+	if context.Bool("recent") {
+		cmd.tailedLogsOutputter.OutputRecentLogs(appGuids, filter)
+		return
+	}
+
+	cmd.tailedLogsOutputter.OutputFilteredTailedLogs(appGuids, filter)
+}
+
+func (cmd *logsCommand) shipLogs(appGuids []string, filter logs.LogFilter, sinkSpecs []string) {
+	sinks := []logs.LogSink{}
+	closeSinks := func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}
+
+	for _, sinkSpec := range sinkSpecs {
+		sink, err := logs.NewLogSink(sinkSpec, cmd.output)
+		if err != nil {
+			cmd.output.Say("Error creating log sink: " + err.Error())
+			closeSinks()
+			return
+		}
+		sinks = append(sinks, sink)
+	}
+
+	cmd.exitHandler.OnExit(closeSinks)
+	cmd.tailedLogsOutputter.ShipLogs(appGuids, filter, sinks)
+}
+
+// appGuids returns the apps named on the command line, or every app with --all. It
+// reports false when it has already told the user why there is nothing to show.
+func (cmd *logsCommand) appGuids(context *cli.Context, what string) ([]string, bool) {
+	appGuids := []string(context.Args())
 
-	if appGuid == "" {
+	if context.Bool("all") {
+		if len(appGuids) > 0 {
+			cmd.output.IncorrectUsage("APP_NAME and --all cannot be combined")
+			return nil, false
+		}
+
+		var err error
+		if appGuids, err = cmd.allAppGuids(); err != nil {
+			cmd.output.Say("Error listing apps: " + err.Error())
+			return nil, false
+		} else if len(appGuids) == 0 {
+			cmd.output.Say("No apps to show " + what + " for.")
+			return nil, false
+		}
+	}
+
+	if len(appGuids) == 0 {
 		cmd.output.IncorrectUsage("")
+		return nil, false
+	}
+
+	return appGuids, true
+}
+
+func (cmd *logsCommand) allAppGuids() ([]string, error) {
+	apps, err := cmd.appExaminer.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	appGuids := make([]string, 0, len(apps))
+	for _, app := range apps {
+		appGuids = append(appGuids, app.ProcessGuid)
+	}
+	return appGuids, nil
+}
+
+func (cmd *logsCommand) buildFilter(context *cli.Context) (logs.LogFilter, error) {
+	filter := logs.NewLogFilter()
+	filter.SourceTypes = context.StringSlice("source")
+	filter.Instance = context.Int("instance")
+
+	if since := context.String("since"); since != "" {
+		sinceDuration, err := time.ParseDuration(since)
+		if err != nil {
+			return logs.LogFilter{}, err
+		}
+		filter.Since = cmd.clock.Now().Add(-sinceDuration)
+	}
+
+	if grep := context.String("grep"); grep != "" {
+		grepRegexp, err := regexp.Compile(grep)
+		if err != nil {
+			return logs.LogFilter{}, err
+		}
+		filter.Grep = grepRegexp
+	}
+
+	return filter, nil
+}
+
+func (cmd *logsCommand) top(context *cli.Context) {
+	rate := context.Duration("rate")
+	if rate <= 0 {
+		cmd.output.IncorrectUsage("--rate must be positive")
+		return
+	}
+
+	appGuids, ok := cmd.appGuids(context, "usage")
+	if !ok {
 		return
 	}
 
-	cmd.tailedLogsOutputter.OutputTailedLogs(appGuid)
+	apps := make([]app_examiner.AppInfo, 0, len(appGuids))
+	for _, appGuid := range appGuids {
+		app, err := cmd.appExaminer.AppStatus(appGuid)
+		if err != nil {
+			cmd.output.Say("Error getting status of " + appGuid + ": " + err.Error())
+			return
+		}
+		apps = append(apps, app)
+	}
+
+	usage := newContainerUsage()
+	logReaders := make([]logs.LogReader, 0, len(apps))
+	for _, app := range apps {
+		appGuid := app.ProcessGuid
+		logReader := cmd.logReaderFactory()
+		logReaders = append(logReaders, logReader)
+
+		metricCallback := func(metric *events.ContainerMetric) {
+			usage.record(appGuid, metric)
+		}
+		errorCallback := func(err error) {
+			usage.recordError(appGuid, err)
+		}
+
+		go logReader.TailContainerMetrics(appGuid, metricCallback, errorCallback)
+	}
+
+	closeChan := make(chan bool)
+	cmd.output.Say(cursor.Hide())
+
+	cmd.exitHandler.OnExit(func() {
+		for _, logReader := range logReaders {
+			logReader.StopTailing()
+		}
+		closeChan <- true
+		cmd.output.Say(cursor.Show())
+	})
+
+	linesWritten := cmd.printUsage(apps, usage)
+	for {
+		select {
+		case <-closeChan:
+			return
+		case <-cmd.clock.NewTimer(rate).C():
+			apps = cmd.refreshStatus(apps)
+			cmd.output.Say(cursor.Up(linesWritten))
+			linesWritten = cmd.printUsage(apps, usage)
+		}
+	}
+}
+
+// refreshStatus fetches the current status of the apps so that scaling shows up in the
+// usage. An app whose status cannot be fetched keeps its previous status.
+func (cmd *logsCommand) refreshStatus(apps []app_examiner.AppInfo) []app_examiner.AppInfo {
+	refreshed := make([]app_examiner.AppInfo, 0, len(apps))
+	for _, app := range apps {
+		if status, err := cmd.appExaminer.AppStatus(app.ProcessGuid); err == nil {
+			app = status
+		}
+		refreshed = append(refreshed, app)
+	}
+	return refreshed
+}
+
+// printUsage draws a row per app with the usage summed over its instances, followed by a
+// row per instance, and returns the number of lines written.
+func (cmd *logsCommand) printUsage(apps []app_examiner.AppInfo, usage *containerUsage) int {
+	defer cmd.output.Say(cursor.ClearToEndOfDisplay())
+
+	linesWritten := 0
+	sayLine := func(line string) {
+		cmd.output.Say(line)
+		cmd.output.Say(cursor.ClearToEndOfLine())
+		cmd.output.NewLine()
+		linesWritten++
+	}
+
+	nameWidth := len("App Name")
+	for _, app := range apps {
+		if len(app.ProcessGuid) > nameWidth {
+			nameWidth = len(app.ProcessGuid)
+		}
+	}
+
+	columns := func(name, instance, cpu string) string {
+		return fmt.Sprintf("%-*s  %-9s  %7s  ", nameWidth, name, instance, cpu)
+	}
+	usageColumns := func(memoryBytes, diskBytes uint64, memoryLimitMB, diskLimitMB int) string {
+		memory := fmt.Sprintf("%-*s", usageColumnWidth, formatUsage(memoryBytes, memoryLimitMB))
+		return colorUsage(memory, memoryBytes, memoryLimitMB) + "  " + colorUsage(formatUsage(diskBytes, diskLimitMB), diskBytes, diskLimitMB)
+	}
+
+	sayLine(colors.Bold(columns("App Name", "Instance", "CPU") + fmt.Sprintf("%-*s  %s", usageColumnWidth, "Memory", "Disk")))
+
+	for _, app := range apps {
+		metrics, err := usage.snapshot(app.ProcessGuid, app.DesiredInstances)
+		instances := fmt.Sprintf("%d/%d", len(metrics), app.DesiredInstances)
+
+		if len(metrics) == 0 {
+			sayLine(colors.Bold(columns(app.ProcessGuid, instances, "-")) + fmt.Sprintf("%-*s  %s", usageColumnWidth, "-", "-"))
+		} else {
+			var cpuPercentage float64
+			var memoryBytes, diskBytes uint64
+			for _, metric := range metrics {
+				cpuPercentage += metric.GetCpuPercentage()
+				memoryBytes += metric.GetMemoryBytes()
+				diskBytes += metric.GetDiskBytes()
+			}
+
+			reported := len(metrics)
+			sayLine(colors.Bold(columns(app.ProcessGuid, instances, formatCPU(cpuPercentage))) + usageColumns(memoryBytes, diskBytes, app.MemoryMB*reported, app.DiskMB*reported))
+		}
+
+		for _, metric := range metrics {
+			index := strconv.Itoa(int(metric.GetInstanceIndex()))
+			sayLine(columns("", index, formatCPU(metric.GetCpuPercentage())) + usageColumns(metric.GetMemoryBytes(), metric.GetDiskBytes(), app.MemoryMB, app.DiskMB))
+		}
+
+		if err != nil {
+			sayLine(colors.Red(err.Error()))
+		}
+	}
+
+	return linesWritten
+}
+
+const (
+	bytesPerMB       = 1024 * 1024
+	usageColumnWidth = 24
+)
+
+func formatCPU(cpuPercentage float64) string {
+	return fmt.Sprintf("%.1f%%", cpuPercentage)
+}
+
+// formatUsage shows bytes in MB against limitMB, or on its own when there is no limit.
+func formatUsage(bytes uint64, limitMB int) string {
+	usedMB := float64(bytes) / bytesPerMB
+	if limitMB <= 0 {
+		return fmt.Sprintf("%.1f MB", usedMB)
+	}
+	return fmt.Sprintf("%.1f/%d MB (%.0f%%)", usedMB, limitMB, usedMB*100/float64(limitMB))
+}
+
+// colorUsage colors usage green below 70% of limitMB, yellow below 90% and red otherwise.
+func colorUsage(usage string, bytes uint64, limitMB int) string {
+	if limitMB <= 0 {
+		return colors.NoColor(usage)
+	}
+
+	switch usedPercentage := float64(bytes) * 100 / float64(limitMB*bytesPerMB); {
+	case usedPercentage < 70:
+		return colors.Green(usage)
+	case usedPercentage < 90:
+		return colors.Yellow(usage)
+	default:
+		return colors.Red(usage)
+	}
+}
+
+// containerUsage keeps the latest container metric of every instance, and the latest
+// error, of each app as they stream in.
+type containerUsage struct {
+	sync.Mutex
+	metrics map[string]map[int32]*events.ContainerMetric
+	errors  map[string]error
+}
+
+func newContainerUsage() *containerUsage {
+	return &containerUsage{
+		metrics: make(map[string]map[int32]*events.ContainerMetric),
+		errors:  make(map[string]error),
+	}
+}
+
+func (u *containerUsage) record(appGuid string, metric *events.ContainerMetric) {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.metrics[appGuid] == nil {
+		u.metrics[appGuid] = make(map[int32]*events.ContainerMetric)
+	}
+	u.metrics[appGuid][metric.GetInstanceIndex()] = metric
+	delete(u.errors, appGuid)
+}
+
+func (u *containerUsage) recordError(appGuid string, err error) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.errors[appGuid] = err
+}
+
+// snapshot returns the app's metrics sorted by instance index, and its latest error.
+// Metrics of instances at or beyond desiredInstances were left behind by a scale-down,
+// so they are dropped.
+func (u *containerUsage) snapshot(appGuid string, desiredInstances int) ([]*events.ContainerMetric, error) {
+	u.Lock()
+	defer u.Unlock()
+
+	metrics := make([]*events.ContainerMetric, 0, len(u.metrics[appGuid]))
+	for index, metric := range u.metrics[appGuid] {
+		if int(index) >= desiredInstances {
+			delete(u.metrics[appGuid], index)
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	sort.Sort(byInstanceIndex(metrics))
+
+	return metrics, u.errors[appGuid]
+}
+
+type byInstanceIndex []*events.ContainerMetric
+
+func (b byInstanceIndex) Len() int      { return len(b) }
+func (b byInstanceIndex) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byInstanceIndex) Less(i, j int) bool {
+	return b[i].GetInstanceIndex() < b[j].GetInstanceIndex()
 }
@@ -1,18 +1,31 @@
 package command_factory_test
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/app_examiner/fake_app_examiner"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/exit_codes"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler/fake_exit_handler"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/command_factory"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs/fake_log_reader"
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
+	"github.com/pivotal-cf-experimental/lattice-cli/output/cursor"
 	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("CommandFactory", func() {
@@ -22,6 +35,9 @@ var _ = Describe("CommandFactory", func() {
 			fakeTailedLogsOutputter *fake_tailed_logs_outputter.FakeTailedLogsOutputter
 			signalChan              chan os.Signal
 			exitHandler             exit_handler.ExitHandler
+			clock                   *fakeclock.FakeClock
+			tailLogsCommand         cli.Command
+			appExaminer             *fake_app_examiner.FakeAppExaminer
 		)
 
 		BeforeEach(func() {
@@ -29,6 +45,11 @@ var _ = Describe("CommandFactory", func() {
 			fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
 			signalChan = make(chan os.Signal)
 			exitHandler = &fake_exit_handler.FakeExitHandler{}
+			clock = fakeclock.NewFakeClock(time.Now())
+			appExaminer = &fake_app_examiner.FakeAppExaminer{}
+
+			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, output.New(outputBuffer), fakeTailedLogsOutputter, nil, clock, exitHandler)
+			tailLogsCommand = commandFactory.MakeLogsCommand()
 		})
 
 		It("Tails logs", func() {
@@ -36,28 +57,334 @@ var _ = Describe("CommandFactory", func() {
 				"my-app-guid",
 			}
 
-			commandFactory := command_factory.NewLogsCommandFactory(output.New(outputBuffer), fakeTailedLogsOutputter, exitHandler)
-			tailLogsCommand := commandFactory.MakeLogsCommand()
+			test_helpers.AsyncExecuteCommandWithArgs(tailLogsCommand, args)
+
+			Eventually(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount).Should(Equal(1))
+			appGuids, filter := fakeTailedLogsOutputter.OutputFilteredTailedLogsArgsForCall(0)
+			Expect(appGuids).To(Equal([]string{"my-app-guid"}))
+			Expect(filter.SourceTypes).To(BeEmpty())
+			Expect(filter.Instance).To(Equal(logs.AllInstances))
+			Expect(filter.Since.IsZero()).To(BeTrue())
+			Expect(filter.Grep).To(BeNil())
+		})
+
+		It("Tails logs matching the filters", func() {
+			args := []string{
+				"--source=APP",
+				"--source=HEALTH",
+				"--instance=1",
+				"--since=10m",
+				"--grep=pan+ic",
+				"my-app-guid",
+			}
 
 			test_helpers.AsyncExecuteCommandWithArgs(tailLogsCommand, args)
 
-			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
-			Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("my-app-guid"))
+			Eventually(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount).Should(Equal(1))
+			_, filter := fakeTailedLogsOutputter.OutputFilteredTailedLogsArgsForCall(0)
+			Expect(filter.SourceTypes).To(Equal([]string{"APP", "HEALTH"}))
+			Expect(filter.Instance).To(Equal(1))
+			Expect(filter.Since).To(Equal(clock.Now().Add(-10 * time.Minute)))
+			Expect(filter.Grep.String()).To(Equal("pan+ic"))
+		})
+
+		It("Outputs the recent logs instead of tailing", func() {
+			args := []string{
+				"--recent",
+				"--source=APP",
+				"my-app-guid",
+			}
+
+			test_helpers.ExecuteCommandWithArgs(tailLogsCommand, args)
+
+			Expect(fakeTailedLogsOutputter.OutputRecentLogsCallCount()).To(Equal(1))
+			appGuids, filter := fakeTailedLogsOutputter.OutputRecentLogsArgsForCall(0)
+			Expect(appGuids).To(Equal([]string{"my-app-guid"}))
+			Expect(filter.SourceTypes).To(Equal([]string{"APP"}))
+			Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+		})
+
+		It("Tails the logs of several apps at once", func() {
+			test_helpers.AsyncExecuteCommandWithArgs(tailLogsCommand, []string{"frontend", "backend"})
 
+			Eventually(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount).Should(Equal(1))
+			appGuids, _ := fakeTailedLogsOutputter.OutputFilteredTailedLogsArgsForCall(0)
+			Expect(appGuids).To(Equal([]string{"frontend", "backend"}))
+		})
+
+		Context("when --all is passed", func() {
+			It("tails the logs of every app", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					app_examiner.AppInfo{ProcessGuid: "frontend"},
+					app_examiner.AppInfo{ProcessGuid: "backend"},
+				}, nil)
+
+				test_helpers.AsyncExecuteCommandWithArgs(tailLogsCommand, []string{"--all"})
+
+				Eventually(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount).Should(Equal(1))
+				appGuids, _ := fakeTailedLogsOutputter.OutputFilteredTailedLogsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"frontend", "backend"}))
+			})
+
+			It("alerts the user when there are no apps", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--all"})
+
+				Expect(outputBuffer).To(test_helpers.Say("No apps to show logs for."))
+				Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+			})
+
+			It("outputs errors listing the apps", func() {
+				appExaminer.ListAppsReturns(nil, errors.New("receptor is down"))
+
+				test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--all"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error listing apps: receptor is down"))
+				Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+			})
+
+			It("cannot be combined with app names", func() {
+				test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--all", "frontend"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: APP_NAME and --all cannot be combined"))
+				Expect(appExaminer.ListAppsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when sinks are passed", func() {
+			It("ships the logs to the sinks", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(tailLogsCommand, []string{"--sink=console", "--sink=json:-", "my-app-guid"})
+
+				Eventually(fakeTailedLogsOutputter.ShipLogsCallCount).Should(Equal(1))
+				appGuids, _, sinks := fakeTailedLogsOutputter.ShipLogsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"my-app-guid"}))
+				Expect(sinks).To(HaveLen(2))
+				Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+			})
+
+			It("reports invalid sinks", func() {
+				test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--sink=carrier-pigeon", "my-app-guid"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error creating log sink: Invalid log sink: carrier-pigeon"))
+				Expect(fakeTailedLogsOutputter.ShipLogsCallCount()).To(Equal(0))
+			})
+
+			It("cannot be combined with --recent", func() {
+				test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--sink=console", "--recent", "my-app-guid"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --sink cannot be combined with --recent"))
+				Expect(fakeTailedLogsOutputter.ShipLogsCallCount()).To(Equal(0))
+			})
 		})
 
 		It("Handles invalid appguids", func() {
 			args := []string{}
 
-			commandFactory := command_factory.NewLogsCommandFactory(output.New(outputBuffer), fakeTailedLogsOutputter, exitHandler)
-			tailLogsCommand := commandFactory.MakeLogsCommand()
-
 			test_helpers.ExecuteCommandWithArgs(tailLogsCommand, args)
 
 			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage"))
-			Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(Equal(0))
+			Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+		})
+
+		It("Handles invalid durations", func() {
+			test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--since=yesterday", "my-app-guid"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: time: invalid duration"))
+			Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+		})
+
+		It("Handles invalid patterns", func() {
+			test_helpers.ExecuteCommandWithArgs(tailLogsCommand, []string{"--grep=(unclosed", "my-app-guid"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: error parsing regexp"))
+			Expect(fakeTailedLogsOutputter.OutputFilteredTailedLogsCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("topCommand", func() {
+		var (
+			outputBuffer   *gbytes.Buffer
+			exitHandler    exit_handler.ExitHandler
+			clock          *fakeclock.FakeClock
+			topCommand     cli.Command
+			appExaminer    *fake_app_examiner.FakeAppExaminer
+			fakeLogReaders []*fake_log_reader.FakeLogReader
+		)
+
+		containerMetric := func(instanceIndex int32, cpuPercentage float64, memoryMB, diskMB uint64) *events.ContainerMetric {
+			memoryBytes := memoryMB * 1024 * 1024
+			diskBytes := diskMB * 1024 * 1024
+			return &events.ContainerMetric{
+				InstanceIndex: &instanceIndex,
+				CpuPercentage: &cpuPercentage,
+				MemoryBytes:   &memoryBytes,
+				DiskBytes:     &diskBytes,
+			}
+		}
+
+		BeforeEach(func() {
+			outputBuffer = gbytes.NewBuffer()
+			exitHandler = &fake_exit_handler.FakeExitHandler{}
+			clock = fakeclock.NewFakeClock(time.Now())
+			appExaminer = &fake_app_examiner.FakeAppExaminer{}
+			fakeLogReaders = []*fake_log_reader.FakeLogReader{fake_log_reader.NewFakeLogReader(), fake_log_reader.NewFakeLogReader()}
 
+			logReadersCreated := 0
+			logReaderFactory := func() logs.LogReader {
+				logReader := fakeLogReaders[logReadersCreated]
+				logReadersCreated++
+				return logReader
+			}
+
+			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, output.New(outputBuffer), nil, logReaderFactory, clock, exitHandler)
+			topCommand = commandFactory.MakeTopCommand()
+		})
+
+		It("shows the usage of the app and its instances against the app's limits", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app", DesiredInstances: 2, MemoryMB: 128, DiskMB: 1024}, nil)
+			fakeLogReaders[0].AddContainerMetric(containerMetric(1, 5, 64, 100))
+			fakeLogReaders[0].AddContainerMetric(containerMetric(0, 2.5, 120, 512))
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
+			Eventually(fakeLogReaders[0].GetAppGuid).Should(Equal("my-app"))
+			Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("my-app"))
+
+			clock.IncrementBySeconds(1)
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  %7s  %-24s  %s", "App Name", "Instance", "CPU", "Memory", "Disk"))))
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  %7s  ", "my-app", "2/2", "7.5%")) + colors.Yellow(fmt.Sprintf("%-24s", "184.0/256 MB (72%)")) + "  " + colors.Green("612.0/2048 MB (30%)")))
+			Eventually(outputBuffer).Should(test_helpers.Say(fmt.Sprintf("%-8s  %-9s  %7s  ", "", "0", "2.5%") + colors.Red(fmt.Sprintf("%-24s", "120.0/128 MB (94%)")) + "  " + colors.Green("512.0/1024 MB (50%)")))
+			Eventually(outputBuffer).Should(test_helpers.Say(fmt.Sprintf("%-8s  %-9s  %7s  ", "", "1", "5.0%") + colors.Green(fmt.Sprintf("%-24s", "64.0/128 MB (50%)")) + "  " + colors.Green("100.0/1024 MB (10%)")))
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.ClearToEndOfDisplay()))
+
+			exitHandler.Exit(exit_codes.SigInt)
+		})
+
+		It("redraws the usage at the given rate", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app", DesiredInstances: 1}, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"--rate=2s", "my-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  %7s  ", "my-app", "0/1", "-")) + fmt.Sprintf("%-24s  %s", "-", "-") + cursor.ClearToEndOfLine() + "\n"))
+
+			clock.IncrementBySeconds(1)
+			Consistently(outputBuffer).ShouldNot(test_helpers.Say(cursor.Up(2)))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(2)))
+			Eventually(outputBuffer).Should(test_helpers.Say("my-app"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+		})
+
+		It("drops the instances removed by a scale-down", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app", DesiredInstances: 2, MemoryMB: 128, DiskMB: 1024}, nil)
+			fakeLogReaders[0].AddContainerMetric(containerMetric(0, 2.5, 120, 512))
+			fakeLogReaders[0].AddContainerMetric(containerMetric(1, 5, 64, 100))
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Eventually(fakeLogReaders[0].GetAppGuid).Should(Equal("my-app"))
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  ", "my-app", "2/2"))))
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.ClearToEndOfDisplay()))
+
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app", DesiredInstances: 1, MemoryMB: 128, DiskMB: 1024}, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  %7s  ", "my-app", "1/1", "2.5%"))))
+			Eventually(outputBuffer).Should(test_helpers.Say(fmt.Sprintf("%-8s  %-9s  %7s  ", "", "0", "2.5%")))
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.ClearToEndOfDisplay()))
+			Expect(outputBuffer).NotTo(test_helpers.Say(fmt.Sprintf("%-8s  %-9s  %7s  ", "", "1", "5.0%")))
+
+			exitHandler.Exit(exit_codes.SigInt)
 		})
 
+		It("keeps the previous status of an app when refreshing it fails", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app", DesiredInstances: 1}, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New("receptor is down"))
+			clock.IncrementBySeconds(1)
+
+			Eventually(appExaminer.AppStatusCallCount).Should(Equal(2))
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(2)))
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold(fmt.Sprintf("%-8s  %-9s  ", "my-app", "0/1"))))
+
+			exitHandler.Exit(exit_codes.SigInt)
+		})
+
+		It("shows the errors streaming the metrics", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app"}, nil)
+			fakeLogReaders[0].AddError(errors.New("doppler is down"))
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Eventually(fakeLogReaders[0].GetAppGuid).Should(Equal("my-app"))
+			clock.IncrementBySeconds(1)
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Red("doppler is down") + cursor.ClearToEndOfLine() + "\n"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+		})
+
+		It("shows the usage of every app with --all", func() {
+			appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{ProcessGuid: "frontend"},
+				app_examiner.AppInfo{ProcessGuid: "backend"},
+			}, nil)
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "frontend"}, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"--all"})
+
+			Eventually(fakeLogReaders[1].GetAppGuid).ShouldNot(BeEmpty())
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(2))
+			Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("frontend"))
+			Expect(appExaminer.AppStatusArgsForCall(1)).To(Equal("backend"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+		})
+
+		It("stops streaming and shows the cursor on exit", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "my-app"}, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
+			Eventually(fakeLogReaders[0].GetAppGuid).Should(Equal("my-app"))
+
+			exitHandler.Exit(exit_codes.SigInt)
+
+			Expect(outputBuffer).To(test_helpers.Say(cursor.Show()))
+			Eventually(fakeLogReaders[0].IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("outputs errors getting the status of the apps", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New("App not found."))
+
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{"my-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error getting status of my-app: App not found."))
+			Expect(fakeLogReaders[0].GetAppGuid()).To(BeEmpty())
+		})
+
+		It("requires an app name or --all", func() {
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(0))
+		})
+
+		It("requires a positive rate", func() {
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{"--rate=0s", "my-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --rate must be positive"))
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(0))
+		})
 	})
 })
@@ -1,8 +1,8 @@
 package console_tailed_logs_outputter
 
 import (
-	"fmt"
-	"time"
+	"sort"
+	"sync"
 
 	"github.com/cloudfoundry/noaa/events"
 	"github.com/pivotal-cf-experimental/lattice-cli/colors"
@@ -12,42 +12,171 @@ import (
 
 type TailedLogsOutputter interface {
 	OutputTailedLogs(appGuid string)
+	OutputFilteredTailedLogs(appGuids []string, filter logs.LogFilter)
+	OutputRecentLogs(appGuids []string, filter logs.LogFilter)
+	ShipLogs(appGuids []string, filter logs.LogFilter, sinks []logs.LogSink)
 	StopOutputting()
 }
 
+type LogReaderFactory func() logs.LogReader
+
 type ConsoleTailedLogsOutputter struct {
-	outputChan chan string
-	output     *output.Output
-	logReader  logs.LogReader
+	outputChan       chan string
+	output           *output.Output
+	logReaderFactory LogReaderFactory
+
+	logReadersMutex sync.Mutex
+	logReaders      []logs.LogReader
+	stopped         bool
 }
 
-func NewConsoleTailedLogsOutputter(output *output.Output, logReader logs.LogReader) *ConsoleTailedLogsOutputter {
+func NewConsoleTailedLogsOutputter(output *output.Output, logReaderFactory LogReaderFactory) *ConsoleTailedLogsOutputter {
 	return &ConsoleTailedLogsOutputter{
-		outputChan: make(chan string, 10),
-		output:     output,
-		logReader:  logReader,
+		outputChan:       make(chan string, 10),
+		output:           output,
+		logReaderFactory: logReaderFactory,
 	}
 
 }
 
 func (ctlo *ConsoleTailedLogsOutputter) OutputTailedLogs(appGuid string) {
-	go ctlo.logReader.TailLogs(appGuid, ctlo.logCallback, ctlo.errorCallback)
+	ctlo.OutputFilteredTailedLogs([]string{appGuid}, logs.NewLogFilter())
+}
+
+// OutputFilteredTailedLogs multiplexes one log stream per app, each with its own
+// connection. With more than one app, every line is prefixed with its app's name.
+func (ctlo *ConsoleTailedLogsOutputter) OutputFilteredTailedLogs(appGuids []string, filter logs.LogFilter) {
+	for _, appGuid := range appGuids {
+		logReader, ok := ctlo.newLogReader()
+		if !ok {
+			return
+		}
+
+		prefix := appPrefix(appGuid, len(appGuids) > 1)
+		logCallback := func(log *events.LogMessage) {
+			if filter.Matches(log) {
+				ctlo.outputChan <- prefix + logs.FormatLog(log)
+			}
+		}
+		errorCallback := func(err error) {
+			ctlo.outputChan <- prefix + err.Error()
+		}
+
+		go logReader.TailLogs(appGuid, logCallback, errorCallback)
+	}
 
 	for log := range ctlo.outputChan {
 		ctlo.output.Say(log + "\n")
 	}
 }
 
+// OutputRecentLogs prints the logs doppler has buffered for the apps, merged by timestamp.
+func (ctlo *ConsoleTailedLogsOutputter) OutputRecentLogs(appGuids []string, filter logs.LogFilter) {
+	recentLogs := []prefixedLog{}
+
+	for _, appGuid := range appGuids {
+		logMessages, err := ctlo.logReaderFactory().RecentLogs(appGuid)
+		if err != nil {
+			ctlo.output.Say("Error fetching recent logs for " + appGuid + ": " + err.Error() + "\n")
+			continue
+		}
+
+		prefix := appPrefix(appGuid, len(appGuids) > 1)
+		for _, log := range logMessages {
+			if filter.Matches(log) {
+				recentLogs = append(recentLogs, prefixedLog{prefix, log})
+			}
+		}
+	}
+
+	sort.Stable(byTimestamp(recentLogs))
+
+	for _, recentLog := range recentLogs {
+		ctlo.output.Say(recentLog.prefix + logs.FormatLog(recentLog.log) + "\n")
+	}
+}
+
+// ShipLogs tails the apps like OutputFilteredTailedLogs but writes every log to
+// the sinks instead of the console. Errors are still reported on the console.
+func (ctlo *ConsoleTailedLogsOutputter) ShipLogs(appGuids []string, filter logs.LogFilter, sinks []logs.LogSink) {
+	shippedLogs := make(chan shippedLog, 10)
+
+	for _, appGuid := range appGuids {
+		logReader, ok := ctlo.newLogReader()
+		if !ok {
+			return
+		}
+
+		appGuid := appGuid
+		logCallback := func(log *events.LogMessage) {
+			if filter.Matches(log) {
+				shippedLogs <- shippedLog{appGuid, log}
+			}
+		}
+		errorCallback := func(err error) {
+			ctlo.outputChan <- appGuid + ": " + err.Error()
+		}
+
+		go logReader.TailLogs(appGuid, logCallback, errorCallback)
+	}
+
+	go func() {
+		for message := range ctlo.outputChan {
+			ctlo.output.Say(message + "\n")
+		}
+	}()
+
+	for shipped := range shippedLogs {
+		for _, sink := range sinks {
+			if err := sink.Write(shipped.appGuid, shipped.log); err != nil {
+				ctlo.outputChan <- "Error writing to log sink: " + err.Error()
+			}
+		}
+	}
+}
+
 func (ctlo *ConsoleTailedLogsOutputter) StopOutputting() {
-	ctlo.logReader.StopTailing()
+	ctlo.logReadersMutex.Lock()
+	defer ctlo.logReadersMutex.Unlock()
+
+	ctlo.stopped = true
+	for _, logReader := range ctlo.logReaders {
+		logReader.StopTailing()
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) newLogReader() (logs.LogReader, bool) {
+	ctlo.logReadersMutex.Lock()
+	defer ctlo.logReadersMutex.Unlock()
+
+	if ctlo.stopped {
+		return nil, false
+	}
+
+	logReader := ctlo.logReaderFactory()
+	ctlo.logReaders = append(ctlo.logReaders, logReader)
+	return logReader, true
 }
 
-func (ctlo *ConsoleTailedLogsOutputter) logCallback(log *events.LogMessage) {
-	timeString := time.Unix(0, log.GetTimestamp()).Format("02 Jan 15:04")
-	logOutput := fmt.Sprintf("%s [%s|%s] %s", colors.Cyan(timeString), colors.Yellow(log.GetSourceType()), colors.Yellow(log.GetSourceInstance()), log.GetMessage())
-	ctlo.outputChan <- logOutput
+func appPrefix(appGuid string, multipleApps bool) string {
+	if !multipleApps {
+		return ""
+	}
+	return colors.ColorForKey(appGuid, "["+appGuid+"]") + " "
 }
 
-func (ctlo *ConsoleTailedLogsOutputter) errorCallback(err error) {
-	ctlo.outputChan <- err.Error()
+type shippedLog struct {
+	appGuid string
+	log     *events.LogMessage
 }
+
+type prefixedLog struct {
+	prefix string
+	log    *events.LogMessage
+}
+
+type byTimestamp []prefixedLog
+
+func (b byTimestamp) Len() int           { return len(b) }
+func (b byTimestamp) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byTimestamp) Less(i, j int) bool { return b[i].log.GetTimestamp() < b[j].log.GetTimestamp() }
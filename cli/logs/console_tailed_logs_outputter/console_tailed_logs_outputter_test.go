@@ -3,6 +3,7 @@ package console_tailed_logs_outputter_test
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -11,12 +12,19 @@ import (
 
 	"github.com/cloudfoundry/noaa/events"
 	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/fake_log_reader"
 	"github.com/pivotal-cf-experimental/lattice-cli/output"
 	"github.com/pivotal-cf-experimental/lattice-cli/test_helpers"
 )
 
+func logReaderFactory(logReader logs.LogReader) console_tailed_logs_outputter.LogReaderFactory {
+	return func() logs.LogReader {
+		return logReader
+	}
+}
+
 var _ = Describe("ConsoleTailedLogsOutputter", func() {
 	var (
 		outputBuffer *gbytes.Buffer
@@ -28,7 +36,7 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 	Describe("OutputTailedLogs", func() {
 		It("Tails logs", func() {
 			logReader := fake_log_reader.NewFakeLogReader()
-			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReader)
+			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReaderFactory(logReader))
 
 			time := time.Now()
 			sourceType := "RTR"
@@ -54,12 +62,148 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 		})
 	})
 
+	Describe("OutputFilteredTailedLogs", func() {
+		It("only outputs logs that match the filter", func() {
+			logReader := fake_log_reader.NewFakeLogReader()
+			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReaderFactory(logReader))
+
+			unixTime := time.Now().UnixNano()
+			appSourceType, healthSourceType, sourceInstance := "APP", "HEALTH", "0"
+			logReader.AddLog(&events.LogMessage{Message: []byte("healthy"), Timestamp: &unixTime, SourceType: &healthSourceType, SourceInstance: &sourceInstance})
+			logReader.AddLog(&events.LogMessage{Message: []byte("app log"), Timestamp: &unixTime, SourceType: &appSourceType, SourceInstance: &sourceInstance})
+
+			filter := logs.NewLogFilter()
+			filter.SourceTypes = []string{"APP"}
+			go consoleTailedLogsOutputter.OutputFilteredTailedLogs([]string{"my-app-guid"}, filter)
+
+			Eventually(outputBuffer).Should(test_helpers.Say("app log\n"))
+			Expect(outputBuffer.Contents()).ToNot(ContainSubstring("healthy"))
+		})
+	})
+
+	Describe("OutputRecentLogs", func() {
+		var (
+			logReader                  *fake_log_reader.FakeLogReader
+			consoleTailedLogsOutputter *console_tailed_logs_outputter.ConsoleTailedLogsOutputter
+		)
+
+		BeforeEach(func() {
+			logReader = fake_log_reader.NewFakeLogReader()
+			consoleTailedLogsOutputter = console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReaderFactory(logReader))
+		})
+
+		It("outputs the recent logs that match the filter", func() {
+			unixTime := time.Now().UnixNano()
+			sourceType, firstInstance, secondInstance := "APP", "0", "1"
+			logReader.AddRecentLog(&events.LogMessage{Message: []byte("instance zero crashed"), Timestamp: &unixTime, SourceType: &sourceType, SourceInstance: &firstInstance})
+			logReader.AddRecentLog(&events.LogMessage{Message: []byte("instance one is fine"), Timestamp: &unixTime, SourceType: &sourceType, SourceInstance: &secondInstance})
+
+			filter := logs.NewLogFilter()
+			filter.Instance = 0
+			consoleTailedLogsOutputter.OutputRecentLogs([]string{"my-app-guid"}, filter)
+
+			Expect(logReader.GetAppGuid()).To(Equal("my-app-guid"))
+			Expect(outputBuffer).To(test_helpers.Say("instance zero crashed\n"))
+			Expect(outputBuffer.Contents()).ToNot(ContainSubstring("instance one is fine"))
+		})
+
+		It("outputs errors fetching the recent logs", func() {
+			logReader.SetRecentLogsError(errors.New("doppler is down"))
+
+			consoleTailedLogsOutputter.OutputRecentLogs([]string{"my-app-guid"}, logs.NewLogFilter())
+
+			Expect(outputBuffer).To(test_helpers.Say("Error fetching recent logs for my-app-guid: doppler is down\n"))
+		})
+	})
+
+	Describe("multiple apps", func() {
+		var (
+			logReaders                 map[string]*fake_log_reader.FakeLogReader
+			consoleTailedLogsOutputter *console_tailed_logs_outputter.ConsoleTailedLogsOutputter
+		)
+
+		addLog := func(appGuid, message string, timestamp int64) {
+			sourceType, sourceInstance := "APP", "0"
+			log := &events.LogMessage{Message: []byte(message), Timestamp: &timestamp, SourceType: &sourceType, SourceInstance: &sourceInstance}
+			logReaders[appGuid].AddLog(log)
+			logReaders[appGuid].AddRecentLog(log)
+		}
+
+		BeforeEach(func() {
+			logReaders = map[string]*fake_log_reader.FakeLogReader{
+				"frontend": fake_log_reader.NewFakeLogReader(),
+				"backend":  fake_log_reader.NewFakeLogReader(),
+			}
+			readerOrder := []*fake_log_reader.FakeLogReader{logReaders["frontend"], logReaders["backend"]}
+
+			var mutex sync.Mutex
+			consoleTailedLogsOutputter = console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), func() logs.LogReader {
+				mutex.Lock()
+				defer mutex.Unlock()
+				logReader := readerOrder[0]
+				readerOrder = readerOrder[1:]
+				return logReader
+			})
+
+			now := time.Now().UnixNano()
+			addLog("frontend", "frontend says hi", now+2)
+			addLog("backend", "backend says hi", now+1)
+		})
+
+		It("tails every app on its own log reader, prefixing lines with a stable color per app", func() {
+			go consoleTailedLogsOutputter.OutputFilteredTailedLogs([]string{"frontend", "backend"}, logs.NewLogFilter())
+
+			Eventually(logReaders["frontend"].GetAppGuid).Should(Equal("frontend"))
+			Eventually(logReaders["backend"].GetAppGuid).Should(Equal("backend"))
+
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.ColorForKey("frontend", "[frontend]") + " "))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring("frontend says hi"))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.ColorForKey("backend", "[backend]") + " "))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring("backend says hi"))
+
+			consoleTailedLogsOutputter.StopOutputting()
+
+			Eventually(logReaders["frontend"].IsLogTailStopped).Should(BeTrue())
+			Eventually(logReaders["backend"].IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("merges the recent logs of every app by timestamp", func() {
+			consoleTailedLogsOutputter.OutputRecentLogs([]string{"frontend", "backend"}, logs.NewLogFilter())
+
+			Expect(outputBuffer).To(test_helpers.Say("backend says hi"))
+			Expect(outputBuffer).To(test_helpers.Say("frontend says hi"))
+		})
+	})
+
+	Describe("ShipLogs", func() {
+		It("writes matching logs to every sink", func() {
+			logReader := fake_log_reader.NewFakeLogReader()
+			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReaderFactory(logReader))
+
+			unixTime := time.Now().UnixNano()
+			sourceType, sourceInstance := "APP", "0"
+			logReader.AddLog(&events.LogMessage{Message: []byte("ship me"), Timestamp: &unixTime, SourceType: &sourceType, SourceInstance: &sourceInstance})
+			logReader.AddError(errors.New("websocket hiccup"))
+
+			firstSinkBuffer, secondSinkBuffer := gbytes.NewBuffer(), gbytes.NewBuffer()
+			sinks := []logs.LogSink{logs.NewConsoleSink(output.New(firstSinkBuffer)), logs.NewConsoleSink(output.New(secondSinkBuffer))}
+
+			go consoleTailedLogsOutputter.ShipLogs([]string{"my-app-guid"}, logs.NewLogFilter(), sinks)
+
+			Eventually(firstSinkBuffer).Should(test_helpers.Say("ship me"))
+			Eventually(secondSinkBuffer).Should(test_helpers.Say("ship me"))
+			Eventually(outputBuffer).Should(test_helpers.Say("my-app-guid: websocket hiccup\n"))
+			Expect(outputBuffer.Contents()).ToNot(ContainSubstring("ship me"))
+		})
+	})
+
 	Describe("StopOutputting", func() {
 		It("stops outputting logs", func() {
 			logReader := fake_log_reader.NewFakeLogReader()
-			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReader)
+			consoleTailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(output.New(outputBuffer), logReaderFactory(logReader))
 
 			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+			Eventually(logReader.GetAppGuid).Should(Equal("my-app-guid"))
 
 			consoleTailedLogsOutputter.StopOutputting()
 
@@ -4,6 +4,7 @@ package fake_tailed_logs_outputter
 import (
 	"sync"
 
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
 	"github.com/pivotal-cf-experimental/lattice-cli/logs/console_tailed_logs_outputter"
 )
 
@@ -19,6 +20,25 @@ type FakeTailedLogsOutputter struct {
 	outputTailedLogsArgsForCall []struct {
 		appGuid string
 	}
+	OutputFilteredTailedLogsStub        func(appGuids []string, filter logs.LogFilter)
+	outputFilteredTailedLogsMutex       sync.RWMutex
+	outputFilteredTailedLogsArgsForCall []struct {
+		appGuids []string
+		filter   logs.LogFilter
+	}
+	OutputRecentLogsStub        func(appGuids []string, filter logs.LogFilter)
+	outputRecentLogsMutex       sync.RWMutex
+	outputRecentLogsArgsForCall []struct {
+		appGuids []string
+		filter   logs.LogFilter
+	}
+	ShipLogsStub        func(appGuids []string, filter logs.LogFilter, sinks []logs.LogSink)
+	shipLogsMutex       sync.RWMutex
+	shipLogsArgsForCall []struct {
+		appGuids []string
+		filter   logs.LogFilter
+		sinks    []logs.LogSink
+	}
 	StopOutputtingStub        func()
 	stopOutputtingMutex       sync.RWMutex
 	stopOutputtingArgsForCall []struct{}
@@ -49,6 +69,81 @@ func (fake *FakeTailedLogsOutputter) OutputTailedLogsArgsForCall(i int) string {
 	return fake.outputTailedLogsArgsForCall[i].appGuid
 }
 
+func (fake *FakeTailedLogsOutputter) OutputFilteredTailedLogs(appGuids []string, filter logs.LogFilter) {
+	fake.outputFilteredTailedLogsMutex.Lock()
+	fake.outputFilteredTailedLogsArgsForCall = append(fake.outputFilteredTailedLogsArgsForCall, struct {
+		appGuids []string
+		filter   logs.LogFilter
+	}{appGuids, filter})
+	fake.outputFilteredTailedLogsMutex.Unlock()
+	if fake.OutputFilteredTailedLogsStub != nil {
+		fake.OutputFilteredTailedLogsStub(appGuids, filter)
+	}
+	<-fake.stopChan
+}
+
+func (fake *FakeTailedLogsOutputter) OutputFilteredTailedLogsCallCount() int {
+	fake.outputFilteredTailedLogsMutex.RLock()
+	defer fake.outputFilteredTailedLogsMutex.RUnlock()
+	return len(fake.outputFilteredTailedLogsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) OutputFilteredTailedLogsArgsForCall(i int) ([]string, logs.LogFilter) {
+	fake.outputFilteredTailedLogsMutex.RLock()
+	defer fake.outputFilteredTailedLogsMutex.RUnlock()
+	return fake.outputFilteredTailedLogsArgsForCall[i].appGuids, fake.outputFilteredTailedLogsArgsForCall[i].filter
+}
+
+func (fake *FakeTailedLogsOutputter) OutputRecentLogs(appGuids []string, filter logs.LogFilter) {
+	fake.outputRecentLogsMutex.Lock()
+	fake.outputRecentLogsArgsForCall = append(fake.outputRecentLogsArgsForCall, struct {
+		appGuids []string
+		filter   logs.LogFilter
+	}{appGuids, filter})
+	fake.outputRecentLogsMutex.Unlock()
+	if fake.OutputRecentLogsStub != nil {
+		fake.OutputRecentLogsStub(appGuids, filter)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) OutputRecentLogsCallCount() int {
+	fake.outputRecentLogsMutex.RLock()
+	defer fake.outputRecentLogsMutex.RUnlock()
+	return len(fake.outputRecentLogsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) OutputRecentLogsArgsForCall(i int) ([]string, logs.LogFilter) {
+	fake.outputRecentLogsMutex.RLock()
+	defer fake.outputRecentLogsMutex.RUnlock()
+	return fake.outputRecentLogsArgsForCall[i].appGuids, fake.outputRecentLogsArgsForCall[i].filter
+}
+
+func (fake *FakeTailedLogsOutputter) ShipLogs(appGuids []string, filter logs.LogFilter, sinks []logs.LogSink) {
+	fake.shipLogsMutex.Lock()
+	fake.shipLogsArgsForCall = append(fake.shipLogsArgsForCall, struct {
+		appGuids []string
+		filter   logs.LogFilter
+		sinks    []logs.LogSink
+	}{appGuids, filter, sinks})
+	fake.shipLogsMutex.Unlock()
+	if fake.ShipLogsStub != nil {
+		fake.ShipLogsStub(appGuids, filter, sinks)
+	}
+	<-fake.stopChan
+}
+
+func (fake *FakeTailedLogsOutputter) ShipLogsCallCount() int {
+	fake.shipLogsMutex.RLock()
+	defer fake.shipLogsMutex.RUnlock()
+	return len(fake.shipLogsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) ShipLogsArgsForCall(i int) ([]string, logs.LogFilter, []logs.LogSink) {
+	fake.shipLogsMutex.RLock()
+	defer fake.shipLogsMutex.RUnlock()
+	return fake.shipLogsArgsForCall[i].appGuids, fake.shipLogsArgsForCall[i].filter, fake.shipLogsArgsForCall[i].sinks
+}
+
 func (fake *FakeTailedLogsOutputter) StopOutputting() {
 	fake.stopOutputtingMutex.Lock()
 	fake.stopOutputtingArgsForCall = append(fake.stopOutputtingArgsForCall, struct{}{})
@@ -9,9 +9,12 @@ type FakeLogReader struct {
 	sync.RWMutex
 	stopChan       chan struct{}
 	logs           []*events.LogMessage
+	metrics        []*events.ContainerMetric
 	errors         []error
 	logTailStopped bool
 	appGuid        string
+	recentLogs     []*events.LogMessage
+	recentLogsErr  error
 }
 
 func NewFakeLogReader() *FakeLogReader {
@@ -44,6 +47,30 @@ func (f *FakeLogReader) TailLogs(appGuid string, logCallback func(*events.LogMes
 	}()
 }
 
+func (f *FakeLogReader) TailContainerMetrics(appGuid string, metricCallback func(*events.ContainerMetric), errorCallback func(error)) {
+	for _, metric := range f.metrics {
+		metricCallback(metric)
+	}
+
+	for _, err := range f.errors {
+		errorCallback(err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.appGuid = appGuid
+
+	go func() {
+		select {
+		case <-f.stopChan:
+			f.Lock()
+			defer f.Unlock()
+			f.logTailStopped = true
+			return
+		}
+	}()
+}
+
 func (f *FakeLogReader) StopTailing() {
 	f.stopChan <- struct{}{}
 	close(f.stopChan)
@@ -65,6 +92,25 @@ func (f *FakeLogReader) AddLog(log *events.LogMessage) {
 	f.logs = append(f.logs, log)
 }
 
+func (f *FakeLogReader) AddContainerMetric(metric *events.ContainerMetric) {
+	f.metrics = append(f.metrics, metric)
+}
+
 func (f *FakeLogReader) AddError(err error) {
 	f.errors = append(f.errors, err)
 }
+
+func (f *FakeLogReader) RecentLogs(appGuid string) ([]*events.LogMessage, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.appGuid = appGuid
+	return f.recentLogs, f.recentLogsErr
+}
+
+func (f *FakeLogReader) AddRecentLog(log *events.LogMessage) {
+	f.recentLogs = append(f.recentLogs, log)
+}
+
+func (f *FakeLogReader) SetRecentLogsError(err error) {
+	f.recentLogsErr = err
+}
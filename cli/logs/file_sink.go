@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+const (
+	DefaultMaxFileBytes   = 10 * 1024 * 1024
+	DefaultMaxFileBackups = 5
+)
+
+// fileSink writes each app instance's logs to DIR/APP/INSTANCE.log, rotating
+// a file to INSTANCE.log.1, INSTANCE.log.2, ... once it grows past maxBytes.
+type fileSink struct {
+	sync.Mutex
+	dir        string
+	maxBytes   int64
+	maxBackups int
+	files      map[string]*os.File
+}
+
+func NewFileSink(dir string, maxBytes int64, maxBackups int) LogSink {
+	return &fileSink{
+		dir:        dir,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+		files:      make(map[string]*os.File),
+	}
+}
+
+func (f *fileSink) Write(appGuid string, log *events.LogMessage) error {
+	f.Lock()
+	defer f.Unlock()
+
+	path := filepath.Join(f.dir, appGuid, instanceFileName(log))
+
+	file, err := f.openFile(path)
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%s [%s|%s] %s\n", time.Unix(0, log.GetTimestamp()).UTC().Format(time.RFC3339Nano), log.GetSourceType(), log.GetSourceInstance(), log.GetMessage())
+	if _, err := file.WriteString(line); err != nil {
+		return err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if fileInfo.Size() >= f.maxBytes {
+		return f.rotate(path)
+	}
+
+	return nil
+}
+
+func (f *fileSink) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	var firstErr error
+	for path, file := range f.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(f.files, path)
+	}
+	return firstErr
+}
+
+func (f *fileSink) openFile(path string) (*os.File, error) {
+	if file, ok := f.files[path]; ok {
+		return file, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	f.files[path] = file
+	return file, nil
+}
+
+func (f *fileSink) rotate(path string) error {
+	if err := f.files[path].Close(); err != nil {
+		return err
+	}
+	delete(f.files, path)
+
+	os.Remove(fmt.Sprintf("%s.%d", path, f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+
+	if f.maxBackups < 1 {
+		return os.Remove(path)
+	}
+	return os.Rename(path, path+".1")
+}
+
+func instanceFileName(log *events.LogMessage) string {
+	instance := log.GetSourceInstance()
+	if instance == "" {
+		instance = "unknown"
+	}
+	return log.GetSourceType() + "-" + filepath.Base(instance) + ".log"
+}
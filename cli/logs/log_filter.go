@@ -0,0 +1,52 @@
+package logs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+const AllInstances = -1
+
+type LogFilter struct {
+	SourceTypes []string
+	Instance    int
+	Since       time.Time
+	Grep        *regexp.Regexp
+}
+
+func NewLogFilter() LogFilter {
+	return LogFilter{Instance: AllInstances}
+}
+
+func (f LogFilter) Matches(log *events.LogMessage) bool {
+	if len(f.SourceTypes) > 0 && !f.matchesSourceType(log.GetSourceType()) {
+		return false
+	}
+
+	if f.Instance != AllInstances && log.GetSourceInstance() != strconv.Itoa(f.Instance) {
+		return false
+	}
+
+	if !f.Since.IsZero() && time.Unix(0, log.GetTimestamp()).Before(f.Since) {
+		return false
+	}
+
+	if f.Grep != nil && !f.Grep.Match(log.GetMessage()) {
+		return false
+	}
+
+	return true
+}
+
+func (f LogFilter) matchesSourceType(sourceType string) bool {
+	for _, filterSourceType := range f.SourceTypes {
+		if strings.EqualFold(filterSourceType, sourceType) {
+			return true
+		}
+	}
+	return false
+}
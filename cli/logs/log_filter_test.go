@@ -0,0 +1,70 @@
+package logs_test
+
+import (
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
+)
+
+var _ = Describe("LogFilter", func() {
+	var (
+		now        time.Time
+		logMessage *events.LogMessage
+		filter     logs.LogFilter
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		timestamp := now.UnixNano()
+		sourceType := "APP"
+		sourceInstance := "2"
+		logMessage = &events.LogMessage{
+			Message:        []byte("panic: out of coffee"),
+			Timestamp:      &timestamp,
+			SourceType:     &sourceType,
+			SourceInstance: &sourceInstance,
+		}
+		filter = logs.NewLogFilter()
+	})
+
+	It("matches everything by default", func() {
+		Expect(filter.Matches(logMessage)).To(BeTrue())
+	})
+
+	It("filters by source type, ignoring case", func() {
+		filter.SourceTypes = []string{"health", "app"}
+		Expect(filter.Matches(logMessage)).To(BeTrue())
+
+		filter.SourceTypes = []string{"HEALTH"}
+		Expect(filter.Matches(logMessage)).To(BeFalse())
+	})
+
+	It("filters by instance index", func() {
+		filter.Instance = 2
+		Expect(filter.Matches(logMessage)).To(BeTrue())
+
+		filter.Instance = 0
+		Expect(filter.Matches(logMessage)).To(BeFalse())
+	})
+
+	It("filters out logs older than since", func() {
+		filter.Since = now.Add(-time.Minute)
+		Expect(filter.Matches(logMessage)).To(BeTrue())
+
+		filter.Since = now.Add(time.Minute)
+		Expect(filter.Matches(logMessage)).To(BeFalse())
+	})
+
+	It("filters by a regular expression on the message", func() {
+		filter.Grep = regexp.MustCompile("out of (coffee|tea)")
+		Expect(filter.Matches(logMessage)).To(BeTrue())
+
+		filter.Grep = regexp.MustCompile("^ok")
+		Expect(filter.Matches(logMessage)).To(BeFalse())
+	})
+})
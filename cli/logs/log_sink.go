@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+)
+
+const LogTimeDisplayLayout = "02 Jan 15:04"
+
+type LogSink interface {
+	Write(appGuid string, log *events.LogMessage) error
+	Close() error
+}
+
+// NewLogSink builds a sink from a --sink spec: console, file:DIR, json:PATH (json:- for stdout),
+// syslog://HOST:PORT (UDP) or syslog+tcp://HOST:PORT.
+func NewLogSink(spec string, output *output.Output) (LogSink, error) {
+	switch {
+	case spec == "console":
+		return NewConsoleSink(output), nil
+	case strings.HasPrefix(spec, "file:"):
+		return NewFileSink(strings.TrimPrefix(spec, "file:"), DefaultMaxFileBytes, DefaultMaxFileBackups), nil
+	case spec == "json:-":
+		return NewJSONSink(nopCloser{output}), nil
+	case strings.HasPrefix(spec, "json:"):
+		file, err := os.OpenFile(strings.TrimPrefix(spec, "json:"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return NewJSONSink(file), nil
+	case strings.HasPrefix(spec, "syslog://"):
+		return NewSyslogSink("udp", strings.TrimPrefix(spec, "syslog://"))
+	case strings.HasPrefix(spec, "syslog+udp://"):
+		return NewSyslogSink("udp", strings.TrimPrefix(spec, "syslog+udp://"))
+	case strings.HasPrefix(spec, "syslog+tcp://"):
+		return NewSyslogSink("tcp", strings.TrimPrefix(spec, "syslog+tcp://"))
+	}
+
+	return nil, errors.New("Invalid log sink: " + spec + ". Must be console, file:DIR, json:PATH, syslog://HOST:PORT or syslog+tcp://HOST:PORT")
+}
+
+func FormatLog(log *events.LogMessage) string {
+	timeString := time.Unix(0, log.GetTimestamp()).Format(LogTimeDisplayLayout)
+	return fmt.Sprintf("%s [%s|%s] %s", colors.Cyan(timeString), colors.Yellow(log.GetSourceType()), colors.Yellow(log.GetSourceInstance()), log.GetMessage())
+}
+
+type consoleSink struct {
+	output *output.Output
+}
+
+func NewConsoleSink(output *output.Output) LogSink {
+	return &consoleSink{output}
+}
+
+func (c *consoleSink) Write(appGuid string, log *events.LogMessage) error {
+	c.output.Say(colors.ColorForKey(appGuid, "["+appGuid+"]") + " " + FormatLog(log) + "\n")
+	return nil
+}
+
+func (c *consoleSink) Close() error {
+	return nil
+}
+
+type jsonLog struct {
+	App            string    `json:"app"`
+	Timestamp      time.Time `json:"timestamp"`
+	SourceType     string    `json:"source_type"`
+	SourceInstance string    `json:"source_instance"`
+	MessageType    string    `json:"message_type"`
+	Message        string    `json:"message"`
+}
+
+type jsonSink struct {
+	sync.Mutex
+	writer  io.WriteCloser
+	encoder *json.Encoder
+}
+
+func NewJSONSink(writer io.WriteCloser) LogSink {
+	return &jsonSink{writer: writer, encoder: json.NewEncoder(writer)}
+}
+
+func (j *jsonSink) Write(appGuid string, log *events.LogMessage) error {
+	j.Lock()
+	defer j.Unlock()
+
+	return j.encoder.Encode(jsonLog{
+		App:            appGuid,
+		Timestamp:      time.Unix(0, log.GetTimestamp()).UTC(),
+		SourceType:     log.GetSourceType(),
+		SourceInstance: log.GetSourceInstance(),
+		MessageType:    log.GetMessageType().String(),
+		Message:        string(log.GetMessage()),
+	})
+}
+
+func (j *jsonSink) Close() error {
+	return j.writer.Close()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,194 @@
+package logs_test
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-cf-experimental/lattice-cli/colors"
+	"github.com/pivotal-cf-experimental/lattice-cli/logs"
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+)
+
+type closingBuffer struct {
+	*gbytes.Buffer
+	closed bool
+}
+
+func (c *closingBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+var _ = Describe("LogSinks", func() {
+	var (
+		timestamp  time.Time
+		logMessage *events.LogMessage
+	)
+
+	BeforeEach(func() {
+		timestamp = time.Date(2015, 3, 4, 5, 6, 7, 0, time.UTC)
+		unixTime := timestamp.UnixNano()
+		sourceType := "APP"
+		sourceInstance := "1"
+		messageType := events.LogMessage_ERR
+		logMessage = &events.LogMessage{
+			Message:        []byte("out of coffee"),
+			MessageType:    &messageType,
+			Timestamp:      &unixTime,
+			SourceType:     &sourceType,
+			SourceInstance: &sourceInstance,
+		}
+	})
+
+	Describe("NewLogSink", func() {
+		It("rejects unknown sinks", func() {
+			_, err := logs.NewLogSink("carrier-pigeon", output.New(gbytes.NewBuffer()))
+
+			Expect(err).To(MatchError("Invalid log sink: carrier-pigeon. Must be console, file:DIR, json:PATH, syslog://HOST:PORT or syslog+tcp://HOST:PORT"))
+		})
+
+		It("builds a console sink", func() {
+			outputBuffer := gbytes.NewBuffer()
+			sink, err := logs.NewLogSink("console", output.New(outputBuffer))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+
+			Expect(outputBuffer.Contents()).To(ContainSubstring(colors.ColorForKey("my-app", "[my-app]") + " " + logs.FormatLog(logMessage) + "\n"))
+		})
+
+		It("returns errors connecting to a syslog drain", func() {
+			_, err := logs.NewLogSink("syslog+tcp://127.0.0.1:1", output.New(gbytes.NewBuffer()))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("jsonSink", func() {
+		It("writes newline-delimited JSON", func() {
+			buffer := &closingBuffer{Buffer: gbytes.NewBuffer()}
+			sink := logs.NewJSONSink(buffer)
+
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+
+			lines := bufio.NewScanner(buffer)
+			Expect(lines.Scan()).To(BeTrue())
+			Expect(lines.Text()).To(MatchJSON(`{
+				"app": "my-app",
+				"timestamp": "2015-03-04T05:06:07Z",
+				"source_type": "APP",
+				"source_instance": "1",
+				"message_type": "ERR",
+				"message": "out of coffee"
+			}`))
+			Expect(lines.Scan()).To(BeTrue())
+
+			Expect(sink.Close()).To(Succeed())
+			Expect(buffer.closed).To(BeTrue())
+		})
+	})
+
+	Describe("fileSink", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "file_sink")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("writes each app instance to its own file", func() {
+			sink := logs.NewFileSink(tmpDir, logs.DefaultMaxFileBytes, logs.DefaultMaxFileBackups)
+
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+			Expect(sink.Close()).To(Succeed())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "my-app", "APP-1.log"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("2015-03-04T05:06:07Z [APP|1] out of coffee\n"))
+		})
+
+		It("rotates files once they grow too big", func() {
+			sink := logs.NewFileSink(tmpDir, 10, 2)
+
+			for i := 0; i < 4; i++ {
+				Expect(sink.Write("my-app", logMessage)).To(Succeed())
+			}
+			Expect(sink.Close()).To(Succeed())
+
+			logFile := filepath.Join(tmpDir, "my-app", "APP-1.log")
+			_, err := os.Stat(logFile + ".1")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = os.Stat(logFile + ".2")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = os.Stat(logFile + ".3")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("syslogSink", func() {
+		It("formats RFC5424 messages", func() {
+			Expect(logs.FormatSyslogMessage("my-host", "my-app", logMessage)).To(Equal("<11>1 2015-03-04T05:06:07Z my-host my-app [APP/1] - - out of coffee\n"))
+		})
+
+		It("sends a datagram per log over UDP", func() {
+			listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+
+			sink, err := logs.NewSyslogSink("udp", listener.LocalAddr().String())
+			Expect(err).ToNot(HaveOccurred())
+			defer sink.Close()
+
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+
+			buffer := make([]byte, 1024)
+			listener.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := listener.ReadFrom(buffer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buffer[:n])).To(MatchRegexp(`^<11>1 2015-03-04T05:06:07Z \S+ my-app \[APP/1\] - - out of coffee\n$`))
+		})
+
+		It("frames messages with octet counting over TCP", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+
+			received := make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+				conn, err := listener.Accept()
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				Expect(err).ToNot(HaveOccurred())
+				received <- line
+			}()
+
+			sink, err := logs.NewSyslogSink("tcp", listener.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			defer sink.Close()
+
+			Expect(sink.Write("my-app", logMessage)).To(Succeed())
+
+			var line string
+			Eventually(received).Should(Receive(&line))
+			Expect(line).To(MatchRegexp(`^\d+ <11>1 2015-03-04T05:06:07Z \S+ my-app \[APP/1\] - - out of coffee\n$`))
+		})
+	})
+})
@@ -1,21 +1,33 @@
 package logs
 
 import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/cloudfoundry/noaa/events"
 )
 
 type LogReader interface {
 	TailLogs(appGuid string, logCallback func(*events.LogMessage), errorCallback func(error))
+	TailContainerMetrics(appGuid string, metricCallback func(*events.ContainerMetric), errorCallback func(error))
 	StopTailing()
+	RecentLogs(appGuid string) ([]*events.LogMessage, error)
 }
 
 type logConsumer interface {
 	TailingLogs(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error, stopChan chan struct{})
+	Stream(appGuid string, authToken string, outputChan chan<- *events.Envelope, errorChan chan<- error, stopChan chan struct{})
+	RecentLogs(appGuid string, authToken string) ([]*events.LogMessage, error)
 }
 
+const ReconnectDelay = time.Second
+
 type logReader struct {
 	consumer logConsumer
 	stopChan chan struct{}
+	stopOnce sync.Once
 }
 
 func NewLogReader(consumer logConsumer) LogReader {
@@ -25,31 +37,155 @@ func NewLogReader(consumer logConsumer) LogReader {
 	}
 }
 
+// TailLogs streams logs until StopTailing is called, reconnecting after
+// ReconnectDelay whenever the consumer's connection drops.
 func (l *logReader) TailLogs(appGuid string, logCallback func(*events.LogMessage), errorCallback func(error)) {
-	outputChan := make(chan *events.LogMessage, 10)
-	errorChan := make(chan error, 10)
+	l.tail("logs for "+appGuid, errorCallback, func() bool {
+		outputChan := make(chan *events.LogMessage, 10)
+		errorChan := make(chan error, 10)
+		consumerDone := make(chan struct{})
+
+		go func() {
+			l.consumer.TailingLogs(appGuid, "", outputChan, errorChan, l.stopChan)
+			close(consumerDone)
+		}()
+
+		return l.readChannels(outputChan, errorChan, consumerDone, logCallback, errorCallback)
+	})
+}
 
-	go l.consumer.TailingLogs(appGuid, "", outputChan, errorChan, l.stopChan)
+// TailContainerMetrics streams the CPU, memory and disk usage doppler reports for each
+// instance of the app until StopTailing is called, skipping every other kind of envelope.
+func (l *logReader) TailContainerMetrics(appGuid string, metricCallback func(*events.ContainerMetric), errorCallback func(error)) {
+	l.tail("container metrics for "+appGuid, errorCallback, func() bool {
+		envelopeChan := make(chan *events.Envelope, 10)
+		errorChan := make(chan error, 10)
+		consumerDone := make(chan struct{})
 
-	l.readChannels(outputChan, errorChan, logCallback, errorCallback)
+		go func() {
+			l.consumer.Stream(appGuid, "", envelopeChan, errorChan, l.stopChan)
+			close(consumerDone)
+		}()
 
-	close(l.stopChan)
-	close(outputChan)
+		return l.readEnvelopes(envelopeChan, errorChan, consumerDone, metricCallback, errorCallback)
+	})
+}
+
+// tail calls connect until it reports that StopTailing was called, waiting
+// ReconnectDelay between connections.
+func (l *logReader) tail(description string, errorCallback func(error), connect func() (stopped bool)) {
+	for {
+		if stopped := connect(); stopped {
+			return
+		}
+
+		select {
+		case <-l.stopChan:
+			return
+		default:
+		}
+
+		errorCallback(errors.New("Lost connection to the " + description + ", reconnecting..."))
+
+		select {
+		case <-l.stopChan:
+			return
+		case <-time.After(ReconnectDelay):
+		}
+	}
 }
 
 func (l *logReader) StopTailing() {
-	l.stopChan <- struct{}{}
+	l.stopOnce.Do(func() {
+		close(l.stopChan)
+	})
 }
 
-func (l *logReader) readChannels(outputChan <-chan *events.LogMessage, errorChan <-chan error, logCallback func(*events.LogMessage), errorCallback func(error)) {
+func (l *logReader) readChannels(outputChan <-chan *events.LogMessage, errorChan <-chan error, consumerDone <-chan struct{}, logCallback func(*events.LogMessage), errorCallback func(error)) (stopped bool) {
 	for {
 		select {
 		case <-l.stopChan:
-			return
-		case err := <-errorChan:
-			errorCallback(err)
-		case logMessage := <-outputChan:
-			logCallback(logMessage)
+			return true
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+			} else if err != nil {
+				errorCallback(err)
+			}
+		case logMessage, ok := <-outputChan:
+			if !ok {
+				outputChan = nil
+			} else {
+				logCallback(logMessage)
+			}
+		case <-consumerDone:
+			for {
+				select {
+				case logMessage, ok := <-outputChan:
+					if !ok {
+						return false
+					}
+					logCallback(logMessage)
+				default:
+					return false
+				}
+			}
 		}
 	}
 }
+
+func (l *logReader) readEnvelopes(envelopeChan <-chan *events.Envelope, errorChan <-chan error, consumerDone <-chan struct{}, metricCallback func(*events.ContainerMetric), errorCallback func(error)) (stopped bool) {
+	sendMetric := func(envelope *events.Envelope) {
+		if envelope.GetEventType() == events.Envelope_ContainerMetric {
+			metricCallback(envelope.GetContainerMetric())
+		}
+	}
+
+	for {
+		select {
+		case <-l.stopChan:
+			return true
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+			} else if err != nil {
+				errorCallback(err)
+			}
+		case envelope, ok := <-envelopeChan:
+			if !ok {
+				envelopeChan = nil
+			} else {
+				sendMetric(envelope)
+			}
+		case <-consumerDone:
+			for {
+				select {
+				case envelope, ok := <-envelopeChan:
+					if !ok {
+						return false
+					}
+					sendMetric(envelope)
+				default:
+					return false
+				}
+			}
+		}
+	}
+}
+
+// RecentLogs returns the logs doppler has buffered for the app, oldest first.
+func (l *logReader) RecentLogs(appGuid string) ([]*events.LogMessage, error) {
+	logMessages, err := l.consumer.RecentLogs(appGuid, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Stable(byTimestamp(logMessages))
+	return logMessages, nil
+}
+
+type byTimestamp []*events.LogMessage
+
+func (b byTimestamp) Len() int           { return len(b) }
+func (b byTimestamp) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byTimestamp) Less(i, j int) bool { return b[i].GetTimestamp() < b[j].GetTimestamp() }
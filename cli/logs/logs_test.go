@@ -3,6 +3,7 @@ package logs_test
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -13,22 +14,48 @@ import (
 
 func NewFakeConsumer() *fakeConsumer {
 	return &fakeConsumer{
-		inboundLogStream:   make(chan *events.LogMessage),
-		inboundErrorStream: make(chan error),
+		inboundLogStream:      make(chan *events.LogMessage),
+		inboundEnvelopeStream: make(chan *events.Envelope),
+		inboundErrorStream:    make(chan error),
+		disconnect:            make(chan struct{}),
 	}
 }
 
 type fakeConsumer struct {
-	inboundLogStream   chan *events.LogMessage
-	inboundErrorStream chan error
+	inboundLogStream      chan *events.LogMessage
+	inboundEnvelopeStream chan *events.Envelope
+	inboundErrorStream    chan error
+	recentLogs            []*events.LogMessage
+	recentLogsError       error
+	recentLogsAppGuid     string
+	disconnect            chan struct{}
+	tailingLogsCalls      int32
+	streamCalls           int32
+	streamAppGuid         atomic.Value
+}
+
+func (consumer *fakeConsumer) StreamCallCount() int {
+	return int(atomic.LoadInt32(&consumer.streamCalls))
+}
+
+func (consumer *fakeConsumer) TailingLogsCallCount() int {
+	return int(atomic.LoadInt32(&consumer.tailingLogsCalls))
+}
+
+func (consumer *fakeConsumer) RecentLogs(appGuid string, authToken string) ([]*events.LogMessage, error) {
+	consumer.recentLogsAppGuid = appGuid
+	return consumer.recentLogs, consumer.recentLogsError
 }
 
 func (consumer *fakeConsumer) TailingLogs(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error, stopChan chan struct{}) {
+	atomic.AddInt32(&consumer.tailingLogsCalls, 1)
 	for {
 		select {
 		case <-stopChan:
 			defer close(errorChan)
 			return
+		case <-consumer.disconnect:
+			return
 		case err := <-consumer.inboundErrorStream:
 			errorChan <- err
 		case logMessage := <-consumer.inboundLogStream:
@@ -37,6 +64,28 @@ func (consumer *fakeConsumer) TailingLogs(appGuid string, authToken string, outp
 	}
 }
 
+func (consumer *fakeConsumer) Stream(appGuid string, authToken string, outputChan chan<- *events.Envelope, errorChan chan<- error, stopChan chan struct{}) {
+	consumer.streamAppGuid.Store(appGuid)
+	atomic.AddInt32(&consumer.streamCalls, 1)
+	for {
+		select {
+		case <-stopChan:
+			defer close(errorChan)
+			return
+		case <-consumer.disconnect:
+			return
+		case err := <-consumer.inboundErrorStream:
+			errorChan <- err
+		case envelope := <-consumer.inboundEnvelopeStream:
+			outputChan <- envelope
+		}
+	}
+}
+
+func (consumer *fakeConsumer) sendToInboundEnvelopeStream(envelope *events.Envelope) {
+	consumer.inboundEnvelopeStream <- envelope
+}
+
 func (consumer *fakeConsumer) sendToInboundLogStream(logMessage *events.LogMessage) {
 	consumer.inboundLogStream <- logMessage
 }
@@ -62,6 +111,23 @@ func (mr *MessageReceiver) GetMessages() []*events.LogMessage {
 	return mr.receivedMessages
 }
 
+type MetricReceiver struct {
+	sync.RWMutex
+	receivedMetrics []*events.ContainerMetric
+}
+
+func (mr *MetricReceiver) AppendMetric(metric *events.ContainerMetric) {
+	defer mr.Unlock()
+	mr.Lock()
+	mr.receivedMetrics = append(mr.receivedMetrics, metric)
+}
+
+func (mr *MetricReceiver) GetMetrics() []*events.ContainerMetric {
+	defer mr.RUnlock()
+	mr.RLock()
+	return mr.receivedMetrics
+}
+
 type ErrorReceiver struct {
 	sync.RWMutex
 	receivedErrors []error
@@ -144,6 +210,130 @@ var _ = Describe("logs", func() {
 			go consumer.sendToInboundErrorStream(errorThree)
 			Consistently(errorReceiver.GetErrors).ShouldNot(ContainElement(errorThree))
 		})
+
+		It("reconnects when the connection drops", func() {
+			messageReceiver := &MessageReceiver{}
+			errorReceiver := &ErrorReceiver{}
+
+			go logReader.TailLogs("app-guid", messageReceiver.AppendMessage, errorReceiver.AppendError)
+
+			Eventually(consumer.TailingLogsCallCount).Should(Equal(1))
+			consumer.disconnect <- struct{}{}
+
+			Eventually(errorReceiver.GetErrors).Should(Equal([]error{errors.New("Lost connection to the logs for app-guid, reconnecting...")}))
+			Eventually(consumer.TailingLogsCallCount, 2*logs.ReconnectDelay).Should(Equal(2))
+
+			logMessage := &events.LogMessage{Message: []byte("Back again")}
+			go consumer.sendToInboundLogStream(logMessage)
+			Eventually(messageReceiver.GetMessages).Should(Equal([]*events.LogMessage{logMessage}))
+
+			logReader.StopTailing()
+		})
+	})
+
+	Describe("TailContainerMetrics", func() {
+		var (
+			consumer  *fakeConsumer
+			logReader logs.LogReader
+		)
+
+		BeforeEach(func() {
+			consumer = NewFakeConsumer()
+			logReader = logs.NewLogReader(consumer)
+		})
+
+		containerMetricEnvelope := func(instanceIndex int32) (*events.Envelope, *events.ContainerMetric) {
+			eventType := events.Envelope_ContainerMetric
+			metric := &events.ContainerMetric{InstanceIndex: &instanceIndex}
+			return &events.Envelope{EventType: &eventType, ContainerMetric: metric}, metric
+		}
+
+		It("provides the metricCallback with the container metrics until StopTailing is called", func() {
+			metricReceiver := &MetricReceiver{}
+
+			go logReader.TailContainerMetrics("app-guid", metricReceiver.AppendMetric, func(error) {})
+
+			Eventually(consumer.StreamCallCount).Should(Equal(1))
+			Expect(consumer.streamAppGuid.Load()).To(Equal("app-guid"))
+
+			envelopeOne, metricOne := containerMetricEnvelope(0)
+			go consumer.sendToInboundEnvelopeStream(envelopeOne)
+			Eventually(metricReceiver.GetMetrics).Should(Equal([]*events.ContainerMetric{metricOne}))
+
+			logReader.StopTailing()
+
+			envelopeTwo, metricTwo := containerMetricEnvelope(1)
+			go consumer.sendToInboundEnvelopeStream(envelopeTwo)
+			Consistently(metricReceiver.GetMetrics).ShouldNot(ContainElement(metricTwo))
+		})
+
+		It("skips envelopes that are not container metrics", func() {
+			metricReceiver := &MetricReceiver{}
+
+			go logReader.TailContainerMetrics("app-guid", metricReceiver.AppendMetric, func(error) {})
+
+			logEventType := events.Envelope_LogMessage
+			go consumer.sendToInboundEnvelopeStream(&events.Envelope{EventType: &logEventType, LogMessage: &events.LogMessage{Message: []byte("hi")}})
+
+			envelope, metric := containerMetricEnvelope(0)
+			go consumer.sendToInboundEnvelopeStream(envelope)
+			Eventually(metricReceiver.GetMetrics).Should(Equal([]*events.ContainerMetric{metric}))
+
+			logReader.StopTailing()
+		})
+
+		It("reconnects when the connection drops", func() {
+			metricReceiver := &MetricReceiver{}
+			errorReceiver := &ErrorReceiver{}
+
+			go logReader.TailContainerMetrics("app-guid", metricReceiver.AppendMetric, errorReceiver.AppendError)
+
+			Eventually(consumer.StreamCallCount).Should(Equal(1))
+			consumer.disconnect <- struct{}{}
+
+			Eventually(errorReceiver.GetErrors).Should(Equal([]error{errors.New("Lost connection to the container metrics for app-guid, reconnecting...")}))
+			Eventually(consumer.StreamCallCount, 2*logs.ReconnectDelay).Should(Equal(2))
+
+			envelope, metric := containerMetricEnvelope(0)
+			go consumer.sendToInboundEnvelopeStream(envelope)
+			Eventually(metricReceiver.GetMetrics).Should(Equal([]*events.ContainerMetric{metric}))
+
+			logReader.StopTailing()
+		})
+	})
+
+	Describe("RecentLogs", func() {
+		var (
+			consumer  *fakeConsumer
+			logReader logs.LogReader
+		)
+
+		BeforeEach(func() {
+			consumer = NewFakeConsumer()
+			logReader = logs.NewLogReader(consumer)
+		})
+
+		It("returns the recent logs sorted by timestamp", func() {
+			first, second, third := int64(100), int64(200), int64(300)
+			logMessageOne := &events.LogMessage{Message: []byte("Message 1"), Timestamp: &first}
+			logMessageTwo := &events.LogMessage{Message: []byte("Message 2"), Timestamp: &second}
+			logMessageThree := &events.LogMessage{Message: []byte("Message 3"), Timestamp: &third}
+			consumer.recentLogs = []*events.LogMessage{logMessageThree, logMessageOne, logMessageTwo}
+
+			recentLogs, err := logReader.RecentLogs("app-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(consumer.recentLogsAppGuid).To(Equal("app-guid"))
+			Expect(recentLogs).To(Equal([]*events.LogMessage{logMessageOne, logMessageTwo, logMessageThree}))
+		})
+
+		It("returns errors from the consumer", func() {
+			consumer.recentLogsError = errors.New("doppler is down")
+
+			_, err := logReader.RecentLogs("app-guid")
+
+			Expect(err).To(MatchError("doppler is down"))
+		})
 	})
 
 })
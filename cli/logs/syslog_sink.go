@@ -0,0 +1,111 @@
+package logs
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+const (
+	syslogFacilityUser = 1
+	syslogSeverityErr  = 3
+	syslogSeverityInfo = 6
+	syslogAppNameLimit = 48
+)
+
+// syslogSink forwards logs to a syslog drain as RFC5424 messages. Over TCP,
+// messages are framed with octet counting as described in RFC6587.
+type syslogSink struct {
+	sync.Mutex
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+func NewSyslogSink(network, address string) (LogSink, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	sink := &syslogSink{network: network, address: address, hostname: hostname}
+	if err := sink.connect(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+func (s *syslogSink) Write(appGuid string, log *events.LogMessage) error {
+	s.Lock()
+	defer s.Unlock()
+
+	message := FormatSyslogMessage(s.hostname, appGuid, log)
+	if s.network == "tcp" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.conn.Write([]byte(message)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *syslogSink) connect() error {
+	conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	return nil
+}
+
+func FormatSyslogMessage(hostname, appGuid string, log *events.LogMessage) string {
+	severity := syslogSeverityInfo
+	if log.GetMessageType() == events.LogMessage_ERR {
+		severity = syslogSeverityErr
+	}
+
+	appName := appGuid
+	if len(appName) > syslogAppNameLimit {
+		appName = appName[:syslogAppNameLimit]
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s [%s/%s] - - %s\n",
+		syslogFacilityUser*8+severity,
+		time.Unix(0, log.GetTimestamp()).UTC().Format(time.RFC3339Nano),
+		hostname,
+		appName,
+		log.GetSourceType(),
+		log.GetSourceInstance(),
+		log.GetMessage(),
+	)
+}
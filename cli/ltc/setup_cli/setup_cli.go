@@ -1,15 +1,18 @@
 package setup_cli
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-cf-experimental/lattice-cli/config"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/config_helpers"
+	"github.com/pivotal-cf-experimental/lattice-cli/config/credential_store"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/persister"
 	"github.com/pivotal-cf-experimental/lattice-cli/exit_handler"
 	"github.com/pivotal-golang/lager"
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/pivotal-cf-experimental/lattice-cli/cli_app_factory"
 	"github.com/pivotal-cf-experimental/lattice-cli/config/target_verifier"
@@ -18,12 +21,14 @@ import (
 )
 
 const (
-	latticeCliHomeVar = "LATTICE_CLI_HOME"
-	timeoutVar        = "LATTICE_CLI_TIMEOUT"
+	latticeCliHomeVar       = "LATTICE_CLI_HOME"
+	timeoutVar              = "LATTICE_CLI_TIMEOUT"
+	credentialHelperVar     = "LATTICE_CREDENTIAL_HELPER"
+	credentialPassphraseVar = "LATTICE_CREDENTIAL_PASSPHRASE"
 )
 
 func NewCliApp() *cli.App {
-	config := config.New(persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot())))
+	config := config.NewWithCredentialStore(persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot())), credentialStore())
 
 	signalChan := make(chan os.Signal)
 	signal.Notify(signalChan, os.Interrupt)
@@ -31,7 +36,7 @@ func NewCliApp() *cli.App {
 	go exitHandler.Run()
 
 	targetVerifier := target_verifier.New(receptor_client_factory.MakeReceptorClient)
-	app := cli_app_factory.MakeCliApp(os.Getenv(timeoutVar), ltcConfigRoot(), exitHandler, config, logger(), targetVerifier, output.New(os.Stdout))
+	app := cli_app_factory.MakeCliApp(os.Getenv(timeoutVar), ltcConfigRoot(), os.Args, exitHandler, config, logger(), targetVerifier, output.New(os.Stdout))
 	return app
 }
 
@@ -49,6 +54,29 @@ func logger() lager.Logger {
 	return logger
 }
 
+func credentialStore() credential_store.CredentialStore {
+	if helper := os.Getenv(credentialHelperVar); helper != "" {
+		return credential_store.NewHelperStore(credential_store.HelperPrefix + helper)
+	}
+
+	credentialsFile := config_helpers.CredentialsFileLocation(ltcConfigRoot())
+	if passphrase := os.Getenv(credentialPassphraseVar); passphrase != "" || !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return credential_store.NewEncryptedFileStore(credentialsFile, passphrase)
+	}
+
+	return credential_store.NewPromptingEncryptedFileStore(credentialsFile, promptForPassphrase)
+}
+
+// promptForPassphrase reads the credential passphrase from the terminal without echoing it.
+// The prompt goes to stderr so that it stays out of json and yaml output.
+func promptForPassphrase() (string, error) {
+	fmt.Fprint(os.Stderr, "Credential passphrase: ")
+	passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+
+	return string(passphrase), err
+}
+
 func ltcConfigRoot() string {
 	if os.Getenv(latticeCliHomeVar) != "" {
 		return os.Getenv(latticeCliHomeVar)
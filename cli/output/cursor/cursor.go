@@ -1,8 +1,17 @@
 package cursor
 
-import "fmt"
+import (
+	"fmt"
+	"io"
 
-const csi = "\033["
+	"github.com/docker/docker/pkg/term"
+)
+
+const (
+	csi = "\033["
+
+	DefaultTerminalWidth = 80
+)
 
 func Up(lines int) string {
 	return fmt.Sprintf("%s%dA", csi, lines)
@@ -23,3 +32,21 @@ func Show() string {
 func Hide() string {
 	return csi + "?25l"
 }
+
+// TerminalWidth is the number of columns of the terminal writer writes to, or
+// DefaultTerminalWidth when writer is not a terminal.
+func TerminalWidth(writer io.Writer) int {
+	file, ok := writer.(interface {
+		Fd() uintptr
+	})
+	if !ok || !term.IsTerminal(file.Fd()) {
+		return DefaultTerminalWidth
+	}
+
+	winsize, err := term.GetWinsize(file.Fd())
+	if err != nil || winsize.Width == 0 {
+		return DefaultTerminalWidth
+	}
+
+	return int(winsize.Width)
+}
@@ -3,6 +3,7 @@ package cursor_test
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 
 	"github.com/pivotal-cf-experimental/lattice-cli/output/cursor"
 )
@@ -37,4 +38,10 @@ var _ = Describe("cursor", func() {
 			Expect(cursor.Hide()).To(Equal("\033[?25l"))
 		})
 	})
+
+	Describe("TerminalWidth", func() {
+		It("falls back to the default width when not writing to a terminal", func() {
+			Expect(cursor.TerminalWidth(gbytes.NewBuffer())).To(Equal(cursor.DefaultTerminalWidth))
+		})
+	})
 })
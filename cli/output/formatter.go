@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+	YAMLFormat = "yaml"
+)
+
+type Formatter interface {
+	Format(value interface{}) ([]byte, error)
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(value interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type yamlFormatter struct{}
+
+func (yamlFormatter) Format(value interface{}) ([]byte, error) {
+	return yaml.Marshal(value)
+}
+
+func NewFormatter(format string) (Formatter, error) {
+	switch format {
+	case TextFormat, "":
+		return nil, nil
+	case JSONFormat:
+		return jsonFormatter{}, nil
+	case YAMLFormat:
+		return yamlFormatter{}, nil
+	}
+
+	return nil, fmt.Errorf("Invalid output format: %s. Must be one of %s, %s or %s", format, TextFormat, JSONFormat, YAMLFormat)
+}
@@ -1,15 +1,49 @@
 package output
 
 import (
+	"errors"
 	"io"
 )
 
+var ErrNoFormat = errors.New("No structured output format is set")
+
 func New(writer io.Writer) *Output {
-	return &Output{writer}
+	return &Output{Writer: writer}
 }
 
 type Output struct {
 	io.Writer
+	formatter Formatter
+}
+
+func (o *Output) SetFormat(format string) error {
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return err
+	}
+
+	o.formatter = formatter
+	return nil
+}
+
+func (o *Output) IsStructured() bool {
+	return o.formatter != nil
+}
+
+// SayFormatted writes value in the format given to SetFormat. Callers check
+// IsStructured first, since there is nothing to format a value with under text.
+func (o *Output) SayFormatted(value interface{}) error {
+	if o.formatter == nil {
+		return ErrNoFormat
+	}
+
+	data, err := o.formatter.Format(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.Write(data)
+	return err
 }
 
 func (o *Output) Say(message string) {
@@ -0,0 +1,73 @@
+package output_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf-experimental/lattice-cli/output"
+)
+
+var _ = Describe("Output", func() {
+	type widget struct {
+		Name  string   `json:"name" yaml:"name"`
+		Sizes []uint16 `json:"sizes" yaml:"sizes"`
+	}
+
+	var (
+		outputBuffer *gbytes.Buffer
+		out          *output.Output
+	)
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		out = output.New(outputBuffer)
+	})
+
+	Describe("SetFormat", func() {
+		It("defaults to unstructured text", func() {
+			Expect(out.IsStructured()).To(BeFalse())
+		})
+
+		It("accepts text, json and yaml", func() {
+			Expect(out.SetFormat(output.JSONFormat)).To(Succeed())
+			Expect(out.IsStructured()).To(BeTrue())
+
+			Expect(out.SetFormat(output.YAMLFormat)).To(Succeed())
+			Expect(out.IsStructured()).To(BeTrue())
+
+			Expect(out.SetFormat(output.TextFormat)).To(Succeed())
+			Expect(out.IsStructured()).To(BeFalse())
+		})
+
+		It("rejects unknown formats", func() {
+			err := out.SetFormat("xml")
+
+			Expect(err).To(MatchError("Invalid output format: xml. Must be one of text, json or yaml"))
+			Expect(out.IsStructured()).To(BeFalse())
+		})
+	})
+
+	Describe("SayFormatted", func() {
+		It("writes indented json", func() {
+			Expect(out.SetFormat(output.JSONFormat)).To(Succeed())
+
+			Expect(out.SayFormatted(widget{Name: "sprocket", Sizes: []uint16{8, 16}})).To(Succeed())
+
+			Expect(string(outputBuffer.Contents())).To(Equal("{\n  \"name\": \"sprocket\",\n  \"sizes\": [\n    8,\n    16\n  ]\n}\n"))
+		})
+
+		It("writes yaml", func() {
+			Expect(out.SetFormat(output.YAMLFormat)).To(Succeed())
+
+			Expect(out.SayFormatted(widget{Name: "sprocket", Sizes: []uint16{8, 16}})).To(Succeed())
+
+			Expect(string(outputBuffer.Contents())).To(Equal("name: sprocket\nsizes:\n- 8\n- 16\n"))
+		})
+
+		It("returns an error without a structured format", func() {
+			Expect(out.SayFormatted(widget{Name: "sprocket"})).To(Equal(output.ErrNoFormat))
+			Expect(outputBuffer.Contents()).To(BeEmpty())
+		})
+	})
+})
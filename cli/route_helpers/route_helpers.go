@@ -11,8 +11,8 @@ const AppRouter = "cf-router"
 type AppRoutes []AppRoute
 
 type AppRoute struct {
-	Hostnames []string `json:"hostnames"`
-	Port      uint16   `json:"port"`
+	Hostnames []string `json:"hostnames" yaml:"hostnames"`
+	Port      uint16   `json:"port" yaml:"port"`
 }
 
 func (l AppRoutes) RoutingInfo() receptor.RoutingInfo {